@@ -1,6 +1,7 @@
 package alphafoxtrot
 
 type Airport struct {
+	ID               uint64
 	ICAOCode         string
 	Type             string
 	Name             string
@@ -21,12 +22,20 @@ type Airport struct {
 	Runways          []Runway
 	Frequencies      []Frequency
 	Navaids          []Navaid
+	TimeZone         string
+	UTCOffsetHours   float64
+	DST              string
+	Origin           string
+	METAR            *METAR
+	FlightCategory   string
+	TAF              *TAF
 }
 
 type Frequency struct {
 	Type         string
 	Description  string
 	FrequencyMHZ float64
+	Origin       string
 }
 
 type Runway struct {
@@ -47,6 +56,7 @@ type Runway struct {
 	HighEndElevationFt          int64
 	HighEndHeadingDegT          float64
 	HighEndDisplacedThresholdFt int64
+	Origin                      string
 }
 
 type Region struct {
@@ -84,6 +94,7 @@ type Navaid struct {
 	UsageType            string
 	Power                string
 	AssociatedAirport    string
+	Origin               string
 }
 
 func NewAirport(airport *AirportData, region *RegionData, country *CountryData, frequencies []*FrequencyData, runways []*RunwayData, navaids []*NavaidData) *Airport {
@@ -91,6 +102,7 @@ func NewAirport(airport *AirportData, region *RegionData, country *CountryData,
 		return nil
 	}
 	aeroport := &Airport{
+		ID:               airport.ID,
 		ICAOCode:         airport.ICAOCode,
 		Type:             airport.Type,
 		Name:             airport.Name,
@@ -106,6 +118,10 @@ func NewAirport(airport *AirportData, region *RegionData, country *CountryData,
 		HomeLink:         airport.HomeLink,
 		WikipediaLink:    airport.WikipediaLink,
 		Keywords:         airport.Keywords,
+		TimeZone:         airport.TimeZone,
+		UTCOffsetHours:   airport.UTCOffsetHours,
+		DST:              airport.DST,
+		Origin:           airport.Origin,
 		Frequencies:      make([]Frequency, 0, len(frequencies)),
 		Runways:          make([]Runway, 0, len(runways)),
 		Navaids:          make([]Navaid, 0, len(navaids)),
@@ -152,6 +168,7 @@ func NewFrequency(frequency *FrequencyData) *Frequency {
 		Type:         frequency.Type,
 		Description:  frequency.Description,
 		FrequencyMHZ: frequency.FrequencyMHZ,
+		Origin:       frequency.Origin,
 	}
 }
 
@@ -174,6 +191,7 @@ func NewRunway(runway *RunwayData) *Runway {
 		HighEndElevationFt:          runway.HighEndElevationFt,
 		HighEndHeadingDegT:          runway.HighEndHeadingDegT,
 		HighEndDisplacedThresholdFt: runway.HighEndDisplacedThresholdFt,
+		Origin:                      runway.Origin,
 	}
 }
 func NewNavaid(navaid *NavaidData) *Navaid {
@@ -196,5 +214,6 @@ func NewNavaid(navaid *NavaidData) *Navaid {
 		UsageType:            navaid.UsageType,
 		Power:                navaid.Power,
 		AssociatedAirport:    navaid.AssociatedAirport,
+		Origin:               navaid.Origin,
 	}
 }
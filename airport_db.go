@@ -7,6 +7,8 @@ import (
 	"math"
 	"os"
 	"sort"
+	"strings"
+	"sync"
 )
 
 // https://ourairports.com/help/data-dictionary.html
@@ -54,10 +56,16 @@ type AirportData struct {
 	HomeLink         string
 	WikipediaLink    string
 	Keywords         string
+	TimeZone         string  // Olson tz name, e.g. America/Los_Angeles (OpenFlights only)
+	UTCOffsetHours   float64 // standard time (OpenFlights only)
+	DST              string  // OpenFlights DST zone: E, A, S, O, Z, N or U
+	Origin           string
 }
 
 type AirportDB struct {
-	Airports []*AirportData
+	Airports   []*AirportData
+	index      *SpatialIndex
+	indexMutex sync.Mutex
 }
 
 func NewAirportDB() *AirportDB {
@@ -68,6 +76,27 @@ func NewAirportDB() *AirportDB {
 
 func (db *AirportDB) Clear() {
 	db.Airports = nil
+	db.InvalidateIndex()
+}
+
+// SpatialIndex returns the index of the airports, it is built on first use and again after airports were added or removed
+func (db *AirportDB) SpatialIndex() *SpatialIndex {
+	db.indexMutex.Lock()
+	defer db.indexMutex.Unlock()
+	if db.index == nil || db.index.Len() != len(db.Airports) {
+		airports := db.Airports
+		db.index = NewSpatialIndex(len(airports), func(i int) (float64, float64) {
+			return airports[i].LatitudeDeg, airports[i].LongitudeDeg
+		})
+	}
+	return db.index
+}
+
+// InvalidateIndex must be called after the coordinates of airports were changed
+func (db *AirportDB) InvalidateIndex() {
+	db.indexMutex.Lock()
+	defer db.indexMutex.Unlock()
+	db.index = nil
 }
 
 func (db *AirportDB) Parse(file string, airportTypeFilter uint64, skipFirstLine bool) error {
@@ -76,8 +105,16 @@ func (db *AirportDB) Parse(file string, airportTypeFilter uint64, skipFirstLine
 		return err
 	}
 	defer f.Close()
+	defer db.InvalidateIndex()
+	return ReadAirports(f, airportTypeFilter, skipFirstLine, func(airport *AirportData) error {
+		db.Airports = append(db.Airports, airport)
+		return nil
+	})
+}
 
-	reader := csv.NewReader(f)
+// ReadAirports calls fn for every record of the csv and stops at the first error returned by fn
+func ReadAirports(r io.Reader, airportTypeFilter uint64, skipFirstLine bool, fn func(*AirportData) error) error {
+	reader := csv.NewReader(r)
 
 	line := -1
 	for {
@@ -155,8 +192,11 @@ func (db *AirportDB) Parse(file string, airportTypeFilter uint64, skipFirstLine
 			HomeLink:         homeLink,
 			WikipediaLink:    wikipediaLink,
 			Keywords:         keywords,
+			Origin:           OriginBase,
+		}
+		if err := fn(airport); err != nil {
+			return err
 		}
-		db.Airports = append(db.Airports, airport)
 	}
 }
 
@@ -172,12 +212,20 @@ func (db *AirportDB) FindByAirportType(airportTypeFilter uint64) []*AirportData
 }
 
 func (db *AirportDB) FindByICAOCode(icaoCode string) *AirportData {
-	for _, airport := range db.Airports {
+	if i := db.indexOfICAOCode(icaoCode); i >= 0 {
+		return db.Airports[i]
+	}
+	return nil
+}
+
+// indexOfICAOCode returns the index of the first airport with the ICAO code, -1 if there is none
+func (db *AirportDB) indexOfICAOCode(icaoCode string) int {
+	for i, airport := range db.Airports {
 		if airport.ICAOCode == icaoCode {
-			return airport
+			return i
 		}
 	}
-	return nil
+	return -1
 }
 
 func (db *AirportDB) FindByIATACode(iataCode string) *AirportData {
@@ -228,61 +276,59 @@ func (db *AirportDB) FindByContinent(continent string, airportTypeFilter uint64)
 	return airports
 }
 
-func (db *AirportDB) FindNearestAirport(latitudeDeg, longitudeDeg, radius float64, airportTypeFilter uint64) *AirportData {
-	var nearestAirport *AirportData = nil
-	minDistance := math.MaxFloat64
-
-	if radius < 0 {
-		radius = math.MaxFloat64
-	}
-
-	for _, airport := range db.Airports {
-		if airport.TypeFlag&airportTypeFilter == 0 {
-			continue
-		}
-		distance := Distance(latitudeDeg, longitudeDeg, airport.LatitudeDeg, airport.LongitudeDeg)
-		if distance < minDistance && distance <= radius {
-			minDistance = distance
-			nearestAirport = airport
-		}
-	}
-	return nearestAirport
-}
-
-func (db *AirportDB) FindNearestAirports(latitudeDeg, longitudeDeg, radiusMeters float64, maxResults int, airportTypeFilter uint64) []*AirportData {
-	if radiusMeters < 0 {
-		radiusMeters = math.MaxFloat64
-	}
+// Search returns the airports whose ICAO code, IATA code, GPS code, local code, name, municipality or keywords contain the query (case-insensitive).
+// Airports with a matching code are returned first.
+func (db *AirportDB) Search(query string, maxResults int, airportTypeFilter uint64) []*AirportData {
 	if maxResults < 0 {
 		maxResults = math.MaxInt32
 	}
-
-	type Candidate struct {
-		Airport  *AirportData
-		Distance float64
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return make([]*AirportData, 0)
 	}
 
-	candidates := make([]*Candidate, 0)
+	codeMatches := make([]*AirportData, 0)
+	textMatches := make([]*AirportData, 0)
 	for _, airport := range db.Airports {
 		if airport.TypeFlag&airportTypeFilter == 0 {
 			continue
 		}
-		distance := Distance(latitudeDeg, longitudeDeg, airport.LatitudeDeg, airport.LongitudeDeg)
-		if distance <= radiusMeters {
-			candidates = append(candidates, &Candidate{airport, distance})
+		if strings.ToLower(airport.ICAOCode) == query || strings.ToLower(airport.IATACode) == query ||
+			strings.ToLower(airport.GPSCode) == query || strings.ToLower(airport.LocalCode) == query {
+			codeMatches = append(codeMatches, airport)
+			continue
+		}
+		if strings.Contains(strings.ToLower(airport.Name), query) ||
+			strings.Contains(strings.ToLower(airport.Municipality), query) ||
+			strings.Contains(strings.ToLower(airport.Keywords), query) {
+			textMatches = append(textMatches, airport)
 		}
 	}
+	airports := append(codeMatches, textMatches...)
+	return airports[:MinInt(len(airports), maxResults)]
+}
 
-	sort.Slice(candidates, func(i, j int) bool {
-		return candidates[i].Distance < candidates[j].Distance
+func (db *AirportDB) FindNearestAirport(latitudeDeg, longitudeDeg, radius float64, airportTypeFilter uint64) *AirportData {
+	airports := db.Airports
+	item, _ := db.SpatialIndex().Nearest(latitudeDeg, longitudeDeg, radius, func(i int) bool {
+		return airports[i].TypeFlag&airportTypeFilter != 0
 	})
+	if item < 0 {
+		return nil
+	}
+	return airports[item]
+}
 
-	airports := make([]*AirportData, 0, len(candidates))
-	count := MinInt(len(candidates), maxResults)
-	for i := 0; i < count; i++ {
-		airports = append(airports, candidates[i].Airport)
+func (db *AirportDB) FindNearestAirports(latitudeDeg, longitudeDeg, radiusMeters float64, maxResults int, airportTypeFilter uint64) []*AirportData {
+	airports := db.Airports
+	neighbors := db.SpatialIndex().Search(latitudeDeg, longitudeDeg, radiusMeters, maxResults, func(i int) bool {
+		return airports[i].TypeFlag&airportTypeFilter != 0
+	})
+	result := make([]*AirportData, 0, len(neighbors))
+	for _, neighbor := range neighbors {
+		result = append(result, airports[neighbor.Item])
 	}
-	return airports
+	return result
 }
 
 func (db *AirportDB) FindAll(isoRegionFilter string, isoCountryFilter string, continentFilter string, airportTypeFilter uint64) []*AirportData {
@@ -2,16 +2,23 @@ package alphafoxtrot
 
 import (
 	"fmt"
+	"math"
 	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+	"unsafe"
 )
 
 type AirportFinder struct {
+	mutex       sync.RWMutex
 	airportDB   *AirportDB
 	frequencyDB *FrequencyDB
 	runwayDB    *RunwayDB
 	regionDB    *RegionDB
 	countryDB   *CountryDB
 	navaidDB    *NavaidDB
+	weatherDB   *WeatherDB
 }
 
 type LoadOptions struct {
@@ -21,6 +28,7 @@ type LoadOptions struct {
 	RegionsFilename     string // optional, usually: regions.csv
 	CountriesFilename   string // optional, usually: countries.csv
 	NavaidsFilename     string // optional, usually: navaids.csv
+	OverridesFilename   string // optional, JSON or CSV patch file with local overrides (see Patch)
 }
 
 func PresetLoadOptions(baseDir string) *LoadOptions {
@@ -42,16 +50,20 @@ func NewAirportFinder() *AirportFinder {
 		regionDB:    NewRegionDB(),
 		countryDB:   NewCountryDB(),
 		navaidDB:    NewNavaidDB(),
+		weatherDB:   NewWeatherDB(),
 	}
 }
 
 func Clear(af *AirportFinder) {
+	af.mutex.Lock()
+	defer af.mutex.Unlock()
 	af.airportDB.Clear()
 	af.frequencyDB.Clear()
 	af.runwayDB.Clear()
 	af.regionDB.Clear()
 	af.countryDB.Clear()
 	af.navaidDB.Clear()
+	af.weatherDB.Clear()
 }
 
 func (af *AirportFinder) Load(options *LoadOptions, airportFilter uint64) []error {
@@ -63,7 +75,15 @@ func (af *AirportFinder) Load(options *LoadOptions, airportFilter uint64) []erro
 		return append(errors, fmt.Errorf("cannot load airports: invalid filename"))
 	}
 
-	if err := af.airportDB.Parse(options.AirportsFilename, airportFilter, true); err != nil {
+	af.mutex.Lock()
+	defer af.mutex.Unlock()
+
+	// patches may change the type of airports, so the filter is applied after patching
+	parseFilter := airportFilter
+	if options.OverridesFilename != "" {
+		parseFilter = AirportTypeAll
+	}
+	if err := af.airportDB.Parse(options.AirportsFilename, parseFilter, true); err != nil {
 		errors = append(errors, err)
 	}
 	if options.FrequenciesFilename != "" {
@@ -91,10 +111,19 @@ func (af *AirportFinder) Load(options *LoadOptions, airportFilter uint64) []erro
 			errors = append(errors, err)
 		}
 	}
+	if options.OverridesFilename != "" {
+		patches, err := ParsePatchFile(options.OverridesFilename)
+		if err != nil {
+			errors = append(errors, err)
+		}
+		errors = append(errors, af.applyPatches(patches, airportFilter)...)
+	}
 	return errors
 }
 
 func (af *AirportFinder) FindAirportByType(airportTypeFilter uint64) []*Airport {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
 	airportsByType := af.airportDB.FindByAirportType(airportTypeFilter)
 	airports := make([]*Airport, 0, len(airportsByType))
 	for _, airport := range airportsByType {
@@ -104,21 +133,29 @@ func (af *AirportFinder) FindAirportByType(airportTypeFilter uint64) []*Airport
 }
 
 func (af *AirportFinder) FindAirportByICAOCode(icaoCode string) *Airport {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
 	airport := af.airportDB.FindByICAOCode(icaoCode)
 	return af.makeAirport(airport)
 }
 
 func (af *AirportFinder) FindAirportByIATACode(iataCode string) *Airport {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
 	airport := af.airportDB.FindByIATACode(iataCode)
 	return af.makeAirport(airport)
 }
 
 func (af *AirportFinder) FindNearestAirport(latitudeDeg, longitudeDeg, radiusMeters float64, airportTypeFilter uint64) *Airport {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
 	nearestAirport := af.airportDB.FindNearestAirport(latitudeDeg, longitudeDeg, radiusMeters, airportTypeFilter)
 	return af.makeAirport(nearestAirport)
 }
 
 func (af *AirportFinder) FindNearestAirports(latitudeDeg, longitudeDeg, radiusMeters float64, maxResults int, airportTypeFilter uint64) []*Airport {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
 	nearestAirports := af.airportDB.FindNearestAirports(latitudeDeg, longitudeDeg, radiusMeters, maxResults, airportTypeFilter)
 	airports := make([]*Airport, 0, len(nearestAirports))
 	for _, airport := range nearestAirports {
@@ -128,6 +165,8 @@ func (af *AirportFinder) FindNearestAirports(latitudeDeg, longitudeDeg, radiusMe
 }
 
 func (af *AirportFinder) FindNearestAirportsByRegion(isoRegion string, latitudeDeg, longitudeDeg, radiusMeters float64, maxResults int, airportTypeFilter uint64) []*Airport {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
 	airportsByRegion := af.airportDB.FindByRegion(isoRegion, airportTypeFilter)
 	airportsByRegion = FindNearestAirports(airportsByRegion, latitudeDeg, longitudeDeg, radiusMeters, maxResults)
 	airports := make([]*Airport, 0, len(airportsByRegion))
@@ -138,6 +177,8 @@ func (af *AirportFinder) FindNearestAirportsByRegion(isoRegion string, latitudeD
 }
 
 func (af *AirportFinder) FindNearestAirportsByCountry(isoCountry string, latitudeDeg, longitudeDeg, radiusMeters float64, maxResults int, airportTypeFilter uint64) []*Airport {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
 	airportsByCountry := af.airportDB.FindByCountry(isoCountry, airportTypeFilter)
 	airportsByCountry = FindNearestAirports(airportsByCountry, latitudeDeg, longitudeDeg, radiusMeters, maxResults)
 	airports := make([]*Airport, 0, len(airportsByCountry))
@@ -148,6 +189,8 @@ func (af *AirportFinder) FindNearestAirportsByCountry(isoCountry string, latitud
 }
 
 func (af *AirportFinder) FindNearestNavaids(latitudeDeg, longitudeDeg, radiusMeters float64, maxResults int) []*Navaid {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
 	nearestNavaids := af.navaidDB.FindNearestNavaids(latitudeDeg, longitudeDeg, radiusMeters, maxResults)
 	navaids := make([]*Navaid, 0, len(nearestNavaids))
 	for _, navaid := range nearestNavaids {
@@ -157,6 +200,8 @@ func (af *AirportFinder) FindNearestNavaids(latitudeDeg, longitudeDeg, radiusMet
 }
 
 func (af *AirportFinder) FindNavaidsByAirportICAOCode(icaoCode string) []*Navaid {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
 	associatedNavaids := af.navaidDB.FindByAirportICAOCode(icaoCode)
 	navaids := make([]*Navaid, 0, len(associatedNavaids))
 	for _, navaid := range associatedNavaids {
@@ -166,6 +211,8 @@ func (af *AirportFinder) FindNavaidsByAirportICAOCode(icaoCode string) []*Navaid
 }
 
 func (af *AirportFinder) FindAllAirports(isoRegionFilter, isoCountryFilter, continentFilter string, airportTypeFilter uint64) []*Airport {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
 	filteredAirports := af.airportDB.FindAll(isoRegionFilter, isoCountryFilter, continentFilter, airportTypeFilter)
 	airports := make([]*Airport, 0, len(filteredAirports))
 	for _, airport := range filteredAirports {
@@ -174,7 +221,62 @@ func (af *AirportFinder) FindAllAirports(isoRegionFilter, isoCountryFilter, cont
 	return airports
 }
 
+func (af *AirportFinder) SearchAirports(query string, maxResults int, airportTypeFilter uint64) []*Airport {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
+	foundAirports := af.airportDB.Search(query, maxResults, airportTypeFilter)
+	airports := make([]*Airport, 0, len(foundAirports))
+	for _, airport := range foundAirports {
+		airports = append(airports, af.makeAirport(airport))
+	}
+	return airports
+}
+
+func (af *AirportFinder) FindAllRegions(isoCountryFilter string) []*Region {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
+	filterCountry := len(isoCountryFilter) > 0
+	regionData := make([]*RegionData, 0, len(af.regionDB.Regions))
+	for _, region := range af.regionDB.Regions {
+		if filterCountry && region.ISOCountry != isoCountryFilter {
+			continue
+		}
+		regionData = append(regionData, region)
+	}
+	sort.Slice(regionData, func(i, j int) bool {
+		return regionData[i].ISOCode < regionData[j].ISOCode
+	})
+	regions := make([]*Region, 0, len(regionData))
+	for _, region := range regionData {
+		regions = append(regions, NewRegion(region))
+	}
+	return regions
+}
+
+func (af *AirportFinder) FindAllCountries(continentFilter string) []*Country {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
+	filterContinent := len(continentFilter) > 0
+	countryData := make([]*CountryData, 0, len(af.countryDB.Countries))
+	for _, country := range af.countryDB.Countries {
+		if filterContinent && country.Continent != continentFilter {
+			continue
+		}
+		countryData = append(countryData, country)
+	}
+	sort.Slice(countryData, func(i, j int) bool {
+		return countryData[i].ISOCode < countryData[j].ISOCode
+	})
+	countries := make([]*Country, 0, len(countryData))
+	for _, country := range countryData {
+		countries = append(countries, NewCountry(country))
+	}
+	return countries
+}
+
 func (af *AirportFinder) FindAllNavaids(isoCountryFilter string) []*Navaid {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
 	filterCountry := len(isoCountryFilter) > 0
 	navaids := make([]*Navaid, 0, len(af.navaidDB.Navaids))
 	if af.navaidDB != nil {
@@ -188,6 +290,78 @@ func (af *AirportFinder) FindAllNavaids(isoCountryFilter string) []*Navaid {
 	return navaids
 }
 
+// Databases gives access to the raw databases of a finder, e.g. for exporters and importers
+type Databases struct {
+	Airports    *AirportDB
+	Frequencies *FrequencyDB
+	Runways     *RunwayDB
+	Regions     *RegionDB
+	Countries   *CountryDB
+	Navaids     *NavaidDB
+}
+
+func NewAirportFinderWithDatabases(databases *Databases) *AirportFinder {
+	af := NewAirportFinder()
+	if databases.Airports != nil {
+		af.airportDB = databases.Airports
+	}
+	if databases.Frequencies != nil {
+		af.frequencyDB = databases.Frequencies
+	}
+	if databases.Runways != nil {
+		af.runwayDB = databases.Runways
+	}
+	if databases.Regions != nil {
+		af.regionDB = databases.Regions
+	}
+	if databases.Countries != nil {
+		af.countryDB = databases.Countries
+	}
+	if databases.Navaids != nil {
+		af.navaidDB = databases.Navaids
+	}
+	return af
+}
+
+// Databases returns the databases of the finder, they must not be modified.
+// Use Swap instead of Load to replace the data of a finder which is shared with readers of its databases.
+func (af *AirportFinder) Databases() *Databases {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
+	return &Databases{
+		Airports:    af.airportDB,
+		Frequencies: af.frequencyDB,
+		Runways:     af.runwayDB,
+		Regions:     af.regionDB,
+		Countries:   af.countryDB,
+		Navaids:     af.navaidDB,
+	}
+}
+
+// Swap replaces the data of the finder with the data of the other finder, which is left empty.
+// It is safe to call Swap while other goroutines are querying the finder.
+func (af *AirportFinder) Swap(other *AirportFinder) {
+	if af == other {
+		return
+	}
+	// lock in the order of the addresses so that a.Swap(b) and b.Swap(a) cannot deadlock
+	first, second := af, other
+	if uintptr(unsafe.Pointer(second)) < uintptr(unsafe.Pointer(first)) {
+		first, second = second, first
+	}
+	first.mutex.Lock()
+	defer first.mutex.Unlock()
+	second.mutex.Lock()
+	defer second.mutex.Unlock()
+
+	af.airportDB, other.airportDB = other.airportDB, NewAirportDB()
+	af.frequencyDB, other.frequencyDB = other.frequencyDB, NewFrequencyDB()
+	af.runwayDB, other.runwayDB = other.runwayDB, NewRunwayDB()
+	af.regionDB, other.regionDB = other.regionDB, NewRegionDB()
+	af.countryDB, other.countryDB = other.countryDB, NewCountryDB()
+	af.navaidDB, other.navaidDB = other.navaidDB, NewNavaidDB()
+}
+
 func (af *AirportFinder) makeAirport(airport *AirportData) *Airport {
 	if airport == nil {
 		return nil
@@ -197,5 +371,87 @@ func (af *AirportFinder) makeAirport(airport *AirportData) *Airport {
 	region := af.regionDB.FindByISOCode(airport.ISORegion)
 	country := af.countryDB.FindByISOCode(airport.ISOCountry)
 	navaids := af.navaidDB.FindByAirportICAOCode(airport.ICAOCode)
-	return NewAirport(airport, region, country, frequencies, runways, navaids)
+	aeroport := NewAirport(airport, region, country, frequencies, runways, navaids)
+	if metar := af.findMETAR(airport); metar != nil {
+		aeroport.METAR = metar
+		aeroport.FlightCategory = metar.FlightCategory()
+	}
+	aeroport.TAF = af.findTAF(airport)
+	if aeroport.TimeZone == "" {
+		aeroport.TimeZone = ResolveTimeZone(airport.LatitudeDeg, airport.LongitudeDeg, airport.ISOCountry, airport.ISORegion)
+	}
+	return aeroport
+}
+
+func (af *AirportFinder) findMETAR(airport *AirportData) *METAR {
+	if metar := af.weatherDB.FindMETARByICAOCode(airport.ICAOCode); metar != nil {
+		return metar
+	}
+	if airport.GPSCode != "" && airport.GPSCode != airport.ICAOCode {
+		return af.weatherDB.FindMETARByICAOCode(airport.GPSCode)
+	}
+	return nil
+}
+
+func (af *AirportFinder) findTAF(airport *AirportData) *TAF {
+	if taf := af.weatherDB.FindTAFByICAOCode(airport.ICAOCode); taf != nil {
+		return taf
+	}
+	if airport.GPSCode != "" && airport.GPSCode != airport.ICAOCode {
+		return af.weatherDB.FindTAFByICAOCode(airport.GPSCode)
+	}
+	return nil
+}
+
+// FindForecast returns the conditions forecast at the airport at the time, nil if there is no TAF valid at that time
+func (af *AirportFinder) FindForecast(icaoCode string, at time.Time) *Forecast {
+	airport := af.FindAirportByICAOCode(icaoCode)
+	if airport == nil || airport.TAF == nil {
+		return nil
+	}
+	return airport.TAF.ForecastAt(at)
+}
+
+// SetWeatherDB sets the weather joined into the airports returned by the finder
+func (af *AirportFinder) SetWeatherDB(weatherDB *WeatherDB) {
+	af.mutex.Lock()
+	defer af.mutex.Unlock()
+	af.weatherDB = weatherDB
+}
+
+func (af *AirportFinder) WeatherDB() *WeatherDB {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
+	return af.weatherDB
+}
+
+// FindNearestAirportsByFlightCategory returns the nearest airports whose latest METAR reports the flight category
+func (af *AirportFinder) FindNearestAirportsByFlightCategory(flightCategory string, latitudeDeg, longitudeDeg, radiusMeters float64, maxResults int, airportTypeFilter uint64) []*Airport {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
+	if maxResults < 0 {
+		maxResults = math.MaxInt32
+	}
+	nearestAirports := af.airportDB.FindNearestAirports(latitudeDeg, longitudeDeg, radiusMeters, -1, airportTypeFilter)
+	airports := make([]*Airport, 0)
+	for _, airport := range nearestAirports {
+		if len(airports) >= maxResults {
+			break
+		}
+		metar := af.findMETAR(airport)
+		if metar == nil || metar.FlightCategory() != flightCategory {
+			continue
+		}
+		airports = append(airports, af.makeAirport(airport))
+	}
+	return airports
+}
+
+// FindNearestVFRAirport returns the nearest airport reporting VFR conditions
+func (af *AirportFinder) FindNearestVFRAirport(latitudeDeg, longitudeDeg, radiusMeters float64, airportTypeFilter uint64) *Airport {
+	airports := af.FindNearestAirportsByFlightCategory(FlightCategoryVFR, latitudeDeg, longitudeDeg, radiusMeters, 1, airportTypeFilter)
+	if len(airports) == 0 {
+		return nil
+	}
+	return airports[0]
 }
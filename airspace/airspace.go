@@ -0,0 +1,278 @@
+package airspace
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	alphafoxtrot "github.com/grumpypixel/go-airport-finder"
+)
+
+// maxSegmentMeters is the length route legs are split into before they are tested against airspace boundaries
+const maxSegmentMeters = 5000.0
+
+const (
+	ReferenceMSL         = "MSL"
+	ReferenceAGL         = "AGL"
+	ReferenceFlightLevel = "FL"
+	ReferenceSurface     = "SFC"
+	ReferenceUnlimited   = "UNL"
+)
+
+type Point struct {
+	LatitudeDeg  float64
+	LongitudeDeg float64
+}
+
+// Limit is a vertical airspace limit, Value is in feet or in hundreds of feet for flight levels
+type Limit struct {
+	Reference string
+	Value     float64
+	Raw       string
+}
+
+// Feet returns the limit in feet MSL, flight levels are treated as pressure altitudes
+func (l Limit) Feet(groundElevationFt float64) float64 {
+	switch l.Reference {
+	case ReferenceUnlimited:
+		return math.Inf(1)
+	case ReferenceSurface:
+		return groundElevationFt
+	case ReferenceAGL:
+		return groundElevationFt + l.Value
+	case ReferenceFlightLevel:
+		return l.Value * 100
+	}
+	return l.Value
+}
+
+type Airspace struct {
+	Class   string
+	Name    string
+	Lower   Limit
+	Upper   Limit
+	Polygon []Point
+
+	minLat, maxLat, minLon, maxLon float64
+}
+
+// Contains returns true if the position is inside the lateral boundary
+func (a *Airspace) Contains(latitudeDeg, longitudeDeg float64) bool {
+	if latitudeDeg < a.minLat || latitudeDeg > a.maxLat || longitudeDeg < a.minLon || longitudeDeg > a.maxLon {
+		return false
+	}
+	inside := false
+	for i, j := 0, len(a.Polygon)-1; i < len(a.Polygon); j, i = i, i+1 {
+		pi, pj := a.Polygon[i], a.Polygon[j]
+		if (pi.LatitudeDeg > latitudeDeg) != (pj.LatitudeDeg > latitudeDeg) {
+			lon := pi.LongitudeDeg + (latitudeDeg-pi.LatitudeDeg)*(pj.LongitudeDeg-pi.LongitudeDeg)/(pj.LatitudeDeg-pi.LatitudeDeg)
+			if longitudeDeg < lon {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// ContainsAltitude returns true if the altitude (ft MSL) is between the lower and upper limit
+func (a *Airspace) ContainsAltitude(altitudeFt, groundElevationFt float64) bool {
+	return altitudeFt >= a.Lower.Feet(groundElevationFt) && altitudeFt <= a.Upper.Feet(groundElevationFt)
+}
+
+// IsSurface returns true if the airspace starts at the ground
+func (a *Airspace) IsSurface() bool {
+	return a.Lower.Reference == ReferenceSurface || (a.Lower.Reference == ReferenceAGL && a.Lower.Value == 0)
+}
+
+type DB struct {
+	Airspaces []*Airspace
+}
+
+func NewDB() *DB {
+	return &DB{Airspaces: make([]*Airspace, 0)}
+}
+
+func (db *DB) add(airspace *Airspace) {
+	airspace.minLat, airspace.maxLat = math.Inf(1), math.Inf(-1)
+	airspace.minLon, airspace.maxLon = math.Inf(1), math.Inf(-1)
+	for _, point := range airspace.Polygon {
+		airspace.minLat = math.Min(airspace.minLat, point.LatitudeDeg)
+		airspace.maxLat = math.Max(airspace.maxLat, point.LatitudeDeg)
+		airspace.minLon = math.Min(airspace.minLon, point.LongitudeDeg)
+		airspace.maxLon = math.Max(airspace.maxLon, point.LongitudeDeg)
+	}
+	db.Airspaces = append(db.Airspaces, airspace)
+}
+
+// FindContaining returns the airspaces containing the position at the given altitude (ft MSL)
+func (db *DB) FindContaining(latitudeDeg, longitudeDeg, altitudeFt, groundElevationFt float64) []*Airspace {
+	airspaces := make([]*Airspace, 0)
+	for _, airspace := range db.Airspaces {
+		if airspace.Contains(latitudeDeg, longitudeDeg) && airspace.ContainsAltitude(altitudeFt, groundElevationFt) {
+			airspaces = append(airspaces, airspace)
+		}
+	}
+	return airspaces
+}
+
+// Crossing is an airspace entered by a route, DistanceMeters is measured along the route from its start to the first contact
+type Crossing struct {
+	Airspace       *Airspace
+	Leg            int
+	DistanceMeters float64
+}
+
+// FindCrossed returns the airspaces whose lateral boundaries are touched by the route, ordered by distance along the route
+func (db *DB) FindCrossed(route []Point) []Crossing {
+	crossings := make([]Crossing, 0)
+	if len(route) == 0 {
+		return crossings
+	}
+	segments := densify(route)
+	for _, airspace := range db.Airspaces {
+		for _, segment := range segments {
+			if distance, ok := segment.contact(airspace); ok {
+				crossings = append(crossings, Crossing{Airspace: airspace, Leg: segment.leg, DistanceMeters: distance})
+				break
+			}
+		}
+	}
+	sort.SliceStable(crossings, func(i, j int) bool {
+		return crossings[i].DistanceMeters < crossings[j].DistanceMeters
+	})
+	return crossings
+}
+
+type segment struct {
+	from, to       Point
+	leg            int
+	startMeters    float64
+	lengthMeters   float64
+	minLat, maxLat float64
+	minLon, maxLon float64
+}
+
+// densify splits the route legs along the great circle, so they can be treated as straight lines in lat/lon
+func densify(route []Point) []segment {
+	segments := make([]segment, 0)
+	if len(route) == 1 {
+		return append(segments, newSegment(route[0], route[0], 0, 0))
+	}
+	total := 0.0
+	for leg := 0; leg+1 < len(route); leg++ {
+		from, to := route[leg], route[leg+1]
+		length := alphafoxtrot.Distance(from.LatitudeDeg, from.LongitudeDeg, to.LatitudeDeg, to.LongitudeDeg)
+		bearing := alphafoxtrot.Bearing(from.LatitudeDeg, from.LongitudeDeg, to.LatitudeDeg, to.LongitudeDeg)
+		steps := int(math.Ceil(length / maxSegmentMeters))
+		if steps < 1 {
+			steps = 1
+		}
+		previous := from
+		for i := 1; i <= steps; i++ {
+			next := to
+			if i < steps {
+				lat, lon := alphafoxtrot.Destination(from.LatitudeDeg, from.LongitudeDeg, bearing, length*float64(i)/float64(steps))
+				next = Point{LatitudeDeg: lat, LongitudeDeg: lon}
+			}
+			segments = append(segments, newSegment(previous, next, leg, total+length*float64(i-1)/float64(steps)))
+			segments[len(segments)-1].lengthMeters = length / float64(steps)
+			previous = next
+		}
+		total += length
+	}
+	return segments
+}
+
+func newSegment(from, to Point, leg int, startMeters float64) segment {
+	return segment{
+		from:        from,
+		to:          to,
+		leg:         leg,
+		startMeters: startMeters,
+		minLat:      math.Min(from.LatitudeDeg, to.LatitudeDeg),
+		maxLat:      math.Max(from.LatitudeDeg, to.LatitudeDeg),
+		minLon:      math.Min(from.LongitudeDeg, to.LongitudeDeg),
+		maxLon:      math.Max(from.LongitudeDeg, to.LongitudeDeg),
+	}
+}
+
+// contact returns the route distance where the segment first touches the airspace
+func (s segment) contact(airspace *Airspace) (float64, bool) {
+	if s.maxLat < airspace.minLat || s.minLat > airspace.maxLat || s.maxLon < airspace.minLon || s.minLon > airspace.maxLon {
+		return 0, false
+	}
+	if airspace.Contains(s.from.LatitudeDeg, s.from.LongitudeDeg) {
+		return s.startMeters, true
+	}
+	first, found := 1.0, false
+	for i, j := 0, len(airspace.Polygon)-1; i < len(airspace.Polygon); j, i = i, i+1 {
+		if t, ok := intersect(s.from, s.to, airspace.Polygon[j], airspace.Polygon[i]); ok && t <= first {
+			first, found = t, true
+		}
+	}
+	if !found {
+		return 0, false
+	}
+	return s.startMeters + first*s.lengthMeters, true
+}
+
+// intersect returns the position (0..1) on a-b where it intersects c-d
+func intersect(a, b, c, d Point) (float64, bool) {
+	rx, ry := b.LongitudeDeg-a.LongitudeDeg, b.LatitudeDeg-a.LatitudeDeg
+	sx, sy := d.LongitudeDeg-c.LongitudeDeg, d.LatitudeDeg-c.LatitudeDeg
+	denominator := rx*sy - ry*sx
+	if denominator == 0 {
+		return 0, false
+	}
+	qx, qy := c.LongitudeDeg-a.LongitudeDeg, c.LatitudeDeg-a.LatitudeDeg
+	t := (qx*sy - qy*sx) / denominator
+	u := (qx*ry - qy*rx) / denominator
+	if t < 0 || t > 1 || u < 0 || u > 1 {
+		return 0, false
+	}
+	return t, true
+}
+
+// controlledClasses are the airspace classes that form a control zone around towered airports
+var controlledClasses = map[string]bool{"B": true, "C": true, "D": true, "CTR": true}
+
+type AirportAirspace struct {
+	Airport     *alphafoxtrot.Airport
+	Towered     bool
+	Airspaces   []*Airspace
+	ControlZone *Airspace
+}
+
+// ForAirport returns the airspaces above the airport; an airport is towered if it has a TWR frequency
+func (db *DB) ForAirport(airport *alphafoxtrot.Airport) *AirportAirspace {
+	result := &AirportAirspace{Airport: airport, Airspaces: make([]*Airspace, 0)}
+	for _, frequency := range airport.Frequencies {
+		if strings.EqualFold(frequency.Type, "TWR") {
+			result.Towered = true
+			break
+		}
+	}
+	ground := float64(airport.ElevationFt)
+	for _, airspace := range db.Airspaces {
+		if !airspace.Contains(airport.LatitudeDeg, airport.LongitudeDeg) {
+			continue
+		}
+		result.Airspaces = append(result.Airspaces, airspace)
+		if result.ControlZone == nil && airspace.IsSurface() && controlledClasses[airspace.Class] && airspace.ContainsAltitude(ground, ground) {
+			result.ControlZone = airspace
+		}
+	}
+	sort.SliceStable(result.Airspaces, func(i, j int) bool {
+		return result.Airspaces[i].Lower.Feet(ground) < result.Airspaces[j].Lower.Feet(ground)
+	})
+	return result
+}
+
+// LinkAirports links each airport to the airspaces it sits under
+func (db *DB) LinkAirports(airports []*alphafoxtrot.Airport) []*AirportAirspace {
+	links := make([]*AirportAirspace, 0, len(airports))
+	for _, airport := range airports {
+		links = append(links, db.ForAirport(airport))
+	}
+	return links
+}
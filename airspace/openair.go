@@ -0,0 +1,293 @@
+package airspace
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	alphafoxtrot "github.com/grumpypixel/go-airport-finder"
+)
+
+// see http://www.winpilot.com/UsersGuide/UserAirspace.asp
+// e.g.
+// AC D
+// AN SANTA MONICA CLASS D
+// AL SFC
+// AH 2500ft MSL
+// V X=34:00:57 N 118:27:04 W
+// DC 4.3
+
+const (
+	arcStepDeg   = 5.0
+	nauticalMile = 1852.0
+)
+
+var coordinatePattern = regexp.MustCompile(`^\s*(\d+):(\d+(?:\.\d+)?)(?::(\d+(?:\.\d+)?))?\s*([NSns])\s*,?\s*(\d+):(\d+(?:\.\d+)?)(?::(\d+(?:\.\d+)?))?\s*([EWew])`)
+
+var limitPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)(FT|F|M)?(MSL|AMSL|ASL|AGL|AAL|GND|SFC)?$`)
+
+// Parse reads an OpenAir file into the database, invalid records are logged and skipped
+func (db *DB) Parse(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return db.Read(f)
+}
+
+// Read reads OpenAir records from the reader
+func (db *DB) Read(r io.Reader) error {
+	parser := &openAirParser{clockwise: true}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "*") {
+			continue
+		}
+		if err := parser.parseLine(text); err != nil {
+			log.Println(line, err)
+		}
+		if parser.done != nil {
+			db.add(parser.done)
+			parser.done = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if airspace := parser.finish(); airspace != nil {
+		db.add(airspace)
+	}
+	return nil
+}
+
+type openAirParser struct {
+	current   *Airspace
+	done      *Airspace
+	center    *Point
+	clockwise bool
+}
+
+func (p *openAirParser) finish() *Airspace {
+	airspace := p.current
+	p.current = nil
+	p.center = nil
+	p.clockwise = true
+	if airspace == nil || len(airspace.Polygon) < 3 {
+		return nil
+	}
+	return airspace
+}
+
+func (p *openAirParser) parseLine(text string) error {
+	command, args := text, ""
+	if i := strings.IndexAny(text, " \t"); i >= 0 {
+		command, args = text[:i], strings.TrimSpace(text[i+1:])
+	}
+	command = strings.ToUpper(command)
+
+	if command == "AC" {
+		p.done = p.finish()
+		p.current = &Airspace{Class: strings.ToUpper(args)}
+		return nil
+	}
+	if p.current == nil {
+		return nil
+	}
+
+	switch command {
+	case "AN":
+		p.current.Name = args
+	case "AL":
+		limit, err := ParseLimit(args)
+		if err != nil {
+			return err
+		}
+		p.current.Lower = limit
+	case "AH":
+		limit, err := ParseLimit(args)
+		if err != nil {
+			return err
+		}
+		p.current.Upper = limit
+	case "V":
+		return p.parseVariable(args)
+	case "DP":
+		point, err := ParseCoordinate(args)
+		if err != nil {
+			return err
+		}
+		p.current.Polygon = append(p.current.Polygon, point)
+	case "DA":
+		return p.parseArc(args)
+	case "DB":
+		return p.parseArcBetween(args)
+	case "DC":
+		if p.center == nil {
+			return fmt.Errorf("openair: DC without center")
+		}
+		radius, err := strconv.ParseFloat(strings.TrimSpace(args), 64)
+		if err != nil {
+			return fmt.Errorf("openair: invalid radius %q", args)
+		}
+		p.current.Polygon = append(p.current.Polygon, arcPoints(*p.center, radius*nauticalMile, 0, 360, true)...)
+	}
+	// AT, SP, SB, DY and unknown records are ignored
+	return nil
+}
+
+func (p *openAirParser) parseVariable(args string) error {
+	parts := strings.SplitN(args, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("openair: invalid variable %q", args)
+	}
+	switch strings.ToUpper(strings.TrimSpace(parts[0])) {
+	case "X":
+		center, err := ParseCoordinate(parts[1])
+		if err != nil {
+			return err
+		}
+		p.center = &center
+	case "D":
+		p.clockwise = strings.TrimSpace(parts[1]) != "-"
+	}
+	return nil
+}
+
+// DA radius, start angle, end angle
+func (p *openAirParser) parseArc(args string) error {
+	if p.center == nil {
+		return fmt.Errorf("openair: DA without center")
+	}
+	values := strings.Split(args, ",")
+	if len(values) != 3 {
+		return fmt.Errorf("openair: invalid arc %q", args)
+	}
+	numbers := make([]float64, 3)
+	for i, value := range values {
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return fmt.Errorf("openair: invalid arc %q", args)
+		}
+		numbers[i] = number
+	}
+	p.current.Polygon = append(p.current.Polygon, arcPoints(*p.center, numbers[0]*nauticalMile, numbers[1], numbers[2], p.clockwise)...)
+	return nil
+}
+
+// DB start point, end point
+func (p *openAirParser) parseArcBetween(args string) error {
+	if p.center == nil {
+		return fmt.Errorf("openair: DB without center")
+	}
+	match := coordinatePattern.FindStringIndex(args)
+	if match == nil {
+		return fmt.Errorf("openair: invalid arc %q", args)
+	}
+	from, err := ParseCoordinate(args[:match[1]])
+	if err != nil {
+		return err
+	}
+	to, err := ParseCoordinate(strings.TrimLeft(args[match[1]:], " ,\t"))
+	if err != nil {
+		return err
+	}
+	center := *p.center
+	radius := alphafoxtrot.Distance(center.LatitudeDeg, center.LongitudeDeg, from.LatitudeDeg, from.LongitudeDeg)
+	start := alphafoxtrot.Bearing(center.LatitudeDeg, center.LongitudeDeg, from.LatitudeDeg, from.LongitudeDeg)
+	end := alphafoxtrot.Bearing(center.LatitudeDeg, center.LongitudeDeg, to.LatitudeDeg, to.LongitudeDeg)
+	points := arcPoints(center, radius, start, end, p.clockwise)
+	// use the exact end points instead of the computed ones
+	points[0], points[len(points)-1] = from, to
+	p.current.Polygon = append(p.current.Polygon, points...)
+	return nil
+}
+
+// arcPoints returns the points of an arc from the start to the end bearing, including both ends
+func arcPoints(center Point, radiusMeters, startDeg, endDeg float64, clockwise bool) []Point {
+	sweep := math.Mod(endDeg-startDeg+720, 360)
+	if !clockwise {
+		sweep = math.Mod(startDeg-endDeg+720, 360)
+	}
+	if sweep == 0 {
+		sweep = 360
+	}
+	steps := int(math.Ceil(sweep / arcStepDeg))
+	points := make([]Point, 0, steps+1)
+	for i := 0; i <= steps; i++ {
+		angle := sweep * float64(i) / float64(steps)
+		bearing := startDeg + angle
+		if !clockwise {
+			bearing = startDeg - angle
+		}
+		lat, lon := alphafoxtrot.Destination(center.LatitudeDeg, center.LongitudeDeg, bearing, radiusMeters)
+		points = append(points, Point{LatitudeDeg: lat, LongitudeDeg: lon})
+	}
+	return points
+}
+
+// ParseCoordinate parses OpenAir coordinates, e.g. 39:29.9 N 119:46.1 W or 52:22:30N 013:29:45E
+func ParseCoordinate(str string) (Point, error) {
+	match := coordinatePattern.FindStringSubmatch(str)
+	if match == nil {
+		return Point{}, fmt.Errorf("openair: invalid coordinate %q", str)
+	}
+	lat := dmsToDegrees(match[1], match[2], match[3])
+	lon := dmsToDegrees(match[5], match[6], match[7])
+	if strings.EqualFold(match[4], "S") {
+		lat = -lat
+	}
+	if strings.EqualFold(match[8], "W") {
+		lon = -lon
+	}
+	return Point{LatitudeDeg: lat, LongitudeDeg: lon}, nil
+}
+
+func dmsToDegrees(degrees, minutes, seconds string) float64 {
+	d, _ := strconv.ParseFloat(degrees, 64)
+	m, _ := strconv.ParseFloat(minutes, 64)
+	s, _ := strconv.ParseFloat(seconds, 64)
+	return d + m/60 + s/3600
+}
+
+// ParseLimit parses vertical limits, e.g. GND, SFC, 1500ft MSL, 2000 AGL, FL65, 900m, UNL
+func ParseLimit(str string) (Limit, error) {
+	raw := strings.TrimSpace(str)
+	text := strings.ToUpper(raw)
+	switch text {
+	case "GND", "SFC", "0", "0 AGL", "0FT AGL":
+		return Limit{Reference: ReferenceSurface, Raw: raw}, nil
+	case "UNL", "UNLIM", "UNLIMITED":
+		return Limit{Reference: ReferenceUnlimited, Raw: raw}, nil
+	}
+	if strings.HasPrefix(text, "FL") {
+		level, err := strconv.ParseFloat(strings.TrimSpace(text[2:]), 64)
+		if err != nil {
+			return Limit{}, fmt.Errorf("openair: invalid limit %q", raw)
+		}
+		return Limit{Reference: ReferenceFlightLevel, Value: level, Raw: raw}, nil
+	}
+
+	match := limitPattern.FindStringSubmatch(strings.Join(strings.Fields(text), ""))
+	if match == nil {
+		return Limit{}, fmt.Errorf("openair: invalid limit %q", raw)
+	}
+	value, _ := strconv.ParseFloat(match[1], 64)
+	if match[2] == "M" {
+		value = alphafoxtrot.MetersToFeet(value)
+	}
+	switch match[3] {
+	case "AGL", "AAL", "GND", "SFC":
+		return Limit{Reference: ReferenceAGL, Value: value, Raw: raw}, nil
+	}
+	return Limit{Reference: ReferenceMSL, Value: value, Raw: raw}, nil
+}
@@ -0,0 +1,227 @@
+package airspace
+
+import (
+	"math"
+	"testing"
+
+	alphafoxtrot "github.com/grumpypixel/go-airport-finder"
+)
+
+const sampleFile = "../testdata/openair/sample.txt"
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		str       string
+		reference string
+		value     float64
+		wantErr   bool
+	}{
+		{"GND", ReferenceSurface, 0, false},
+		{"sfc", ReferenceSurface, 0, false},
+		{"0 AGL", ReferenceSurface, 0, false},
+		{"UNL", ReferenceUnlimited, 0, false},
+		{"unlimited", ReferenceUnlimited, 0, false},
+		{"FL65", ReferenceFlightLevel, 65, false},
+		{"FL 180", ReferenceFlightLevel, 180, false},
+		{"1500ft MSL", ReferenceMSL, 1500, false},
+		{"2500 ft AMSL", ReferenceMSL, 2500, false},
+		{"3000", ReferenceMSL, 3000, false},
+		{"2000 AGL", ReferenceAGL, 2000, false},
+		{"1200ft AGL", ReferenceAGL, 1200, false},
+		{"700 GND", ReferenceAGL, 700, false},
+		{"900m", ReferenceMSL, 2952.756, false},
+		{"300M AGL", ReferenceAGL, 984.252, false},
+		{"FLX", "", 0, true},
+		{"high", "", 0, true},
+		{"1500 FT QNH", "", 0, true},
+	}
+	for _, test := range tests {
+		limit, err := ParseLimit(test.str)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: got error %v", test.str, err)
+			continue
+		}
+		if test.wantErr {
+			continue
+		}
+		if limit.Reference != test.reference || math.Abs(limit.Value-test.value) > 1e-6 || limit.Raw != test.str {
+			t.Errorf("%q: got %+v", test.str, limit)
+		}
+	}
+}
+
+func TestLimitFeet(t *testing.T) {
+	tests := []struct {
+		limit Limit
+		feet  float64
+	}{
+		{Limit{Reference: ReferenceSurface}, 120},
+		{Limit{Reference: ReferenceAGL, Value: 700}, 820},
+		{Limit{Reference: ReferenceMSL, Value: 2500}, 2500},
+		{Limit{Reference: ReferenceFlightLevel, Value: 180}, 18000},
+		{Limit{Reference: ReferenceUnlimited}, math.Inf(1)},
+	}
+	for _, test := range tests {
+		if feet := test.limit.Feet(120); feet != test.feet {
+			t.Errorf("%+v: got %f ft, want %f", test.limit, feet, test.feet)
+		}
+	}
+}
+
+func TestParseCoordinate(t *testing.T) {
+	tests := []struct {
+		str       string
+		latitude  float64
+		longitude float64
+		wantErr   bool
+	}{
+		{"34:00:57 N 118:27:05 W", 34.015833, -118.451389, false},
+		{"39:29.9 N 119:46.1 W", 39.498333, -119.768333, false},
+		{"52:22:30N 013:29:45E", 52.375, 13.495833, false},
+		{"33:51:00 S, 151:12:36 E", -33.85, 151.21, false},
+		{"34:02:00 n 118:20:00 w", 34.033333, -118.333333, false},
+		{"34.02 N 118.20 W", 0, 0, true},
+		{"34:02:00 118:20:00", 0, 0, true},
+		{"", 0, 0, true},
+	}
+	for _, test := range tests {
+		point, err := ParseCoordinate(test.str)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: got error %v", test.str, err)
+			continue
+		}
+		if math.Abs(point.LatitudeDeg-test.latitude) > 1e-6 || math.Abs(point.LongitudeDeg-test.longitude) > 1e-6 {
+			t.Errorf("%q: got %f,%f", test.str, point.LatitudeDeg, point.LongitudeDeg)
+		}
+	}
+}
+
+func readSample(t *testing.T) *DB {
+	t.Helper()
+	db := NewDB()
+	if err := db.Parse(sampleFile); err != nil {
+		t.Fatal(err)
+	}
+	if len(db.Airspaces) != 4 {
+		t.Fatalf("got %d airspaces, want 4", len(db.Airspaces))
+	}
+	return db
+}
+
+func TestReadSample(t *testing.T) {
+	db := readSample(t)
+	tests := []struct {
+		class string
+		name  string
+		lower Limit
+		upper Limit
+	}{
+		{"D", "SANTA MONICA CLASS D", Limit{ReferenceSurface, 0, "SFC"}, Limit{ReferenceMSL, 2500, "2500ft MSL"}},
+		{"B", "LOS ANGELES CLASS B SURFACE AREA", Limit{ReferenceSurface, 0, "GND"}, Limit{ReferenceMSL, 10000, "10000ft MSL"}},
+		{"R", "R-2501 SAMPLE", Limit{ReferenceFlightLevel, 180, "FL180"}, Limit{ReferenceUnlimited, 0, "UNL"}},
+		{"E", "SAMPLE TRANSITION AREA", Limit{ReferenceAGL, 700, "700 AGL"}, Limit{ReferenceAGL, 1200, "1200ft AGL"}},
+	}
+	for i, test := range tests {
+		airspace := db.Airspaces[i]
+		if airspace.Class != test.class || airspace.Name != test.name || airspace.Lower != test.lower || airspace.Upper != test.upper {
+			t.Errorf("%s: got %s %s %+v %+v", test.name, airspace.Class, airspace.Name, airspace.Lower, airspace.Upper)
+		}
+	}
+	if polygon := db.Airspaces[3].Polygon; len(polygon) != 4 {
+		t.Errorf("got %d points, want 4", len(polygon))
+	}
+}
+
+// checkArc checks that every point of an arc is at the radius from the center and passes the optional check
+func checkArc(t *testing.T, name string, points []Point, center Point, radiusMeters float64, check func(Point) bool) {
+	t.Helper()
+	for _, point := range points {
+		distance := alphafoxtrot.Distance(center.LatitudeDeg, center.LongitudeDeg, point.LatitudeDeg, point.LongitudeDeg)
+		if math.Abs(distance-radiusMeters) > 1 {
+			t.Errorf("%s: %+v is %.0f m from the center, want %.0f", name, point, distance, radiusMeters)
+		}
+		if check != nil && !check(point) {
+			t.Errorf("%s: %+v is on the wrong side", name, point)
+		}
+	}
+}
+
+func TestCircle(t *testing.T) {
+	db := readSample(t)
+	// DC 4.3 around 34:00:57 N 118:27:05 W
+	center := Point{34.015833, -118.451389}
+	polygon := db.Airspaces[0].Polygon
+	if len(polygon) != 360/arcStepDeg+1 {
+		t.Errorf("got %d points", len(polygon))
+	}
+	checkArc(t, "DC", polygon, center, 4.3*nauticalMile, nil)
+	if !db.Airspaces[0].Contains(center.LatitudeDeg, center.LongitudeDeg) || db.Airspaces[0].Contains(34.1, -118.45) {
+		t.Error("wrong lateral boundary")
+	}
+}
+
+func TestArcBetweenPoints(t *testing.T) {
+	db := readSample(t)
+	polygon := db.Airspaces[1].Polygon
+	from, to := Point{34.033333, -118.333333}, Point{33.85, -118.333333}
+	// DP, DP, DB start..end, DP
+	first, last := polygon[0], polygon[len(polygon)-1]
+	if math.Abs(first.LatitudeDeg-34.033333) > 1e-6 || first.LongitudeDeg != -118.5 || last.LatitudeDeg != 33.85 || last.LongitudeDeg != -118.5 {
+		t.Errorf("unexpected polygon ends %+v %+v", first, last)
+	}
+	arc := polygon[2 : len(polygon)-1]
+	if math.Abs(arc[0].LatitudeDeg-from.LatitudeDeg) > 1e-6 || math.Abs(arc[len(arc)-1].LatitudeDeg-to.LatitudeDeg) > 1e-6 {
+		t.Errorf("the arc doesn't end at the given points: %+v %+v", arc[0], arc[len(arc)-1])
+	}
+	// clockwise from north to south of the center passes east of it
+	center := Point{33.9425, -118.408056}
+	radius := alphafoxtrot.Distance(center.LatitudeDeg, center.LongitudeDeg, from.LatitudeDeg, from.LongitudeDeg)
+	checkArc(t, "DB", arc[1:len(arc)-1], center, radius, func(p Point) bool { return p.LongitudeDeg > center.LongitudeDeg })
+	// KLAX
+	if !db.Airspaces[1].Contains(33.942501, -118.407997) {
+		t.Error("KLAX is outside the class B surface area")
+	}
+}
+
+func TestArcAngles(t *testing.T) {
+	db := readSample(t)
+	// V D=- and DA 10,0,180 around 34:20:00 N 117:50:00 W: counterclockwise from north to south passes west of the center
+	center := Point{34.333333, -117.833333}
+	polygon := db.Airspaces[2].Polygon
+	arc := polygon[:len(polygon)-1]
+	if len(arc) != 180/arcStepDeg+1 {
+		t.Errorf("got %d arc points", len(arc))
+	}
+	checkArc(t, "DA", arc, center, 10*nauticalMile, func(p Point) bool { return p.LongitudeDeg < center.LongitudeDeg+1e-9 })
+	if arc[0].LatitudeDeg < center.LatitudeDeg || arc[len(arc)-1].LatitudeDeg > center.LatitudeDeg {
+		t.Errorf("the arc runs from %+v to %+v", arc[0], arc[len(arc)-1])
+	}
+}
+
+func TestArcPointsSweep(t *testing.T) {
+	center := Point{0, 0}
+	tests := []struct {
+		start, end float64
+		clockwise  bool
+		points     int
+		via        float64 // bearing of the middle point
+	}{
+		{0, 90, true, 19, 45},
+		{0, 90, false, 55, 225},
+		{350, 10, true, 5, 0},
+		{10, 350, false, 5, 0},
+		{0, 0, true, 73, 180},
+	}
+	for _, test := range tests {
+		points := arcPoints(center, 10000, test.start, test.end, test.clockwise)
+		if len(points) != test.points {
+			t.Errorf("%v: got %d points, want %d", test, len(points), test.points)
+			continue
+		}
+		middle := points[len(points)/2]
+		bearing := alphafoxtrot.Bearing(0, 0, middle.LatitudeDeg, middle.LongitudeDeg)
+		if diff := math.Abs(math.Mod(bearing-test.via+540, 360) - 180); diff > 0.01 {
+			t.Errorf("%v: the middle point is at %f°, want %f°", test, bearing, test.via)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+package alphafoxtrot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ARINC 424 navigation database, fixed-width 132 column records (e.g. the FAA CIFP).
+// columns are 1-based start positions and lengths as in the ARINC 424 specification
+
+const (
+	OriginARINC424       = "arinc424"
+	arincRecordLength    = 132
+	arincSectionCol      = 5
+	arincSubsectionCol   = 6 // D records
+	arincPSubsectionCol  = 13
+	arincContinuationCol = 22
+)
+
+// airport primary record, section P subsection A (PA)
+const (
+	arincAptIdent          = 7  // 4
+	arincAptICAORegion     = 11 // 2
+	arincAptIATA           = 14 // 3
+	arincAptLongestRunway  = 28 // 3, hundreds of ft
+	arincAptLatitude       = 33 // 9, N33563389
+	arincAptLongitude      = 42 // 10, W118242880
+	arincAptMagVariation   = 52 // 5, E0120 (tenths)
+	arincAptElevationFt    = 57 // 5
+	arincAptName           = 94 // 30
+	arincRwyIdent          = 14 // 5, RW07L
+	arincRwyLengthFt       = 23 // 5
+	arincRwyLatitude       = 33 // 9
+	arincRwyLongitude      = 42 // 10
+	arincRwyElevationFt    = 67 // 5, landing threshold elevation
+	arincRwyDisplacedFt    = 72 // 4
+	arincRwyWidthFt        = 78 // 3
+	arincNavAirport        = 7  // 4, blank for enroute navaids
+	arincNavIdent          = 14 // 4
+	arincNavICAORegion     = 20 // 2
+	arincNavFrequency      = 23 // 5, VHF in 10 kHz, NDB in 100 Hz
+	arincNavClass          = 28 // 5, e.g. VTHW
+	arincNavLatitude       = 33 // 9
+	arincNavLongitude      = 42 // 10
+	arincNavDMELatitude    = 56 // 9
+	arincNavDMELongitude   = 65 // 10
+	arincNavDeclination    = 75 // 5, VHF: station declination, NDB: magnetic variation
+	arincNavDMEElevationFt = 80 // 5
+	arincNavName           = 94 // 30
+)
+
+// ICAO region prefixes to ISO country codes, only the North American regions are mapped
+var arincRegionCountries = map[string]string{
+	"K": "US", "PA": "US", "PH": "US", "PP": "US", "C": "CA", "MM": "MX",
+}
+
+// ARINC424Importer imports airports (PA), runways (PG), VHF navaids (D) and NDBs (DB).
+// Airport sizes are derived from the longest runway: large from 10000 ft, medium from 5000 ft.
+type ARINC424Importer struct{}
+
+func (ARINC424Importer) Import(r io.Reader, databases *Databases, airportTypeFilter uint64) error {
+	ids := newImportIDs(databases)
+	airports := make(map[string]*AirportData)
+	runwayEnds := make(map[string][]runwayEnd)
+	runwayWidths := make(map[string]map[string]int64)
+	runwayLengths := make(map[string]map[string]int64)
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if len(text) < arincRecordLength-10 || (text[0] != 'S' && text[0] != 'T') {
+			continue
+		}
+		// only primary records, continuation records carry notes
+		if c := text[arincContinuationCol-1]; c != '0' && c != '1' {
+			continue
+		}
+
+		section := text[arincSectionCol-1]
+		switch {
+		case section == 'P' && text[arincPSubsectionCol-1] == 'A':
+			airport, err := parseARINC424Airport(text)
+			if err != nil {
+				log.Println(line, err)
+				continue
+			}
+			airport.ID = ids.nextAirport()
+			airports[airport.ICAOCode] = airport
+
+		case section == 'P' && text[arincPSubsectionCol-1] == 'G':
+			airportIdent := fixedField(text, arincAptIdent, 4)
+			end, err := parseARINC424RunwayEnd(text)
+			if err != nil {
+				log.Println(line, airportIdent, err)
+				continue
+			}
+			runwayEnds[airportIdent] = append(runwayEnds[airportIdent], end)
+			if runwayWidths[airportIdent] == nil {
+				runwayWidths[airportIdent] = make(map[string]int64)
+				runwayLengths[airportIdent] = make(map[string]int64)
+			}
+			runwayWidths[airportIdent][end.ident], _ = ParseInt(fixedField(text, arincRwyWidthFt, 3))
+			runwayLengths[airportIdent][end.ident], _ = ParseInt(fixedField(text, arincRwyLengthFt, 5))
+
+		case section == 'D' && (text[arincSubsectionCol-1] == ' ' || text[arincSubsectionCol-1] == 'B'):
+			navaid, err := parseARINC424Navaid(text, text[arincSubsectionCol-1] == 'B')
+			if err != nil {
+				log.Println(line, err)
+				continue
+			}
+			if navaid == nil {
+				continue
+			}
+			navaid.ID = ids.nextNavaid()
+			databases.Navaids.Navaids = append(databases.Navaids.Navaids, navaid)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	idents := make([]string, 0, len(airports))
+	for ident := range airports {
+		idents = append(idents, ident)
+	}
+	sort.Strings(idents)
+	for _, ident := range idents {
+		airport := airports[ident]
+		if airport.TypeFlag&airportTypeFilter == 0 {
+			continue
+		}
+		databases.Airports.Airports = append(databases.Airports.Airports, airport)
+		for _, pair := range pairRunwayEnds(runwayEnds[ident]) {
+			low, high := pair[0], pair[1]
+			runway := &RunwayData{
+				ID:                         ids.nextRunway(),
+				AirportID:                  airport.ID,
+				AirportIdent:               airport.ICAOCode,
+				LengthFt:                   runwayLengths[ident][low.ident],
+				WidthFt:                    runwayWidths[ident][low.ident],
+				LowEndIdent:                low.ident,
+				LowEndLatitudeDeg:          low.latitude,
+				LowEndLongitudeDeg:         low.longitude,
+				LowEndElevationFt:          low.elevationFt,
+				LowEndDisplacedThresholdFt: low.displacedFt,
+				Origin:                     OriginARINC424,
+			}
+			if high != nil {
+				runway.HighEndIdent = high.ident
+				runway.HighEndLatitudeDeg = high.latitude
+				runway.HighEndLongitudeDeg = high.longitude
+				runway.HighEndElevationFt = high.elevationFt
+				runway.HighEndDisplacedThresholdFt = high.displacedFt
+				runway.LowEndHeadingDegT = math.Round(Bearing(low.latitude, low.longitude, high.latitude, high.longitude)*10) / 10
+				runway.HighEndHeadingDegT = math.Round(Bearing(high.latitude, high.longitude, low.latitude, low.longitude)*10) / 10
+			}
+			databases.Runways.Runways[airport.ID] = append(databases.Runways.Runways[airport.ID], runway)
+		}
+	}
+	return nil
+}
+
+func parseARINC424Airport(text string) (*AirportData, error) {
+	ident := fixedField(text, arincAptIdent, 4)
+	latitude, err := parseARINC424Coordinate(fixedField(text, arincAptLatitude, 9))
+	if err != nil {
+		return nil, fmt.Errorf("arinc424: %s: %v", ident, err)
+	}
+	longitude, err := parseARINC424Coordinate(fixedField(text, arincAptLongitude, 10))
+	if err != nil {
+		return nil, fmt.Errorf("arinc424: %s: %v", ident, err)
+	}
+	elevation, _ := ParseInt(fixedField(text, arincAptElevationFt, 5))
+	longest, _ := ParseInt(fixedField(text, arincAptLongestRunway, 3))
+	typ := airportTypeFromLongestRunway(longest * 100)
+	return &AirportData{
+		ICAOCode:     ident,
+		Type:         typ,
+		TypeFlag:     AirportTypeFromString(typ),
+		Name:         fixedField(text, arincAptName, 30),
+		LatitudeDeg:  latitude,
+		LongitudeDeg: longitude,
+		ElevationFt:  elevation,
+		ISOCountry:   arinc424Country(fixedField(text, arincAptICAORegion, 2)),
+		GPSCode:      ident,
+		IATACode:     fixedField(text, arincAptIATA, 3),
+		Origin:       OriginARINC424,
+	}, nil
+}
+
+func parseARINC424RunwayEnd(text string) (runwayEnd, error) {
+	var err error
+	end := runwayEnd{ident: strings.TrimPrefix(fixedField(text, arincRwyIdent, 5), "RW")}
+	if end.latitude, err = parseARINC424Coordinate(fixedField(text, arincRwyLatitude, 9)); err != nil {
+		return end, err
+	}
+	if end.longitude, err = parseARINC424Coordinate(fixedField(text, arincRwyLongitude, 10)); err != nil {
+		return end, err
+	}
+	end.elevationFt, _ = ParseInt(fixedField(text, arincRwyElevationFt, 5))
+	end.displacedFt, _ = ParseInt(fixedField(text, arincRwyDisplacedFt, 4))
+	return end, nil
+}
+
+// parseARINC424Navaid returns nil for navaids which aren't VORs, DMEs, TACANs or NDBs (e.g. ILS DMEs)
+func parseARINC424Navaid(text string, ndb bool) (*NavaidData, error) {
+	ident := fixedField(text, arincNavIdent, 4)
+	class := text[arincNavClass-1 : arincNavClass-1+5]
+	typ := arinc424NavaidType(class, ndb)
+	if typ == "" {
+		return nil, nil
+	}
+
+	navaid := &NavaidData{
+		Ident:      ident,
+		Name:       fixedField(text, arincNavName, 30),
+		Type:       typ,
+		ISOCountry: arinc424Country(fixedField(text, arincNavICAORegion, 2)),
+		Origin:     OriginARINC424,
+	}
+	if airport := fixedField(text, arincNavAirport, 4); airport != "" {
+		navaid.AssociatedAirport = airport
+	}
+	frequency, _ := ParseFloat(fixedField(text, arincNavFrequency, 5))
+	if ndb {
+		navaid.FrequencyKHZ = uint64(math.Round(frequency / 10))
+		navaid.MagneticVariationDeg = parseVariation(fixedField(text, arincNavDeclination, 5))
+	} else {
+		navaid.FrequencyKHZ = uint64(math.Round(frequency * 10))
+		navaid.SlavedVariationDeg = parseVariation(fixedField(text, arincNavDeclination, 5))
+	}
+
+	var err error
+	if lat := fixedField(text, arincNavLatitude, 9); lat != "" {
+		if navaid.LatitudeDeg, err = parseARINC424Coordinate(lat); err != nil {
+			return nil, fmt.Errorf("arinc424: %s: %v", ident, err)
+		}
+		if navaid.LongitudeDeg, err = parseARINC424Coordinate(fixedField(text, arincNavLongitude, 10)); err != nil {
+			return nil, fmt.Errorf("arinc424: %s: %v", ident, err)
+		}
+	}
+	if !ndb && fixedField(text, arincNavDMELatitude, 9) != "" {
+		if navaid.DMELatitudeDeg, err = parseARINC424Coordinate(fixedField(text, arincNavDMELatitude, 9)); err != nil {
+			return nil, fmt.Errorf("arinc424: %s: %v", ident, err)
+		}
+		if navaid.DMELongitudeDeg, err = parseARINC424Coordinate(fixedField(text, arincNavDMELongitude, 10)); err != nil {
+			return nil, fmt.Errorf("arinc424: %s: %v", ident, err)
+		}
+		navaid.DMEElevationFt, _ = ParseInt(fixedField(text, arincNavDMEElevationFt, 5))
+		navaid.DMEFrequencyKHZ = navaid.FrequencyKHZ
+		navaid.ElevationFt = navaid.DMEElevationFt
+		// a standalone DME has no VOR position
+		if navaid.LatitudeDeg == 0 && navaid.LongitudeDeg == 0 {
+			navaid.LatitudeDeg, navaid.LongitudeDeg = navaid.DMELatitudeDeg, navaid.DMELongitudeDeg
+		}
+	}
+	return navaid, nil
+}
+
+// arinc424NavaidType maps the navaid class, e.g. "VTHW " (VORTAC) or "HW  B" (NDB)
+func arinc424NavaidType(class string, ndb bool) string {
+	if ndb {
+		if class[0] != 'H' {
+			return ""
+		}
+		if class[1] == 'D' {
+			return "NDB-DME"
+		}
+		return "NDB"
+	}
+	switch class[0:2] {
+	case "V ":
+		return "VOR"
+	case "VD":
+		return "VOR-DME"
+	case "VT", "VM":
+		return "VORTAC"
+	case " D":
+		return "DME"
+	case " T", " M":
+		return "TACAN"
+	}
+	return ""
+}
+
+func arinc424Country(icaoRegion string) string {
+	if country, ok := arincRegionCountries[icaoRegion]; ok {
+		return country
+	}
+	if len(icaoRegion) > 0 {
+		return arincRegionCountries[icaoRegion[:1]]
+	}
+	return ""
+}
+
+// parseARINC424Coordinate parses N33563389 (DDMMSSss) or W118242880 (DDDMMSSss)
+func parseARINC424Coordinate(str string) (float64, error) {
+	if len(str) != 9 && len(str) != 10 {
+		return 0, fmt.Errorf("invalid coordinate %q", str)
+	}
+	degreeDigits := 2
+	if len(str) == 10 {
+		degreeDigits = 3
+	}
+	digits := str[1:]
+	degrees, err := strconv.Atoi(digits[:degreeDigits])
+	if err != nil {
+		return 0, fmt.Errorf("invalid coordinate %q", str)
+	}
+	minutes, err := strconv.Atoi(digits[degreeDigits : degreeDigits+2])
+	if err != nil {
+		return 0, fmt.Errorf("invalid coordinate %q", str)
+	}
+	hundredths, err := strconv.Atoi(digits[degreeDigits+2:])
+	if err != nil {
+		return 0, fmt.Errorf("invalid coordinate %q", str)
+	}
+	value := float64(degrees) + float64(minutes)/60 + float64(hundredths)/100/3600
+	switch str[0] {
+	case 'N', 'E':
+		return value, nil
+	case 'S', 'W':
+		return -value, nil
+	}
+	return 0, fmt.Errorf("invalid coordinate %q", str)
+}
+
+// pairRunwayEnds pairs opposite runway ends, e.g. 07L and 25R, the lower designator comes first.
+// Ends without an opposite end (e.g. helipads) are returned alone.
+func pairRunwayEnds(ends []runwayEnd) [][2]*runwayEnd {
+	pairs := make([][2]*runwayEnd, 0, len(ends)/2+1)
+	used := make([]bool, len(ends))
+	for i := range ends {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		opposite := oppositeRunwayIdent(ends[i].ident)
+		var partner *runwayEnd
+		for j := i + 1; j < len(ends); j++ {
+			if !used[j] && ends[j].ident == opposite {
+				used[j] = true
+				partner = &ends[j]
+				break
+			}
+		}
+		low := &ends[i]
+		if partner != nil && partner.ident < low.ident {
+			low, partner = partner, low
+		}
+		pairs = append(pairs, [2]*runwayEnd{low, partner})
+	}
+	return pairs
+}
+
+// oppositeRunwayIdent returns e.g. 25R for 07L, or an empty string for idents which aren't runway designators
+func oppositeRunwayIdent(ident string) string {
+	number := strings.TrimRight(ident, "LRCW")
+	value, err := strconv.Atoi(number)
+	if err != nil || value < 1 || value > 36 {
+		return ""
+	}
+	opposite := (value+17)%36 + 1
+	suffix := strings.TrimPrefix(ident, number)
+	switch suffix {
+	case "L":
+		suffix = "R"
+	case "R":
+		suffix = "L"
+	}
+	return fmt.Sprintf("%02d%s", opposite, suffix)
+}
@@ -0,0 +1,104 @@
+package alphafoxtrot
+
+import (
+	"math"
+	"testing"
+)
+
+func TestARINC424Importer(t *testing.T) {
+	databases := NewDatabases()
+	if err := ImportFile(ARINC424Importer{}, "testdata/arinc424/FAACIFP18", databases, AirportTypeAll); err != nil {
+		t.Fatal(err)
+	}
+	airports := []struct {
+		icao      string
+		iata      string
+		typ       string
+		latitude  float64
+		longitude float64
+		elevation int64
+		runways   int
+	}{
+		{"KLAX", "LAX", AirportTypeLargeName, 33.942536, -118.408075, 128, 2},
+		{"KSMO", "SMO", AirportTypeSmallName, 34.01585, -118.451, 177, 1},
+	}
+	if len(databases.Airports.Airports) != len(airports) {
+		t.Fatalf("got %d airports, want %d", len(databases.Airports.Airports), len(airports))
+	}
+	for _, test := range airports {
+		airport := databases.Airports.FindByICAOCode(test.icao)
+		if airport == nil {
+			t.Errorf("%s: not found", test.icao)
+			continue
+		}
+		if airport.IATACode != test.iata || airport.Type != test.typ || airport.ElevationFt != test.elevation || airport.ISOCountry != "US" {
+			t.Errorf("%s: got %s %s %d ft %s", test.icao, airport.IATACode, airport.Type, airport.ElevationFt, airport.ISOCountry)
+		}
+		if math.Abs(airport.LatitudeDeg-test.latitude) > 1e-5 || math.Abs(airport.LongitudeDeg-test.longitude) > 1e-5 {
+			t.Errorf("%s: got position %f,%f", test.icao, airport.LatitudeDeg, airport.LongitudeDeg)
+		}
+		if runways := databases.Runways.FindByAirportID(airport.ID); len(runways) != test.runways {
+			t.Errorf("%s: got %d runways, want %d", test.icao, len(runways), test.runways)
+		}
+	}
+
+	// the runway ends are paired, the lower designator first
+	klax := databases.Airports.FindByICAOCode("KLAX")
+	runway := databases.Runways.FindByAirportID(klax.ID)[0]
+	if runway.LowEndIdent != "07L" || runway.HighEndIdent != "25R" || runway.LengthFt != 12923 || runway.WidthFt != 150 {
+		t.Errorf("unexpected runway %+v", runway)
+	}
+	if runway.LowEndElevationFt != 114 || runway.HighEndElevationFt != 94 || runway.HighEndDisplacedThresholdFt != 957 || runway.LowEndHeadingDegT != 82.4 {
+		t.Errorf("unexpected runway ends %+v", runway)
+	}
+
+	navaids := []struct {
+		ident        string
+		typ          string
+		frequencyKHZ uint64
+		latitude     float64
+		longitude    float64
+		elevation    int64
+	}{
+		{"LAX", "VORTAC", 113600, 33.9333, -118.432453, 182},
+		{"SMO", "VOR-DME", 110800, 34.010942, -118.456997, 120},
+		{"OS", "NDB", 338, 33.921667, -118.515, 0},
+	}
+	if len(databases.Navaids.Navaids) != len(navaids) {
+		t.Fatalf("got %d navaids, want %d", len(databases.Navaids.Navaids), len(navaids))
+	}
+	for i, test := range navaids {
+		navaid := databases.Navaids.Navaids[i]
+		if navaid.Ident != test.ident || navaid.Type != test.typ || navaid.FrequencyKHZ != test.frequencyKHZ || navaid.ElevationFt != test.elevation {
+			t.Errorf("%s: got %s %s %d kHz %d ft", test.ident, navaid.Ident, navaid.Type, navaid.FrequencyKHZ, navaid.ElevationFt)
+		}
+		if math.Abs(navaid.LatitudeDeg-test.latitude) > 1e-5 || math.Abs(navaid.LongitudeDeg-test.longitude) > 1e-5 {
+			t.Errorf("%s: got position %f,%f", test.ident, navaid.LatitudeDeg, navaid.LongitudeDeg)
+		}
+	}
+}
+
+func TestParseARINC424Coordinate(t *testing.T) {
+	tests := []struct {
+		str     string
+		value   float64
+		wantErr bool
+	}{
+		{"N33563389", 33.942747, false},
+		{"W118242880", -118.408, false},
+		{"S33563389", -33.942747, false},
+		{"E000000000", 0, false},
+		{"X33563389", 0, true},
+		{"N3356", 0, true},
+	}
+	for _, test := range tests {
+		value, err := parseARINC424Coordinate(test.str)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got error %v", test.str, err)
+			continue
+		}
+		if math.Abs(value-test.value) > 1e-5 {
+			t.Errorf("%s: got %f, want %f", test.str, value, test.value)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package alphafoxtrot
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// batchChunkSize is the number of points or matrix rows a worker takes at a time
+const batchChunkSize = 256
+
+type Position struct {
+	LatitudeDeg  float64
+	LongitudeDeg float64
+}
+
+type BatchOptions struct {
+	Workers int // runtime.NumCPU() if 0
+}
+
+// NearestAirport is the result of a batch search, Airport is nil if there is no airport within the radius.
+// Results for the same airport share the Airport.
+type NearestAirport struct {
+	Airport        *Airport
+	DistanceMeters float64
+}
+
+// runBatch splits count items into chunks and processes them with a pool of workers
+func runBatch(count int, options *BatchOptions, fn func(from, to int)) {
+	workers := runtime.NumCPU()
+	if options != nil && options.Workers > 0 {
+		workers = options.Workers
+	}
+	chunks := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for from := range chunks {
+				fn(from, MinInt(from+batchChunkSize, count))
+			}
+		}()
+	}
+	for from := 0; from < count; from += batchChunkSize {
+		chunks <- from
+	}
+	close(chunks)
+	wg.Wait()
+}
+
+// DistanceMatrix returns the distances in meters from every airport of from (rows) to every airport of to (columns)
+func DistanceMatrix(from, to []*Airport, options *BatchOptions) [][]float64 {
+	matrix := make([][]float64, len(from))
+	cells := make([]float64, len(from)*len(to))
+	for i := range matrix {
+		matrix[i] = cells[i*len(to) : (i+1)*len(to)]
+	}
+	DistanceMatrixInto(matrix, from, to, options)
+	return matrix
+}
+
+// DistanceMatrixInto writes the distances into the matrix, which must have a row for every airport of from
+// with a column for every airport of to
+func DistanceMatrixInto(matrix [][]float64, from, to []*Airport, options *BatchOptions) error {
+	if len(matrix) != len(from) {
+		return fmt.Errorf("distance matrix: %d rows for %d airports", len(matrix), len(from))
+	}
+	for i, row := range matrix {
+		if len(row) != len(to) {
+			return fmt.Errorf("distance matrix: row %d has %d columns for %d airports", i, len(row), len(to))
+		}
+	}
+	DistanceMatrixFunc(from, to, options, func(i, j int, distanceMeters float64) {
+		matrix[i][j] = distanceMeters
+	})
+	return nil
+}
+
+// DistanceMatrixFunc calls fn with the distance of every pair of airports.
+// fn is called concurrently for different rows, the columns of a row are passed in order by the same goroutine.
+func DistanceMatrixFunc(from, to []*Airport, options *BatchOptions, fn func(i, j int, distanceMeters float64)) {
+	// the positions on the unit sphere save the trigonometry of Distance for every pair
+	columns := make([][3]float64, len(to))
+	for j, airport := range to {
+		columns[j] = unitVector(airport.LatitudeDeg, airport.LongitudeDeg)
+	}
+	runBatch(len(from), options, func(first, last int) {
+		for i := first; i < last; i++ {
+			row := unitVector(from[i].LatitudeDeg, from[i].LongitudeDeg)
+			for j := range columns {
+				fn(i, j, chordDistance(&row, &columns[j]))
+			}
+		}
+	})
+}
+
+// FindNearestAirportBatch writes the nearest airport of every point to the result with the same index.
+// The radius is unlimited if < 0.
+func (af *AirportFinder) FindNearestAirportBatch(points []Position, radiusMeters float64, airportTypeFilter uint64, results []NearestAirport, options *BatchOptions) error {
+	if len(results) != len(points) {
+		return fmt.Errorf("nearest airport batch: %d results for %d points", len(results), len(points))
+	}
+	af.FindNearestAirportBatchFunc(points, radiusMeters, airportTypeFilter, options, func(i int, result NearestAirport) {
+		results[i] = result
+	})
+	return nil
+}
+
+// FindNearestAirportBatchFunc calls fn with the nearest airport of every point.
+// fn is called concurrently, the points of a chunk are passed in order by the same goroutine.
+func (af *AirportFinder) FindNearestAirportBatchFunc(points []Position, radiusMeters float64, airportTypeFilter uint64, options *BatchOptions, fn func(i int, result NearestAirport)) {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
+	index := af.airportDB.SpatialIndex()
+	airportData := af.airportDB.Airports
+	accept := func(item int) bool {
+		return airportData[item].TypeFlag&airportTypeFilter != 0
+	}
+
+	// every airport is made once by the first worker which finds it, without blocking the workers finding others
+	airports := make([]*Airport, len(airportData))
+	once := make([]sync.Once, len(airportData))
+	runBatch(len(points), options, func(first, last int) {
+		for i := first; i < last; i++ {
+			item, distance := index.Nearest(points[i].LatitudeDeg, points[i].LongitudeDeg, radiusMeters, accept)
+			if item < 0 {
+				fn(i, NearestAirport{})
+				continue
+			}
+			once[item].Do(func() {
+				airports[item] = af.makeAirport(airportData[item])
+			})
+			fn(i, NearestAirport{Airport: airports[item], DistanceMeters: distance})
+		}
+	})
+}
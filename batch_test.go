@@ -0,0 +1,101 @@
+package alphafoxtrot
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDistanceMatrix(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	airports := finder.FindAllAirports("", "", "", AirportTypeAll)
+	matrix := DistanceMatrix(airports, airports[1:], &BatchOptions{Workers: 2})
+	if len(matrix) != len(airports) {
+		t.Fatalf("got %d rows, want %d", len(matrix), len(airports))
+	}
+	for i, from := range airports {
+		for j, to := range airports[1:] {
+			want := Distance(from.LatitudeDeg, from.LongitudeDeg, to.LatitudeDeg, to.LongitudeDeg)
+			if math.Abs(matrix[i][j]-want) > 0.01 {
+				t.Errorf("%s -> %s: got %.3f m, want %.3f m", from.ICAOCode, to.ICAOCode, matrix[i][j], want)
+			}
+		}
+	}
+	if err := DistanceMatrixInto(make([][]float64, 1), airports, airports, nil); err == nil {
+		t.Error("expected an error for missing rows")
+	}
+	if err := DistanceMatrixInto(DistanceMatrix(airports, airports[1:], nil), airports, airports, nil); err == nil {
+		t.Error("expected an error for missing columns")
+	}
+}
+
+func TestSpatialIndexNearest(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	positions := make([]Position, 2000)
+	for i := range positions {
+		positions[i] = Position{LatitudeDeg: random.Float64()*180 - 90, LongitudeDeg: random.Float64()*360 - 180}
+	}
+	index := NewSpatialIndex(len(positions), func(item int) (float64, float64) {
+		return positions[item].LatitudeDeg, positions[item].LongitudeDeg
+	})
+	even := func(item int) bool { return item%2 == 0 }
+	for n := 0; n < 200; n++ {
+		latitude, longitude := random.Float64()*180-90, random.Float64()*360-180
+		radius := -1.0
+		if n%2 == 1 {
+			radius = KilometersToMeters(500)
+		}
+		accept := even
+		if n%3 == 0 {
+			accept = nil
+		}
+		wantItem, wantDistance := -1, math.MaxFloat64
+		for item, position := range positions {
+			distance := Distance(latitude, longitude, position.LatitudeDeg, position.LongitudeDeg)
+			if (accept == nil || accept(item)) && (radius < 0 || distance <= radius) && distance < wantDistance {
+				wantItem, wantDistance = item, distance
+			}
+		}
+		item, distance := index.Nearest(latitude, longitude, radius, accept)
+		if item != wantItem || (item >= 0 && math.Abs(distance-wantDistance) > 0.01) {
+			t.Errorf("%v,%v radius %v: got %d at %.3f m, want %d at %.3f m", latitude, longitude, radius, item, distance, wantItem, wantDistance)
+		}
+	}
+}
+
+func TestFindNearestAirportBatch(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	points := make([]Position, 1000)
+	for i := range points {
+		// alternate between Santa Monica, Long Beach and the middle of the Pacific
+		points[i] = []Position{{34.02, -118.45}, {33.82, -118.15}, {0, -150}}[i%3]
+	}
+	if err := finder.FindNearestAirportBatch(points, -1, AirportTypeAll, make([]NearestAirport, 1), nil); err == nil {
+		t.Error("expected an error for too few results")
+	}
+	results := make([]NearestAirport, len(points))
+	if err := finder.FindNearestAirportBatch(points, KilometersToMeters(100), AirportTypeLarge|AirportTypeMedium, results, &BatchOptions{Workers: 4}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"KSMO", "KLGB", ""}
+	shared := make(map[string]*Airport)
+	for i, result := range results {
+		icaoCode := ""
+		if result.Airport != nil {
+			icaoCode = result.Airport.ICAOCode
+		}
+		if icaoCode != want[i%3] {
+			t.Fatalf("point %d: got %q, want %q", i, icaoCode, want[i%3])
+		}
+		if result.Airport == nil {
+			continue
+		}
+		if airport, ok := shared[icaoCode]; ok && airport != result.Airport {
+			t.Errorf("point %d: %s is not shared", i, icaoCode)
+		}
+		shared[icaoCode] = result.Airport
+		if distance := Distance(points[i].LatitudeDeg, points[i].LongitudeDeg, result.Airport.LatitudeDeg, result.Airport.LongitudeDeg); math.Abs(distance-result.DistanceMeters) > 0.01 {
+			t.Errorf("point %d: got %.3f m, want %.3f m", i, result.DistanceMeters, distance)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	alphafoxtrot "github.com/grumpypixel/go-airport-finder"
+	"github.com/grumpypixel/go-airport-finder/httpapi"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	dataDir := flag.String("data", "./data", "directory holding the OurAirports csv files")
+	types := flag.String("types", "all", "airport types to load, e.g. all, active, runways or small,medium,large")
+	overrides := flag.String("overrides", "", "optional JSON or CSV patch file with local overrides")
+	refresh := flag.Duration("refresh", 0, "refresh the data from OurAirports.com in this interval, e.g. 24h (disabled if 0)")
+	flag.Parse()
+
+	filter, err := alphafoxtrot.AirportTypeFilterFromString(*types)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	finder := alphafoxtrot.NewAirportFinder()
+	if *refresh > 0 {
+		refresher := alphafoxtrot.NewRefresher(finder, &alphafoxtrot.RefreshOptions{
+			DataDir:       *dataDir,
+			Interval:      *refresh,
+			AirportFilter: filter,
+			Overrides:     *overrides,
+		})
+		if err := refresher.Refresh(); err != nil {
+			log.Println("refresh failed:", err)
+		}
+		refresher.Start(context.Background())
+		defer refresher.Stop()
+	} else {
+		options := alphafoxtrot.PresetLoadOptions(*dataDir)
+		options.OverridesFilename = *overrides
+		if errs := finder.Load(options, filter); len(errs) > 0 {
+			log.Println("errors:", errs)
+		}
+	}
+
+	server := &http.Server{
+		Addr:         *addr,
+		Handler:      httpapi.NewHandler(finder),
+		ReadTimeout:  time.Second * 10,
+		WriteTimeout: time.Second * 30,
+	}
+	log.Println("listening on", *addr)
+	log.Fatal(server.ListenAndServe())
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	alphafoxtrot "github.com/grumpypixel/go-airport-finder"
+	"github.com/grumpypixel/go-airport-finder/httpapi"
+)
+
+func loadFinder(options globalOptions) (*alphafoxtrot.AirportFinder, error) {
+	finder := alphafoxtrot.NewAirportFinder()
+	if errs := finder.Load(alphafoxtrot.PresetLoadOptions(options.dataDir), alphafoxtrot.AirportTypeAll); len(errs) > 0 {
+		return nil, fmt.Errorf("cannot load data from %s: %v", options.dataDir, errs)
+	}
+	return finder, nil
+}
+
+// findAirport looks up an airport by its ICAO code first and by its IATA code second
+func findAirport(finder *alphafoxtrot.AirportFinder, code string) (*alphafoxtrot.Airport, error) {
+	code = strings.ToUpper(code)
+	if airport := finder.FindAirportByICAOCode(code); airport != nil {
+		return airport, nil
+	}
+	if airport := finder.FindAirportByIATACode(code); airport != nil {
+		return airport, nil
+	}
+	return nil, fmt.Errorf("airport %s not found", code)
+}
+
+// parseCommandFlags parses the flags of a command, positional arguments may appear before the flags
+func parseCommandFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0)
+	for len(args) > 0 {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) > 0 {
+			positional = append(positional, args[0])
+			args = args[1:]
+		}
+	}
+	return positional, nil
+}
+
+func runInfo(options globalOptions, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: info <ICAO|IATA>")
+	}
+	finder, err := loadFinder(options)
+	if err != nil {
+		return err
+	}
+	airport, err := findAirport(finder, args[0])
+	if err != nil {
+		return err
+	}
+	if options.jsonOutput {
+		return printJSON(httpapi.NewAirportResponse(airport))
+	}
+	printAirportInfo(airport)
+	return nil
+}
+
+func runNearest(options globalOptions, args []string) error {
+	flags := flag.NewFlagSet("nearest", flag.ContinueOnError)
+	latitude := flags.Float64("lat", 0, "latitude in degrees")
+	longitude := flags.Float64("lon", 0, "longitude in degrees")
+	radius := flags.String("radius", "25nm", "search radius, e.g. 25nm, 40km, 30mi")
+	maxResults := flags.Int("max", 10, "maximum number of results")
+	types := flags.String("type", "active", "airport types, e.g. active, runways, large,medium")
+	if _, err := parseCommandFlags(flags, args); err != nil {
+		return err
+	}
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["lat"] || !set["lon"] {
+		return fmt.Errorf("usage: nearest --lat <deg> --lon <deg> [--radius 25nm] [--max 10] [--type active]")
+	}
+	radiusMeters, err := alphafoxtrot.ParseDistance(*radius)
+	if err != nil {
+		return err
+	}
+	filter, err := alphafoxtrot.AirportTypeFilterFromString(*types)
+	if err != nil {
+		return err
+	}
+	finder, err := loadFinder(options)
+	if err != nil {
+		return err
+	}
+	airports := finder.FindNearestAirports(*latitude, *longitude, radiusMeters, *maxResults, filter)
+	if options.jsonOutput {
+		return printJSON(httpapi.NewAirportResponses(airports))
+	}
+	printAirportTable(airports, *latitude, *longitude)
+	return nil
+}
+
+func runNavaids(options globalOptions, args []string) error {
+	flags := flag.NewFlagSet("navaids", flag.ContinueOnError)
+	near := flags.String("near", "", "airport code or lat,lon")
+	associated := flags.String("airport", "", "list the navaids associated with this airport")
+	radius := flags.String("radius", "50km", "search radius, e.g. 25nm, 40km, 30mi")
+	maxResults := flags.Int("max", 10, "maximum number of results")
+	if _, err := parseCommandFlags(flags, args); err != nil {
+		return err
+	}
+	if (*near == "") == (*associated == "") {
+		return fmt.Errorf("usage: navaids --near <ICAO|IATA|lat,lon> or navaids --airport <ICAO>")
+	}
+	finder, err := loadFinder(options)
+	if err != nil {
+		return err
+	}
+
+	var navaids []*alphafoxtrot.Navaid
+	var latitude, longitude float64
+	if *associated != "" {
+		navaids = finder.FindNavaidsByAirportICAOCode(strings.ToUpper(*associated))
+		if airport := finder.FindAirportByICAOCode(strings.ToUpper(*associated)); airport != nil {
+			latitude, longitude = airport.LatitudeDeg, airport.LongitudeDeg
+		}
+	} else {
+		latitude, longitude, err = resolvePosition(finder, *near)
+		if err != nil {
+			return err
+		}
+		radiusMeters, err := alphafoxtrot.ParseDistance(*radius)
+		if err != nil {
+			return err
+		}
+		navaids = finder.FindNearestNavaids(latitude, longitude, radiusMeters, *maxResults)
+	}
+	if options.jsonOutput {
+		return printJSON(httpapi.NewNavaidResponses(navaids))
+	}
+	printNavaidTable(navaids, latitude, longitude)
+	return nil
+}
+
+// resolvePosition accepts either "lat,lon" or an airport code
+func resolvePosition(finder *alphafoxtrot.AirportFinder, str string) (float64, float64, error) {
+	if parts := strings.Split(str, ","); len(parts) == 2 {
+		latitude, err := alphafoxtrot.ParseFloat(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid latitude: %s", parts[0])
+		}
+		longitude, err := alphafoxtrot.ParseFloat(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid longitude: %s", parts[1])
+		}
+		return latitude, longitude, nil
+	}
+	airport, err := findAirport(finder, str)
+	if err != nil {
+		return 0, 0, err
+	}
+	return airport.LatitudeDeg, airport.LongitudeDeg, nil
+}
+
+type distanceResult struct {
+	From          string  `json:"from"`
+	To            string  `json:"to"`
+	Meters        float64 `json:"meters"`
+	Kilometers    float64 `json:"kilometers"`
+	NauticalMiles float64 `json:"nautical_miles"`
+	Miles         float64 `json:"miles"`
+	BearingDegT   float64 `json:"bearing_degT"`
+}
+
+func runDistance(options globalOptions, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: distance <from> <to>")
+	}
+	finder, err := loadFinder(options)
+	if err != nil {
+		return err
+	}
+	from, err := findAirport(finder, args[0])
+	if err != nil {
+		return err
+	}
+	to, err := findAirport(finder, args[1])
+	if err != nil {
+		return err
+	}
+	meters := alphafoxtrot.Distance(from.LatitudeDeg, from.LongitudeDeg, to.LatitudeDeg, to.LongitudeDeg)
+	result := distanceResult{
+		From:          from.ICAOCode,
+		To:            to.ICAOCode,
+		Meters:        meters,
+		Kilometers:    alphafoxtrot.MetersToKilometers(meters),
+		NauticalMiles: alphafoxtrot.MetersToNauticalMiles(meters),
+		Miles:         alphafoxtrot.MetersToMiles(meters),
+		BearingDegT:   alphafoxtrot.Bearing(from.LatitudeDeg, from.LongitudeDeg, to.LatitudeDeg, to.LongitudeDeg),
+	}
+	if options.jsonOutput {
+		return printJSON(result)
+	}
+	fmt.Printf("%s (%s) -> %s (%s)\n", from.ICAOCode, from.Name, to.ICAOCode, to.Name)
+	fmt.Printf("%.1f nm / %.1f km / %.1f mi, initial bearing %.0f° true\n", result.NauticalMiles, result.Kilometers, result.Miles, result.BearingDegT)
+	return nil
+}
+
+func runSearch(options globalOptions, args []string) error {
+	flags := flag.NewFlagSet("search", flag.ContinueOnError)
+	maxResults := flags.Int("max", 20, "maximum number of results")
+	types := flags.String("type", "all", "airport types, e.g. active, runways, large,medium")
+	positional, err := parseCommandFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return fmt.Errorf("usage: search <text>")
+	}
+	filter, err := alphafoxtrot.AirportTypeFilterFromString(*types)
+	if err != nil {
+		return err
+	}
+	finder, err := loadFinder(options)
+	if err != nil {
+		return err
+	}
+	airports := finder.SearchAirports(strings.Join(positional, " "), *maxResults, filter)
+	if options.jsonOutput {
+		return printJSON(httpapi.NewAirportResponses(airports))
+	}
+	printAirportTable(airports, 0, 0)
+	return nil
+}
+
+func runDownload(options globalOptions, args []string) error {
+	targetDir := options.dataDir
+	if len(args) > 0 {
+		targetDir = args[0]
+	}
+	downloadOptions := alphafoxtrot.DefaultDownloadOptions()
+	downloadOptions.Progress = alphafoxtrot.TerminalProgress{Writer: os.Stderr}
+	downloadOptions.SkipUnchanged = true
+	if _, errs := alphafoxtrot.DownloadDatabaseWithOptions(targetDir, downloadOptions); len(errs) > 0 {
+		return fmt.Errorf("download failed: %v", errs)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	alphafoxtrot "github.com/grumpypixel/go-airport-finder"
+)
+
+func runExport(options globalOptions, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	out := flags.String("out", "", "output file, - for stdout")
+	format := flags.String("format", "", "geojson, kml, kmz or gpx (default: derived from the output file extension, geojson for stdout)")
+	types := flags.String("type", "all", "airport types, e.g. active, runways, large,medium")
+	country := flags.String("country", "", "ISO country code, e.g. US")
+	region := flags.String("region", "", "ISO region code, e.g. US-CA")
+	continent := flags.String("continent", "", "continent code, e.g. NA")
+	runways := flags.Bool("runways", false, "include runways")
+	navaids := flags.Bool("navaids", false, "include the navaids associated with the airports")
+	if _, err := parseCommandFlags(flags, args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return fmt.Errorf("usage: export --out <file.geojson|file.kml|file.kmz|file.gpx>")
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*out)), ".")
+	}
+	filter, err := alphafoxtrot.AirportTypeFilterFromString(*types)
+	if err != nil {
+		return err
+	}
+	finder, err := loadFinder(options)
+	if err != nil {
+		return err
+	}
+	airports := finder.FindAllAirports(*region, *country, *continent, filter)
+
+	switch *format {
+	case "geojson", "json", "":
+		collection := alphafoxtrot.AirportsFeatureCollection(airports, *runways, *navaids)
+		return writeOutput(*out, collection.Write)
+	case "kml", "kmz":
+		var navaidList []*alphafoxtrot.Navaid
+		if *navaids {
+			navaidList = associatedNavaids(airports)
+		}
+		kmlOptions := &alphafoxtrot.KMLOptions{Name: "Airports", IncludeRunways: *runways}
+		return writeOutput(*out, func(w io.Writer) error {
+			if *format == "kmz" {
+				return alphafoxtrot.WriteKMZ(w, airports, navaidList, kmlOptions)
+			}
+			return alphafoxtrot.WriteKML(w, airports, navaidList, kmlOptions)
+		})
+	case "gpx":
+		var navaidList []*alphafoxtrot.Navaid
+		if *navaids {
+			navaidList = associatedNavaids(airports)
+		}
+		return writeOutput(*out, alphafoxtrot.NewGPXWaypoints(airports, navaidList).Write)
+	}
+	return fmt.Errorf("unknown format: %s", *format)
+}
+
+func associatedNavaids(airports []*alphafoxtrot.Airport) []*alphafoxtrot.Navaid {
+	navaids := make([]*alphafoxtrot.Navaid, 0)
+	for _, airport := range airports {
+		for i := range airport.Navaids {
+			navaids = append(navaids, &airport.Navaids[i])
+		}
+	}
+	return navaids
+}
+
+// writeOutput creates the file (or uses stdout for "-") and passes it to the write function
+func writeOutput(filename string, write func(w io.Writer) error) error {
+	if filename == "-" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
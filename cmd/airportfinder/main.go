@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+const usage = `usage: airportfinder [-data dir] [-json] <command> [arguments]
+
+commands:
+  info <ICAO|IATA>                                   show an airport with its runways, frequencies and navaids
+  nearest --lat <deg> --lon <deg> [--radius 25nm] [--max 10] [--type active]
+                                                     list the nearest airports
+  navaids --near <ICAO|IATA|lat,lon> [--radius 50km] [--max 10]
+  navaids --airport <ICAO>                           list navaids near a position or associated with an airport
+  distance <from> <to>                               great circle distance and bearing between two airports
+  search <text> [--max 20] [--type all]              search airports by code, name, municipality and keywords
+  download <dir>                                     download the csv files from OurAirports.com
+  export --out <file.geojson|kml|kmz|gpx> [--type all] [--country US] [--region US-CA] [--continent NA] [--runways] [--navaids]
+                                                     export a filtered subset of the airports as GeoJSON, KML, KMZ or GPX
+
+Distances accept the units m, km, nm and mi (default: m).
+`
+
+type globalOptions struct {
+	dataDir    string
+	jsonOutput bool
+}
+
+func main() {
+	flags := flag.NewFlagSet("airportfinder", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	options := globalOptions{}
+	flags.StringVar(&options.dataDir, "data", "./data", "directory holding the OurAirports csv files")
+	flags.BoolVar(&options.jsonOutput, "json", false, "print JSON instead of tables")
+	flags.Parse(os.Args[1:])
+
+	args := flags.Args()
+	if len(args) == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	commands := map[string]func(globalOptions, []string) error{
+		"info":     runInfo,
+		"nearest":  runNearest,
+		"navaids":  runNavaids,
+		"distance": runDistance,
+		"search":   runSearch,
+		"download": runDownload,
+		"export":   runExport,
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n\n", args[0])
+		flags.Usage()
+		os.Exit(2)
+	}
+	if err := command(options, args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
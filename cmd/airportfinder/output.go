@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	alphafoxtrot "github.com/grumpypixel/go-airport-finder"
+)
+
+// printJSON prints the value indented, pass the httpapi response types so the output matches the server
+func printJSON(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func newTable() *tabwriter.Writer {
+	return tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+}
+
+func printAirportInfo(airport *alphafoxtrot.Airport) {
+	w := newTable()
+	fmt.Fprintf(w, "ICAO\t%s\n", airport.ICAOCode)
+	fmt.Fprintf(w, "IATA\t%s\n", airport.IATACode)
+	fmt.Fprintf(w, "Name\t%s\n", airport.Name)
+	fmt.Fprintf(w, "Type\t%s\n", airport.Type)
+	fmt.Fprintf(w, "Municipality\t%s\n", airport.Municipality)
+	fmt.Fprintf(w, "Region\t%s %s\n", airport.Region.ISOCode, airport.Region.Name)
+	fmt.Fprintf(w, "Country\t%s %s\n", airport.Country.ISOCode, airport.Country.Name)
+	fmt.Fprintf(w, "Position\t%.6f, %.6f\n", airport.LatitudeDeg, airport.LongitudeDeg)
+	fmt.Fprintf(w, "Elevation\t%d ft\n", airport.ElevationFt)
+	w.Flush()
+
+	if len(airport.Runways) > 0 {
+		fmt.Println("\nRunways:")
+		w = newTable()
+		fmt.Fprintln(w, "IDENT\tLENGTH FT\tWIDTH FT\tSURFACE\tLIGHTED\tCLOSED")
+		for _, runway := range airport.Runways {
+			fmt.Fprintf(w, "%s/%s\t%d\t%d\t%s\t%v\t%v\n", runway.LowEndIdent, runway.HighEndIdent, runway.LengthFt, runway.WidthFt, runway.Surface, runway.Lighted, runway.Closed)
+		}
+		w.Flush()
+	}
+	if len(airport.Frequencies) > 0 {
+		fmt.Println("\nFrequencies:")
+		w = newTable()
+		fmt.Fprintln(w, "TYPE\tMHZ\tDESCRIPTION")
+		for _, frequency := range airport.Frequencies {
+			fmt.Fprintf(w, "%s\t%.3f\t%s\n", frequency.Type, frequency.FrequencyMHZ, frequency.Description)
+		}
+		w.Flush()
+	}
+	if len(airport.Navaids) > 0 {
+		fmt.Println("\nNavaids:")
+		navaids := make([]*alphafoxtrot.Navaid, 0, len(airport.Navaids))
+		for i := range airport.Navaids {
+			navaids = append(navaids, &airport.Navaids[i])
+		}
+		printNavaidTable(navaids, airport.LatitudeDeg, airport.LongitudeDeg)
+	}
+}
+
+// printAirportTable prints the airports, the distance column is omitted if the reference position is 0,0
+func printAirportTable(airports []*alphafoxtrot.Airport, latitudeDeg, longitudeDeg float64) {
+	withDistance := latitudeDeg != 0 || longitudeDeg != 0
+	w := newTable()
+	if withDistance {
+		fmt.Fprintln(w, "ICAO\tIATA\tNAME\tTYPE\tCOUNTRY\tDIST NM\tBRG")
+	} else {
+		fmt.Fprintln(w, "ICAO\tIATA\tNAME\tTYPE\tCOUNTRY")
+	}
+	for _, airport := range airports {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s", airport.ICAOCode, airport.IATACode, airport.Name, airport.Type, airport.Country.ISOCode)
+		if withDistance {
+			distance := alphafoxtrot.Distance(latitudeDeg, longitudeDeg, airport.LatitudeDeg, airport.LongitudeDeg)
+			bearing := alphafoxtrot.Bearing(latitudeDeg, longitudeDeg, airport.LatitudeDeg, airport.LongitudeDeg)
+			fmt.Fprintf(w, "\t%.1f\t%03.0f", alphafoxtrot.MetersToNauticalMiles(distance), bearing)
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+}
+
+func printNavaidTable(navaids []*alphafoxtrot.Navaid, latitudeDeg, longitudeDeg float64) {
+	w := newTable()
+	fmt.Fprintln(w, "IDENT\tNAME\tTYPE\tFREQ KHZ\tAIRPORT\tDIST NM")
+	for _, navaid := range navaids {
+		distance := alphafoxtrot.Distance(latitudeDeg, longitudeDeg, navaid.LatitudeDeg, navaid.LongitudeDeg)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%.1f\n", navaid.Ident, navaid.Name, navaid.Type, navaid.FrequencyKHZ, navaid.AssociatedAirport, alphafoxtrot.MetersToNauticalMiles(distance))
+	}
+	w.Flush()
+}
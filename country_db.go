@@ -49,8 +49,15 @@ func (db *CountryDB) Parse(file string, skipFirstLine bool) error {
 		return err
 	}
 	defer f.Close()
+	return ReadCountries(f, skipFirstLine, func(country *CountryData) error {
+		db.Countries[country.ISOCode] = country
+		return nil
+	})
+}
 
-	reader := csv.NewReader(f)
+// ReadCountries calls fn for every record of the csv and stops at the first error returned by fn
+func ReadCountries(r io.Reader, skipFirstLine bool, fn func(*CountryData) error) error {
+	reader := csv.NewReader(r)
 	line := -1
 
 	for {
@@ -87,7 +94,9 @@ func (db *CountryDB) Parse(file string, skipFirstLine bool) error {
 			WikipediaLink: wikipedia,
 			Keywords:      keywords,
 		}
-		db.Countries[isoCode] = country
+		if err := fn(country); err != nil {
+			return err
+		}
 	}
 }
 
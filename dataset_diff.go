@@ -0,0 +1,219 @@
+package alphafoxtrot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+const (
+	RecordKindAirport   = "airport"
+	RecordKindRunway    = "runway"
+	RecordKindFrequency = "frequency"
+	RecordKindNavaid    = "navaid"
+)
+
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+type RecordChange struct {
+	Kind   string        `json:"kind"`
+	Change string        `json:"change"`
+	ID     uint64        `json:"id"`
+	Ident  string        `json:"ident"`
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// DatasetDiff holds the records that were added, removed or modified between two snapshots, keyed by their OurAirports ID
+type DatasetDiff struct {
+	Airports    []RecordChange `json:"airports"`
+	Runways     []RecordChange `json:"runways"`
+	Frequencies []RecordChange `json:"frequencies"`
+	Navaids     []RecordChange `json:"navaids"`
+}
+
+// fields which are derived from other fields and therefore not worth reporting
+var diffIgnoredFields = map[string]bool{
+	"TypeFlag": true,
+}
+
+func DiffAirportFinders(oldFinder, newFinder *AirportFinder) *DatasetDiff {
+	oldFinder.mutex.RLock()
+	defer oldFinder.mutex.RUnlock()
+	if oldFinder != newFinder {
+		newFinder.mutex.RLock()
+		defer newFinder.mutex.RUnlock()
+	}
+
+	diff := &DatasetDiff{}
+
+	oldAirports := make(map[uint64]interface{})
+	for _, airport := range oldFinder.airportDB.Airports {
+		oldAirports[airport.ID] = airport
+	}
+	newAirports := make(map[uint64]interface{})
+	for _, airport := range newFinder.airportDB.Airports {
+		newAirports[airport.ID] = airport
+	}
+	diff.Airports = diffRecords(RecordKindAirport, oldAirports, newAirports, func(record interface{}) string {
+		return record.(*AirportData).ICAOCode
+	})
+
+	oldRunways := make(map[uint64]interface{})
+	for _, runways := range oldFinder.runwayDB.Runways {
+		for _, runway := range runways {
+			oldRunways[runway.ID] = runway
+		}
+	}
+	newRunways := make(map[uint64]interface{})
+	for _, runways := range newFinder.runwayDB.Runways {
+		for _, runway := range runways {
+			newRunways[runway.ID] = runway
+		}
+	}
+	diff.Runways = diffRecords(RecordKindRunway, oldRunways, newRunways, func(record interface{}) string {
+		runway := record.(*RunwayData)
+		return fmt.Sprintf("%s %s/%s", runway.AirportIdent, runway.LowEndIdent, runway.HighEndIdent)
+	})
+
+	oldFrequencies := make(map[uint64]interface{})
+	for _, frequencies := range oldFinder.frequencyDB.Frequencies {
+		for _, frequency := range frequencies {
+			oldFrequencies[frequency.ID] = frequency
+		}
+	}
+	newFrequencies := make(map[uint64]interface{})
+	for _, frequencies := range newFinder.frequencyDB.Frequencies {
+		for _, frequency := range frequencies {
+			newFrequencies[frequency.ID] = frequency
+		}
+	}
+	diff.Frequencies = diffRecords(RecordKindFrequency, oldFrequencies, newFrequencies, func(record interface{}) string {
+		frequency := record.(*FrequencyData)
+		return fmt.Sprintf("%s %s", frequency.AirportIdent, frequency.Type)
+	})
+
+	oldNavaids := make(map[uint64]interface{})
+	for _, navaid := range oldFinder.navaidDB.Navaids {
+		oldNavaids[navaid.ID] = navaid
+	}
+	newNavaids := make(map[uint64]interface{})
+	for _, navaid := range newFinder.navaidDB.Navaids {
+		newNavaids[navaid.ID] = navaid
+	}
+	diff.Navaids = diffRecords(RecordKindNavaid, oldNavaids, newNavaids, func(record interface{}) string {
+		return record.(*NavaidData).Ident
+	})
+
+	return diff
+}
+
+func DiffDataDirs(oldDir, newDir string, airportFilter uint64) (*DatasetDiff, []error) {
+	oldFinder := NewAirportFinder()
+	if errs := oldFinder.Load(PresetLoadOptions(oldDir), airportFilter); len(errs) > 0 {
+		return nil, errs
+	}
+	newFinder := NewAirportFinder()
+	if errs := newFinder.Load(PresetLoadOptions(newDir), airportFilter); len(errs) > 0 {
+		return nil, errs
+	}
+	return DiffAirportFinders(oldFinder, newFinder), nil
+}
+
+func (diff *DatasetDiff) Empty() bool {
+	return len(diff.Airports) == 0 && len(diff.Runways) == 0 && len(diff.Frequencies) == 0 && len(diff.Navaids) == 0
+}
+
+func (diff *DatasetDiff) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(diff)
+}
+
+// WriteText writes a human-readable changelog
+func (diff *DatasetDiff) WriteText(w io.Writer) error {
+	sections := []struct {
+		title   string
+		changes []RecordChange
+	}{
+		{"Airports", diff.Airports},
+		{"Runways", diff.Runways},
+		{"Frequencies", diff.Frequencies},
+		{"Navaids", diff.Navaids},
+	}
+	for _, section := range sections {
+		if len(section.changes) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s (%d changes)\n", section.title, len(section.changes)); err != nil {
+			return err
+		}
+		for _, change := range section.changes {
+			if _, err := fmt.Fprintf(w, "  %-8s %s #%d %s\n", change.Change, change.Kind, change.ID, change.Ident); err != nil {
+				return err
+			}
+			for _, field := range change.Fields {
+				if _, err := fmt.Fprintf(w, "           %s: %v -> %v\n", field.Field, field.Old, field.New); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func diffRecords(kind string, oldRecords, newRecords map[uint64]interface{}, identOf func(interface{}) string) []RecordChange {
+	changes := make([]RecordChange, 0)
+	for id, oldRecord := range oldRecords {
+		newRecord, ok := newRecords[id]
+		if !ok {
+			changes = append(changes, RecordChange{Kind: kind, Change: ChangeRemoved, ID: id, Ident: identOf(oldRecord)})
+			continue
+		}
+		if fields := diffFields(oldRecord, newRecord); len(fields) > 0 {
+			changes = append(changes, RecordChange{Kind: kind, Change: ChangeModified, ID: id, Ident: identOf(newRecord), Fields: fields})
+		}
+	}
+	for id, newRecord := range newRecords {
+		if _, ok := oldRecords[id]; !ok {
+			changes = append(changes, RecordChange{Kind: kind, Change: ChangeAdded, ID: id, Ident: identOf(newRecord)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].ID < changes[j].ID
+	})
+	return changes
+}
+
+func diffFields(oldRecord, newRecord interface{}) []FieldChange {
+	oldValue := reflect.Indirect(reflect.ValueOf(oldRecord))
+	newValue := reflect.Indirect(reflect.ValueOf(newRecord))
+	typ := oldValue.Type()
+
+	fields := make([]FieldChange, 0)
+	for i := 0; i < typ.NumField(); i++ {
+		name := typ.Field(i).Name
+		// unexported fields cannot be read through Interface
+		if diffIgnoredFields[name] || !typ.Field(i).IsExported() {
+			continue
+		}
+		oldField := oldValue.Field(i).Interface()
+		newField := newValue.Field(i).Interface()
+		// DeepEqual compares slices and maps, which would panic with !=
+		if !reflect.DeepEqual(oldField, newField) {
+			fields = append(fields, FieldChange{Field: name, Old: oldField, New: newField})
+		}
+	}
+	return fields
+}
@@ -0,0 +1,106 @@
+package alphafoxtrot
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDiffAirportFinders(t *testing.T) {
+	oldFinder := loadTestFinder(t, AirportTypeAll)
+	newFinder := loadTestFinder(t, AirportTypeAll)
+	if diff := DiffAirportFinders(oldFinder, newFinder); !diff.Empty() {
+		t.Fatalf("same data: got %+v", diff)
+	}
+
+	airports := make([]*AirportData, 0, len(newFinder.airportDB.Airports))
+	for _, airport := range newFinder.airportDB.Airports {
+		switch airport.ICAOCode {
+		case "EDDF":
+			continue
+		case "KSMO":
+			airport.Name = "Santa Monica"
+			airport.ElevationFt = 180
+		}
+		airports = append(airports, airport)
+	}
+	newFinder.airportDB.Airports = append(airports, &AirportData{ID: 9001, ICAOCode: "XNEW", Name: "New"})
+	newFinder.runwayDB.Runways[3878][0].LengthFt = 3000
+	newFinder.navaidDB.Navaids = append(newFinder.navaidDB.Navaids, &NavaidData{ID: 9002, Ident: "NEW"})
+
+	diff := DiffAirportFinders(oldFinder, newFinder)
+	if len(diff.Airports) != 3 || len(diff.Runways) != 1 || len(diff.Frequencies) != 0 || len(diff.Navaids) != 1 {
+		t.Fatalf("got %+v", diff)
+	}
+	changes := make(map[string]RecordChange)
+	for _, change := range diff.Airports {
+		changes[change.Ident] = change
+	}
+	if change := changes["EDDF"]; change.Change != ChangeRemoved || change.Kind != RecordKindAirport || len(change.Fields) != 0 {
+		t.Errorf("unexpected EDDF change %+v", change)
+	}
+	if change := changes["XNEW"]; change.Change != ChangeAdded || change.ID != 9001 {
+		t.Errorf("unexpected XNEW change %+v", change)
+	}
+	ksmo := changes["KSMO"]
+	if ksmo.Change != ChangeModified || len(ksmo.Fields) != 2 ||
+		ksmo.Fields[0] != (FieldChange{Field: "Name", Old: "Santa Monica Municipal Airport", New: "Santa Monica"}) ||
+		ksmo.Fields[1] != (FieldChange{Field: "ElevationFt", Old: int64(177), New: int64(180)}) {
+		t.Errorf("unexpected KSMO change %+v", ksmo)
+	}
+	if runway := diff.Runways[0]; runway.Ident != "KSMO 03/21" || len(runway.Fields) != 1 || runway.Fields[0].Field != "LengthFt" {
+		t.Errorf("unexpected runway change %+v", runway)
+	}
+	for i := 1; i < len(diff.Airports); i++ {
+		if diff.Airports[i-1].ID >= diff.Airports[i].ID {
+			t.Error("changes are not sorted by ID")
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := diff.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string][]map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded["airports"]) != 3 || len(decoded["navaids"]) != 1 || decoded["navaids"][0]["change"] != ChangeAdded || decoded["navaids"][0]["ident"] != "NEW" {
+		t.Errorf("unexpected JSON %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := diff.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	text := buf.String()
+	for _, want := range []string{"Airports (3 changes)", "Runways (1 changes)", "Navaids (1 changes)", "removed  airport", "ElevationFt: 177 -> 180", "LengthFt: 3500 -> 3000"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text is missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Frequencies") {
+		t.Errorf("text lists the unchanged frequencies:\n%s", text)
+	}
+}
+
+func TestDiffFieldsSlicesAndUnexported(t *testing.T) {
+	type record struct {
+		Name    string
+		Tags    []string
+		Values  map[string]int
+		private []int
+	}
+	oldRecord := &record{Name: "a", Tags: []string{"x"}, Values: map[string]int{"a": 1}, private: []int{1}}
+	newRecord := &record{Name: "a", Tags: []string{"x"}, Values: map[string]int{"a": 1}, private: []int{2}}
+	if fields := diffFields(oldRecord, newRecord); len(fields) != 0 {
+		t.Errorf("equal records: got %+v", fields)
+	}
+	newRecord.Tags = []string{"x", "y"}
+	newRecord.Values = map[string]int{"a": 2}
+	fields := diffFields(oldRecord, newRecord)
+	if len(fields) != 2 || fields[0].Field != "Tags" || fields[1].Field != "Values" {
+		t.Errorf("got %+v", fields)
+	}
+}
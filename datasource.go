@@ -0,0 +1,439 @@
+package alphafoxtrot
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// DataSource yields the records of a dataset as streams: each method calls fn for every record and stops at the first error returned by fn.
+// Sources are e.g. the OurAirports csv files (CSVSource), databases held in memory (DatabasesSource),
+// vendor files read by an Importer (ImporterSource) or a SQLite file (see sqlitedb.Source).
+type DataSource interface {
+	Name() string
+	Airports(fn func(*AirportData) error) error
+	Runways(fn func(*RunwayData) error) error
+	Frequencies(fn func(*FrequencyData) error) error
+	Navaids(fn func(*NavaidData) error) error
+	Regions(fn func(*RegionData) error) error
+	Countries(fn func(*CountryData) error) error
+}
+
+// CSVSource reads the OurAirports csv files, files with an empty filename are skipped
+type CSVSource struct {
+	Options *LoadOptions
+}
+
+func NewCSVSource(options *LoadOptions) *CSVSource {
+	return &CSVSource{Options: options}
+}
+
+func (src *CSVSource) Name() string {
+	return "ourairports"
+}
+
+func (src *CSVSource) Airports(fn func(*AirportData) error) error {
+	return readCSVFile(src.Options.AirportsFilename, func(r io.Reader) error {
+		return ReadAirports(r, AirportTypeAll, true, fn)
+	})
+}
+
+func (src *CSVSource) Runways(fn func(*RunwayData) error) error {
+	return readCSVFile(src.Options.RunwaysFilename, func(r io.Reader) error {
+		return ReadRunways(r, true, fn)
+	})
+}
+
+func (src *CSVSource) Frequencies(fn func(*FrequencyData) error) error {
+	return readCSVFile(src.Options.FrequenciesFilename, func(r io.Reader) error {
+		return ReadFrequencies(r, true, fn)
+	})
+}
+
+func (src *CSVSource) Navaids(fn func(*NavaidData) error) error {
+	return readCSVFile(src.Options.NavaidsFilename, func(r io.Reader) error {
+		return ReadNavaids(r, true, fn)
+	})
+}
+
+func (src *CSVSource) Regions(fn func(*RegionData) error) error {
+	return readCSVFile(src.Options.RegionsFilename, func(r io.Reader) error {
+		return ReadRegions(r, true, fn)
+	})
+}
+
+func (src *CSVSource) Countries(fn func(*CountryData) error) error {
+	return readCSVFile(src.Options.CountriesFilename, func(r io.Reader) error {
+		return ReadCountries(r, true, fn)
+	})
+}
+
+func readCSVFile(file string, read func(r io.Reader) error) error {
+	if file == "" {
+		return nil
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return read(f)
+}
+
+// DatabasesSource yields the records of databases held in memory, e.g. the result of ParseXPlane or a snapshot of another finder
+type DatabasesSource struct {
+	name      string
+	databases *Databases
+}
+
+func NewDatabasesSource(name string, databases *Databases) *DatabasesSource {
+	return &DatabasesSource{name: name, databases: databases}
+}
+
+func (src *DatabasesSource) Name() string {
+	return src.name
+}
+
+func (src *DatabasesSource) Airports(fn func(*AirportData) error) error {
+	if src.databases.Airports == nil {
+		return nil
+	}
+	for _, airport := range src.databases.Airports.Airports {
+		if err := fn(airport); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (src *DatabasesSource) Runways(fn func(*RunwayData) error) error {
+	if src.databases.Runways == nil {
+		return nil
+	}
+	airportIDs := make([]uint64, 0, len(src.databases.Runways.Runways))
+	for airportID := range src.databases.Runways.Runways {
+		airportIDs = append(airportIDs, airportID)
+	}
+	sortUint64s(airportIDs)
+	for _, airportID := range airportIDs {
+		for _, runway := range src.databases.Runways.Runways[airportID] {
+			if err := fn(runway); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (src *DatabasesSource) Frequencies(fn func(*FrequencyData) error) error {
+	if src.databases.Frequencies == nil {
+		return nil
+	}
+	airportIDs := make([]uint64, 0, len(src.databases.Frequencies.Frequencies))
+	for airportID := range src.databases.Frequencies.Frequencies {
+		airportIDs = append(airportIDs, airportID)
+	}
+	sortUint64s(airportIDs)
+	for _, airportID := range airportIDs {
+		for _, frequency := range src.databases.Frequencies.Frequencies[airportID] {
+			if err := fn(frequency); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (src *DatabasesSource) Navaids(fn func(*NavaidData) error) error {
+	if src.databases.Navaids == nil {
+		return nil
+	}
+	for _, navaid := range src.databases.Navaids.Navaids {
+		if err := fn(navaid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (src *DatabasesSource) Regions(fn func(*RegionData) error) error {
+	if src.databases.Regions == nil {
+		return nil
+	}
+	codes := make([]string, 0, len(src.databases.Regions.Regions))
+	for code := range src.databases.Regions.Regions {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if err := fn(src.databases.Regions.Regions[code]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (src *DatabasesSource) Countries(fn func(*CountryData) error) error {
+	if src.databases.Countries == nil {
+		return nil
+	}
+	codes := make([]string, 0, len(src.databases.Countries.Countries))
+	for code := range src.databases.Countries.Countries {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if err := fn(src.databases.Countries.Countries[code]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImporterSource imports a vendor file (see Importer) on first use and yields its records
+type ImporterSource struct {
+	name     string
+	importer Importer
+	file     string
+	source   *DatabasesSource
+	err      error
+}
+
+func NewImporterSource(name string, importer Importer, file string) *ImporterSource {
+	return &ImporterSource{name: name, importer: importer, file: file}
+}
+
+func (src *ImporterSource) Name() string {
+	return src.name
+}
+
+func (src *ImporterSource) load() (*DatabasesSource, error) {
+	if src.source == nil && src.err == nil {
+		databases := NewDatabases()
+		src.err = ImportFile(src.importer, src.file, databases, AirportTypeAll)
+		src.source = NewDatabasesSource(src.name, databases)
+	}
+	return src.source, src.err
+}
+
+func (src *ImporterSource) Airports(fn func(*AirportData) error) error {
+	source, err := src.load()
+	if err != nil {
+		return err
+	}
+	return source.Airports(fn)
+}
+
+func (src *ImporterSource) Runways(fn func(*RunwayData) error) error {
+	source, err := src.load()
+	if err != nil {
+		return err
+	}
+	return source.Runways(fn)
+}
+
+func (src *ImporterSource) Frequencies(fn func(*FrequencyData) error) error {
+	source, err := src.load()
+	if err != nil {
+		return err
+	}
+	return source.Frequencies(fn)
+}
+
+func (src *ImporterSource) Navaids(fn func(*NavaidData) error) error {
+	source, err := src.load()
+	if err != nil {
+		return err
+	}
+	return source.Navaids(fn)
+}
+
+func (src *ImporterSource) Regions(fn func(*RegionData) error) error {
+	source, err := src.load()
+	if err != nil {
+		return err
+	}
+	return source.Regions(fn)
+}
+
+func (src *ImporterSource) Countries(fn func(*CountryData) error) error {
+	source, err := src.load()
+	if err != nil {
+		return err
+	}
+	return source.Countries(fn)
+}
+
+// LoadSource replaces the data of the finder with the data of the source
+func (af *AirportFinder) LoadSource(source DataSource, airportTypeFilter uint64) []error {
+	return af.LoadSources(airportTypeFilter, source)
+}
+
+// LoadSources replaces the data of the finder with the merged data of the sources, earlier sources take priority:
+// an airport is skipped along with its runways and frequencies if an earlier source has an airport with the same ICAO code
+// (airports without ICAO code are always loaded),
+// navaids are matched by ident, type and country, regions and countries by ISO code.
+// Records whose ID is already taken by an earlier source are renumbered.
+// Runways and frequencies of airports which aren't loaded are skipped. Errors don't stop the loading of the other sources.
+func (af *AirportFinder) LoadSources(airportTypeFilter uint64, sources ...DataSource) []error {
+	merger := newSourceMerger()
+	errors := make([]error, 0)
+	for _, source := range sources {
+		for _, err := range merger.merge(source, airportTypeFilter) {
+			errors = append(errors, fmt.Errorf("%s: %v", source.Name(), err))
+		}
+	}
+
+	af.mutex.Lock()
+	defer af.mutex.Unlock()
+	af.airportDB = merger.databases.Airports
+	af.frequencyDB = merger.databases.Frequencies
+	af.runwayDB = merger.databases.Runways
+	af.regionDB = merger.databases.Regions
+	af.countryDB = merger.databases.Countries
+	af.navaidDB = merger.databases.Navaids
+	return errors
+}
+
+type sourceMerger struct {
+	databases      *Databases
+	icaoCodes      map[string]bool
+	navaidKeys     map[string]bool
+	airportIDs     map[uint64]bool
+	runwayIDs      map[uint64]bool
+	frequencyIDs   map[uint64]bool
+	navaidIDs      map[uint64]bool
+	maxAirportID   uint64
+	maxRunwayID    uint64
+	maxFrequencyID uint64
+	maxNavaidID    uint64
+}
+
+func newSourceMerger() *sourceMerger {
+	return &sourceMerger{
+		databases:    NewDatabases(),
+		icaoCodes:    make(map[string]bool),
+		navaidKeys:   make(map[string]bool),
+		airportIDs:   make(map[uint64]bool),
+		runwayIDs:    make(map[uint64]bool),
+		frequencyIDs: make(map[uint64]bool),
+		navaidIDs:    make(map[uint64]bool),
+	}
+}
+
+// claimID returns the ID if it is free, otherwise a new one
+func claimID(taken map[uint64]bool, max *uint64, id uint64) uint64 {
+	if id == 0 || taken[id] {
+		id = *max + 1
+	}
+	taken[id] = true
+	if id > *max {
+		*max = id
+	}
+	return id
+}
+
+func (m *sourceMerger) merge(source DataSource, airportTypeFilter uint64) []error {
+	errors := make([]error, 0)
+	// maps the airport IDs of the source to the IDs of the loaded airports
+	airportIDs := make(map[uint64]uint64)
+	// like navaids, only airports of earlier sources are duplicates, airports without ICAO code never are
+	icaoCodes := make(map[string]bool)
+	// records are copied since the source may hand out records it keeps using
+	err := source.Airports(func(airport *AirportData) error {
+		typeFlag := airport.TypeFlag
+		if typeFlag == AirportTypeUnknown {
+			typeFlag = AirportTypeFromString(airport.Type)
+		}
+		if typeFlag&airportTypeFilter == 0 || (airport.ICAOCode != "" && m.icaoCodes[airport.ICAOCode]) {
+			return nil
+		}
+		copied := *airport
+		copied.TypeFlag = typeFlag
+		copied.ID = claimID(m.airportIDs, &m.maxAirportID, airport.ID)
+		airportIDs[airport.ID] = copied.ID
+		icaoCodes[copied.ICAOCode] = true
+		m.databases.Airports.Airports = append(m.databases.Airports.Airports, &copied)
+		return nil
+	})
+	if err != nil {
+		errors = append(errors, fmt.Errorf("airports: %v", err))
+	}
+	for icaoCode := range icaoCodes {
+		m.icaoCodes[icaoCode] = true
+	}
+
+	err = source.Runways(func(runway *RunwayData) error {
+		airportID, ok := airportIDs[runway.AirportID]
+		if !ok {
+			return nil
+		}
+		copied := *runway
+		copied.ID = claimID(m.runwayIDs, &m.maxRunwayID, runway.ID)
+		copied.AirportID = airportID
+		m.databases.Runways.Runways[airportID] = append(m.databases.Runways.Runways[airportID], &copied)
+		return nil
+	})
+	if err != nil {
+		errors = append(errors, fmt.Errorf("runways: %v", err))
+	}
+
+	err = source.Frequencies(func(frequency *FrequencyData) error {
+		airportID, ok := airportIDs[frequency.AirportID]
+		if !ok {
+			return nil
+		}
+		copied := *frequency
+		copied.ID = claimID(m.frequencyIDs, &m.maxFrequencyID, frequency.ID)
+		copied.AirportID = airportID
+		m.databases.Frequencies.Frequencies[airportID] = append(m.databases.Frequencies.Frequencies[airportID], &copied)
+		return nil
+	})
+	if err != nil {
+		errors = append(errors, fmt.Errorf("frequencies: %v", err))
+	}
+
+	// navaids of the same source may share ident, type and country, only those of earlier sources are duplicates
+	navaidKeys := make(map[string]bool)
+	err = source.Navaids(func(navaid *NavaidData) error {
+		key := navaid.Ident + "|" + navaid.Type + "|" + navaid.ISOCountry
+		if m.navaidKeys[key] {
+			return nil
+		}
+		navaidKeys[key] = true
+		copied := *navaid
+		copied.ID = claimID(m.navaidIDs, &m.maxNavaidID, navaid.ID)
+		m.databases.Navaids.Navaids = append(m.databases.Navaids.Navaids, &copied)
+		return nil
+	})
+	if err != nil {
+		errors = append(errors, fmt.Errorf("navaids: %v", err))
+	}
+	for key := range navaidKeys {
+		m.navaidKeys[key] = true
+	}
+
+	err = source.Regions(func(region *RegionData) error {
+		if _, ok := m.databases.Regions.Regions[region.ISOCode]; !ok {
+			copied := *region
+			m.databases.Regions.Regions[region.ISOCode] = &copied
+		}
+		return nil
+	})
+	if err != nil {
+		errors = append(errors, fmt.Errorf("regions: %v", err))
+	}
+
+	err = source.Countries(func(country *CountryData) error {
+		if _, ok := m.databases.Countries.Countries[country.ISOCode]; !ok {
+			copied := *country
+			m.databases.Countries.Countries[country.ISOCode] = &copied
+		}
+		return nil
+	})
+	if err != nil {
+		errors = append(errors, fmt.Errorf("countries: %v", err))
+	}
+	return errors
+}
@@ -0,0 +1,106 @@
+package alphafoxtrot
+
+import "testing"
+
+// testMergeDatabases overlaps the OurAirports fixture: KLAX and the LAX VORTAC are duplicates,
+// the IDs 3632 (KLAX), 240922 (a KLAX runway) and 60768 (a KLAX frequency) are taken
+func testMergeDatabases() *Databases {
+	databases := NewDatabases()
+	databases.Airports.Airports = []*AirportData{
+		{ID: 1, ICAOCode: "KLAX", Type: AirportTypeLargeName, Name: "Duplicate"},
+		{ID: 3632, ICAOCode: "XDUP", Type: AirportTypeSmallName, Name: "First"},
+		{ID: 2, ICAOCode: "XDUP", Type: AirportTypeSmallName, Name: "Second"},
+		{ID: 3, Type: AirportTypeHeliportName, Name: "No Code"},
+		{ID: 4, Type: AirportTypeHeliportName, Name: "No Code Either"},
+	}
+	databases.Runways.Runways[1] = []*RunwayData{{ID: 7, AirportID: 1, LowEndIdent: "01"}}
+	databases.Runways.Runways[3632] = []*RunwayData{{ID: 240922, AirportID: 3632, LowEndIdent: "18"}}
+	databases.Frequencies.Frequencies[3632] = []*FrequencyData{{ID: 60768, AirportID: 3632, Type: "CTAF"}}
+	databases.Navaids.Navaids = []*NavaidData{
+		{ID: 1, Ident: "LAX", Type: "VORTAC", ISOCountry: "US"},
+		{ID: 90184, Ident: "XYZ", Type: "NDB", ISOCountry: "US"},
+	}
+	databases.Regions.Regions["US-CA"] = &RegionData{ISOCode: "US-CA", Name: "Duplicate"}
+	return databases
+}
+
+func TestLoadSourcesPriority(t *testing.T) {
+	finder := NewAirportFinder()
+	sources := []DataSource{NewCSVSource(PresetLoadOptions(testDataDir)), NewDatabasesSource("extra", testMergeDatabases())}
+	if errs := finder.LoadSources(AirportTypeAll, sources...); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	klax := finder.FindAirportByICAOCode("KLAX")
+	if klax.Name != "Los Angeles International Airport" || len(klax.Runways) != 2 || len(klax.Frequencies) != 2 {
+		t.Errorf("the earlier source did not take priority: %s, %d runways, %d frequencies", klax.Name, len(klax.Runways), len(klax.Frequencies))
+	}
+	if _, ok := finder.runwayDB.Runways[1]; ok {
+		t.Error("the runway of the skipped airport was loaded")
+	}
+
+	counts := make(map[string]int)
+	for _, airport := range finder.airportDB.Airports {
+		counts[airport.ICAOCode]++
+	}
+	// airports of the same source and airports without ICAO code are no duplicates
+	if counts["KLAX"] != 1 || counts["XDUP"] != 2 || counts[""] != 2 {
+		t.Errorf("unexpected airports %v", counts)
+	}
+	if len(finder.navaidDB.Navaids) != 2 {
+		t.Errorf("got %d navaids, want 2", len(finder.navaidDB.Navaids))
+	}
+	if region := finder.regionDB.Regions["US-CA"]; region.Name != "California" {
+		t.Errorf("the earlier region did not take priority: %s", region.Name)
+	}
+}
+
+func TestLoadSourcesRenumbering(t *testing.T) {
+	finder := NewAirportFinder()
+	sources := []DataSource{NewCSVSource(PresetLoadOptions(testDataDir)), NewDatabasesSource("extra", testMergeDatabases())}
+	if errs := finder.LoadSources(AirportTypeAll, sources...); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	airportIDs := make(map[uint64]bool)
+	for _, airport := range finder.airportDB.Airports {
+		if airportIDs[airport.ID] {
+			t.Errorf("airport ID %d is used twice", airport.ID)
+		}
+		airportIDs[airport.ID] = true
+	}
+	klax := finder.airportDB.FindByICAOCode("KLAX")
+	if klax.ID != 3632 {
+		t.Errorf("the earlier source was renumbered: %d", klax.ID)
+	}
+
+	var first *AirportData
+	for _, airport := range finder.airportDB.Airports {
+		if airport.Name == "First" {
+			first = airport
+		}
+	}
+	if first == nil || first.ID == 3632 {
+		t.Fatalf("XDUP was not renumbered: %+v", first)
+	}
+	// runways and frequencies follow the renumbered airport and are renumbered themselves
+	runways := finder.runwayDB.FindByAirportID(first.ID)
+	if len(runways) != 1 || runways[0].LowEndIdent != "18" || runways[0].ID == 240922 || runways[0].AirportID != first.ID {
+		t.Errorf("unexpected runways %+v", runways)
+	}
+	frequencies := finder.frequencyDB.FindByAirportID(first.ID)
+	if len(frequencies) != 1 || frequencies[0].Type != "CTAF" || frequencies[0].ID == 60768 {
+		t.Errorf("unexpected frequencies %+v", frequencies)
+	}
+	if len(finder.runwayDB.FindByAirportID(3632)) != 2 {
+		t.Error("the runways of KLAX changed")
+	}
+
+	navaidIDs := make(map[uint64]bool)
+	for _, navaid := range finder.navaidDB.Navaids {
+		if navaidIDs[navaid.ID] {
+			t.Errorf("navaid ID %d is used twice", navaid.ID)
+		}
+		navaidIDs[navaid.ID] = true
+	}
+}
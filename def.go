@@ -1,6 +1,7 @@
 package alphafoxtrot
 
 import (
+	"fmt"
 	"math"
 	"strconv"
 	"strings"
@@ -65,6 +66,10 @@ func NauticalMilesToMeters(nm float64) float64 {
 	return nm * 1852.0
 }
 
+func FeetToMeters(ft float64) float64 {
+	return ft * 0.3048
+}
+
 func MetersToKilometers(m float64) float64 {
 	return m * 0.001
 }
@@ -77,6 +82,41 @@ func MetersToNauticalMiles(m float64) float64 {
 	return m * 0.000539957
 }
 
+func MetersToFeet(m float64) float64 {
+	return m * 3.28084
+}
+
+// ParseDistance parses a non-negative distance with an optional unit suffix (m, km, nm, mi) and returns it in meters,
+// e.g. "25nm", "40 km", "1500" (meters)
+func ParseDistance(str string) (float64, error) {
+	str = strings.ToLower(strings.TrimSpace(str))
+	units := []struct {
+		suffix  string
+		convert func(float64) float64
+	}{
+		{"km", KilometersToMeters},
+		{"nm", NauticalMilesToMeters},
+		{"mi", MilesToMeters},
+		{"m", func(m float64) float64 { return m }},
+	}
+	convert := units[len(units)-1].convert
+	for _, unit := range units {
+		if strings.HasSuffix(str, unit.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, unit.suffix))
+			convert = unit.convert
+			break
+		}
+	}
+	value, err := ParseFloat(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid distance: %v", err)
+	}
+	if value < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("invalid distance: %s", str)
+	}
+	return convert(value), nil
+}
+
 func AirportTypeFromString(typ string) uint64 {
 	switch typ {
 	case AirportTypeClosedName:
@@ -95,6 +135,38 @@ func AirportTypeFromString(typ string) uint64 {
 	return AirportTypeUnknown
 }
 
+// AirportTypeFilterFromString parses a comma-separated list of airport types into a filter,
+// e.g. "active", "small,medium,large", "large_airport,heliport"
+func AirportTypeFilterFromString(str string) (uint64, error) {
+	var filter uint64
+	for _, name := range strings.Split(str, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "all":
+			filter |= AirportTypeAll
+		case "active":
+			filter |= AirportTypeActive
+		case "runways":
+			filter |= AirportTypeRunways
+		case "small":
+			filter |= AirportTypeSmall
+		case "medium":
+			filter |= AirportTypeMedium
+		case "large":
+			filter |= AirportTypeLarge
+		case "seaplane":
+			filter |= AirportTypeSeaplaneBase
+		default:
+			typ := AirportTypeFromString(name)
+			if typ == AirportTypeUnknown {
+				return 0, fmt.Errorf("unknown airport type: %s", name)
+			}
+			filter |= typ
+		}
+	}
+	return filter, nil
+}
+
 // see https://stackoverflow.com/questions/43167417/calculate-distance-between-two-points-in-leaflet
 // returns the distance between to coordinates in meters
 func Distance(fromLatitudeDeg, fromLongitudeDeg, toLatitudeDeg, toLongitudeDeg float64) float64 {
@@ -111,6 +183,30 @@ func Distance(fromLatitudeDeg, fromLongitudeDeg, toLatitudeDeg, toLongitudeDeg f
 	return c * EarthRadius
 }
 
+// returns the initial true bearing in degrees (0-360) of the great circle from one coordinate to another
+func Bearing(fromLatitudeDeg, fromLongitudeDeg, toLatitudeDeg, toLongitudeDeg float64) float64 {
+	lat1 := fromLatitudeDeg * DegToRad
+	lat2 := toLatitudeDeg * DegToRad
+	dtLon := (toLongitudeDeg - fromLongitudeDeg) * DegToRad
+
+	y := math.Sin(dtLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dtLon)
+	return math.Mod(math.Atan2(y, x)/DegToRad+360.0, 360.0)
+}
+
+// returns the coordinate reached when travelling the given distance in meters along the great circle with the given initial bearing
+func Destination(latitudeDeg, longitudeDeg, bearingDeg, distanceMeters float64) (float64, float64) {
+	lat1 := latitudeDeg * DegToRad
+	lon1 := longitudeDeg * DegToRad
+	brg := bearingDeg * DegToRad
+	dist := distanceMeters / EarthRadius
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(dist) + math.Cos(lat1)*math.Sin(dist)*math.Cos(brg))
+	lon2 := lon1 + math.Atan2(math.Sin(brg)*math.Sin(dist)*math.Cos(lat1), math.Cos(dist)-math.Sin(lat1)*math.Sin(lat2))
+	lon2 = math.Mod(lon2/DegToRad+540.0, 360.0) - 180.0
+	return lat2 / DegToRad, lon2
+}
+
 func ParseFloat(str string) (float64, error) {
 	value, err := strconv.ParseFloat(str, 64)
 	if err != nil {
@@ -0,0 +1,30 @@
+package alphafoxtrot
+
+import "testing"
+
+func TestParseDistance(t *testing.T) {
+	tests := []struct {
+		str     string
+		meters  float64
+		wantErr bool
+	}{
+		{"1500", 1500, false},
+		{"25nm", 46300, false},
+		{"40 km", 40000, false},
+		{"0mi", 0, false},
+		{"-5nm", 0, true},
+		{"-1", 0, true},
+		{"far", 0, true},
+		{"NaN", 0, true},
+	}
+	for _, test := range tests {
+		meters, err := ParseDistance(test.str)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseDistance(%q): got error %v", test.str, err)
+			continue
+		}
+		if !test.wantErr && meters != test.meters {
+			t.Errorf("ParseDistance(%q): got %f, want %f", test.str, meters, test.meters)
+		}
+	}
+}
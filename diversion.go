@@ -0,0 +1,160 @@
+package alphafoxtrot
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// RunwayProfile is the minimum runway an aircraft needs
+type RunwayProfile struct {
+	MinLengthFt int64
+	MinWidthFt  int64
+	PavedOnly   bool
+	LightedOnly bool
+}
+
+// Suits returns true if the runway is open and meets the profile
+func (p *RunwayProfile) Suits(runway *Runway) bool {
+	if runway.Closed || runway.LengthFt < p.MinLengthFt || runway.WidthFt < p.MinWidthFt {
+		return false
+	}
+	if p.PavedOnly && !IsPavedSurface(runway.Surface) {
+		return false
+	}
+	return !p.LightedOnly || runway.Lighted
+}
+
+// SuitableRunways returns the runways of the airport which meet the profile
+func (p *RunwayProfile) SuitableRunways(airport *Airport) []Runway {
+	runways := make([]Runway, 0)
+	for i := range airport.Runways {
+		if p.Suits(&airport.Runways[i]) {
+			runways = append(runways, airport.Runways[i])
+		}
+	}
+	return runways
+}
+
+type DiversionRequest struct {
+	LatitudeDeg       float64
+	LongitudeDeg      float64
+	GroundSpeedKt     float64
+	EnduranceMinutes  float64 // fuel on board
+	ReserveMinutes    float64 // fuel which must remain after landing
+	Runway            RunwayProfile
+	AirportTypeFilter uint64    // AirportTypeRunways if 0
+	MaxResults        int       // all if <= 0
+	Time              time.Time // for the ETA, now if zero
+	// optional weather: airports reporting a worse flight category are excluded, one of the FlightCategory constants
+	WorstFlightCategory string
+	RequireMETAR        bool
+	// Score ranks the diversions, lower is better; DefaultDiversionScore if nil
+	Score func(diversion *Diversion) float64
+}
+
+type Diversion struct {
+	Airport              *Airport
+	DistanceMeters       float64
+	BearingDeg           float64
+	TimeEnrouteMinutes   float64
+	ETA                  time.Time
+	FuelRemainingMinutes float64
+	Runways              []Runway
+	LongestRunwayFt      int64
+	FlightCategory       string
+	Score                float64
+}
+
+// diversionWeatherPenalties are the minutes added by DefaultDiversionScore for the reported flight category
+var diversionWeatherPenalties = map[string]float64{
+	FlightCategoryUnknown: 10,
+	FlightCategoryVFR:     0,
+	FlightCategoryMVFR:    10,
+	FlightCategoryIFR:     30,
+	FlightCategoryLIFR:    60,
+}
+
+// DefaultDiversionScore is the time enroute in minutes plus a penalty for the weather and for airports without a tower
+func DefaultDiversionScore(diversion *Diversion) float64 {
+	score := diversion.TimeEnrouteMinutes + diversionWeatherPenalties[diversion.FlightCategory]
+	towered := false
+	for _, frequency := range diversion.Airport.Frequencies {
+		if frequency.Type == "TWR" {
+			towered = true
+			break
+		}
+	}
+	if !towered {
+		score += 5
+	}
+	return score
+}
+
+// FindDiversions returns the airports reachable with the usable fuel which have a runway meeting the profile, ranked by score.
+// It fails if the worst flight category is not one of the FlightCategory constants.
+func (af *AirportFinder) FindDiversions(request *DiversionRequest) ([]*Diversion, error) {
+	if _, ok := flightCategoryRanks[request.WorstFlightCategory]; !ok {
+		return nil, fmt.Errorf("diversion: unknown flight category %q", request.WorstFlightCategory)
+	}
+	diversions := make([]*Diversion, 0)
+	usableMinutes := request.EnduranceMinutes - request.ReserveMinutes
+	if request.GroundSpeedKt <= 0 || usableMinutes <= 0 {
+		return diversions, nil
+	}
+	airportTypeFilter := request.AirportTypeFilter
+	if airportTypeFilter == 0 {
+		airportTypeFilter = AirportTypeRunways
+	}
+	score := request.Score
+	if score == nil {
+		score = DefaultDiversionScore
+	}
+	now := request.Time
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+
+	rangeMeters := NauticalMilesToMeters(request.GroundSpeedKt * usableMinutes / 60)
+	airports := af.FindNearestAirports(request.LatitudeDeg, request.LongitudeDeg, rangeMeters, -1, airportTypeFilter)
+	for _, airport := range airports {
+		runways := request.Runway.SuitableRunways(airport)
+		if len(runways) == 0 {
+			continue
+		}
+		if request.RequireMETAR && airport.METAR == nil {
+			continue
+		}
+		if request.WorstFlightCategory != "" && airport.METAR != nil &&
+			flightCategoryRanks[airport.FlightCategory] > flightCategoryRanks[request.WorstFlightCategory] {
+			continue
+		}
+		distance := Distance(request.LatitudeDeg, request.LongitudeDeg, airport.LatitudeDeg, airport.LongitudeDeg)
+		minutes := MetersToNauticalMiles(distance) / request.GroundSpeedKt * 60
+		diversion := &Diversion{
+			Airport:              airport,
+			DistanceMeters:       distance,
+			BearingDeg:           Bearing(request.LatitudeDeg, request.LongitudeDeg, airport.LatitudeDeg, airport.LongitudeDeg),
+			TimeEnrouteMinutes:   minutes,
+			ETA:                  now.Add(time.Duration(minutes * float64(time.Minute))),
+			FuelRemainingMinutes: request.EnduranceMinutes - minutes,
+			Runways:              runways,
+			FlightCategory:       airport.FlightCategory,
+		}
+		for _, runway := range runways {
+			if runway.LengthFt > diversion.LongestRunwayFt {
+				diversion.LongestRunwayFt = runway.LengthFt
+			}
+		}
+		diversion.Score = score(diversion)
+		diversions = append(diversions, diversion)
+	}
+
+	sort.SliceStable(diversions, func(i, j int) bool {
+		return diversions[i].Score < diversions[j].Score
+	})
+	if request.MaxResults > 0 && len(diversions) > request.MaxResults {
+		diversions = diversions[:request.MaxResults]
+	}
+	return diversions, nil
+}
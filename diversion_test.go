@@ -0,0 +1,74 @@
+package alphafoxtrot
+
+import (
+	"testing"
+)
+
+func TestFindDiversions(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	weatherDB := NewWeatherDB()
+	for _, report := range []string{
+		"KLAX 011753Z 25010KT 10SM FEW030 20/12 A2992",
+		"KLGB 011753Z 00000KT 2SM BR OVC006 15/14 A2990",
+	} {
+		metar, err := ParseMETAR(report, testWeatherTime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		weatherDB.AddMETAR(metar)
+	}
+	finder.SetWeatherDB(weatherDB)
+
+	request := &DiversionRequest{
+		LatitudeDeg: 33.9, LongitudeDeg: -118.3, GroundSpeedKt: 120,
+		EnduranceMinutes: 90, ReserveMinutes: 30,
+		Time: testWeatherTime,
+	}
+	tests := []struct {
+		name                string
+		runway              RunwayProfile
+		worstFlightCategory string
+		maxResults          int
+		want                []string
+	}{
+		{"all", RunwayProfile{}, "", 0, []string{"KLAX", "KSMO", "KLGB"}},
+		{"max results", RunwayProfile{}, "", 1, []string{"KLAX"}},
+		{"negative max results", RunwayProfile{}, "", -1, []string{"KLAX", "KSMO", "KLGB"}},
+		{"runway profile", RunwayProfile{MinLengthFt: 4000}, "", 0, []string{"KLAX", "KLGB"}},
+		{"weather", RunwayProfile{MinLengthFt: 4000}, FlightCategoryMVFR, 0, []string{"KLAX"}},
+		{"worst weather", RunwayProfile{MinLengthFt: 4000}, FlightCategoryLIFR, 0, []string{"KLAX", "KLGB"}},
+	}
+	for _, test := range tests {
+		request.Runway = test.runway
+		request.WorstFlightCategory = test.worstFlightCategory
+		request.MaxResults = test.maxResults
+		diversions, err := finder.FindDiversions(request)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		got := make([]string, 0, len(diversions))
+		for _, diversion := range diversions {
+			got = append(got, diversion.Airport.ICAOCode)
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestFindDiversionsUnknownFlightCategory(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	for _, category := range []string{"vfr", "VMC", "SVFR"} {
+		request := &DiversionRequest{GroundSpeedKt: 120, EnduranceMinutes: 90, WorstFlightCategory: category}
+		if diversions, err := finder.FindDiversions(request); err == nil {
+			t.Errorf("%s: expected an error, got %v", category, diversions)
+		}
+	}
+}
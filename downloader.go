@@ -1,37 +1,269 @@
 package alphafoxtrot
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
 	"path"
+	"path/filepath"
 	"time"
-
-	"github.com/grumpypixel/go-webget"
 )
 
 // This isn't the code you're looking for.
 // This was created to power the example code.
 // And for fun of course.
 
+const DefaultDownloadTimeout = time.Second * 60
+
+// downloadExtension is appended to the file while it is being downloaded
+const downloadExtension = ".download"
+
+type DownloadOptions struct {
+	Progress      DownloadProgress // optional, defaults to NoProgress
+	Timeout       time.Duration    // optional, defaults to DefaultDownloadTimeout
+	SkipUnchanged bool             // skip files whose local copy matches the remote size and modification time
+	ReferenceDir  string           // optional, directory holding the local copies to compare against, defaults to the target directory
+	Context       context.Context  // optional, cancels the requests when it is done
+	BaseURL       string           // optional, defaults to OurAirportsBaseURL, e.g. a mirror
+}
+
+func DefaultDownloadOptions() *DownloadOptions {
+	return &DownloadOptions{
+		Progress: TerminalProgress{Writer: os.Stdout},
+		Timeout:  DefaultDownloadTimeout,
+	}
+}
+
 // Download csv files from OurAirports.com
-func DownloadDatabase(targetDir string) {
-	files := make([]string, 0)
-	for _, filename := range OurAirportsFiles {
-		files = append(files, OurAirportsBaseURL+filename)
-	}
-	for _, url := range files {
-		options := webget.Options{
-			ProgressHandler: MyProgress{},
-			Timeout:         time.Second * 60,
-			CreateTargetDir: true,
+func DownloadDatabase(targetDir string) []error {
+	_, errors := DownloadDatabaseWithOptions(targetDir, DefaultDownloadOptions())
+	return errors
+}
+
+// Download csv files from OurAirports.com, returns the keys (see OurAirportsFiles) of the files that were downloaded
+func DownloadDatabaseWithOptions(targetDir string, options *DownloadOptions) ([]string, []error) {
+	if options == nil {
+		options = &DownloadOptions{}
+	}
+	progress := options.Progress
+	if progress == nil {
+		progress = NoProgress{}
+	}
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = DefaultDownloadTimeout
+	}
+	referenceDir := options.ReferenceDir
+	if referenceDir == "" {
+		referenceDir = targetDir
+	}
+	ctx := options.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if channelProgress, ok := progress.(*ChannelProgress); ok && channelProgress.Context == nil {
+		// a copy, so the events stop blocking once the download is canceled
+		progress = &ChannelProgress{Events: channelProgress.Events, Context: ctx}
+	}
+	baseURL := options.BaseURL
+	if baseURL == "" {
+		baseURL = OurAirportsBaseURL
+	}
+
+	downloaded := make([]string, 0, len(OurAirportsFiles))
+	errors := make([]error, 0)
+	for key, filename := range OurAirportsFiles {
+		url := baseURL + filename
+		var lastModified time.Time
+		if options.SkipUnchanged {
+			// servers which do not answer HEAD are treated like files without a modification time
+			var contentLength int64
+			lastModified, contentLength = headFile(ctx, url, timeout)
+			unchanged, err := isUnchanged(filepath.Join(referenceDir, filename), lastModified, contentLength)
+			if err != nil {
+				progress.FileFailed(url, err)
+				errors = append(errors, err)
+				continue
+			}
+			if unchanged {
+				progress.FileSkipped(url, filepath.Join(referenceDir, filename))
+				continue
+			}
+		}
+		if err := downloadFile(ctx, url, targetDir, filename, lastModified, timeout, progress); err != nil {
+			progress.FileFailed(url, err)
+			errors = append(errors, err)
+			continue
 		}
-		webget.DownloadToFile(url, targetDir, "", &options)
+		downloaded = append(downloaded, key)
+	}
+	return downloaded, errors
+}
+
+// downloadFile downloads the file and sets its modification time to lastModified unless it is zero
+func downloadFile(ctx context.Context, url, targetDir, filename string, lastModified time.Time, timeout time.Duration, progress DownloadProgress) error {
+	if err := os.MkdirAll(targetDir, os.ModePerm); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
 	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("cannot download %s: %s", url, resp.Status)
+	}
+
+	progress.FileStarted(url)
+	targetFile := filepath.Join(targetDir, filename)
+	tempFile := targetFile + downloadExtension
+	file, err := os.Create(tempFile)
+	if err != nil {
+		return err
+	}
+	writer := &progressWriter{sourceURL: url, contentLength: resp.ContentLength, progress: progress}
+	_, err = io.Copy(file, io.TeeReader(resp.Body, writer))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tempFile)
+		return err
+	}
+	if err := os.Rename(tempFile, targetFile); err != nil {
+		return err
+	}
+	if !lastModified.IsZero() {
+		// keep the remote modification time so that unchanged files can be detected later on
+		if err := os.Chtimes(targetFile, lastModified, lastModified); err != nil {
+			return err
+		}
+	}
+	progress.FileFinished(url, targetFile)
+	return nil
+}
+
+// isUnchanged compares the local file with the remote modification time and size, it is changed if either is unknown
+func isUnchanged(localFile string, lastModified time.Time, contentLength int64) (bool, error) {
+	if lastModified.IsZero() || contentLength < 0 {
+		return false, nil
+	}
+	info, err := os.Stat(localFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return info.Size() == contentLength && info.ModTime().Equal(lastModified), nil
+}
+
+// headFile returns the modification time and size of the remote file, zero and -1 if the server does not tell
+func headFile(ctx context.Context, url string, timeout time.Duration) (time.Time, int64) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return time.Time{}, -1
+	}
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return time.Time{}, -1
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, -1
+	}
+
+	var lastModified time.Time
+	if header := resp.Header.Get("Last-Modified"); header != "" {
+		if t, err := http.ParseTime(header); err == nil {
+			lastModified = t
+		}
+	}
+	return lastModified, resp.ContentLength
+}
+
+// DownloadProgress receives the events of the download subsystem
+type DownloadProgress interface {
+	FileStarted(sourceURL string)
+	BytesTransferred(sourceURL string, bytesRead, contentLength int64)
+	FileFinished(sourceURL, targetFile string)
+	FileSkipped(sourceURL, localFile string)
+	FileFailed(sourceURL string, err error)
 }
 
+// progressWriter reports the bytes written to it as the progress of the download
+type progressWriter struct {
+	sourceURL     string
+	bytesRead     int64
+	contentLength int64
+	progress      DownloadProgress
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.bytesRead += int64(len(p))
+	w.progress.BytesTransferred(w.sourceURL, w.bytesRead, w.contentLength)
+	return len(p), nil
+}
+
+// NoProgress discards all events
+type NoProgress struct{}
+
+func (p NoProgress) FileStarted(sourceURL string) {}
+
+func (p NoProgress) BytesTransferred(sourceURL string, bytesRead, contentLength int64) {}
+
+func (p NoProgress) FileFinished(sourceURL, targetFile string) {}
+
+func (p NoProgress) FileSkipped(sourceURL, localFile string) {}
+
+func (p NoProgress) FileFailed(sourceURL string, err error) {}
+
+// TerminalProgress writes carriage-return progress lines to the given writer
+type TerminalProgress struct {
+	Writer io.Writer
+}
+
+func (p TerminalProgress) FileStarted(sourceURL string) {}
+
+func (p TerminalProgress) BytesTransferred(sourceURL string, bytesRead, contentLength int64) {
+	if contentLength > 0 {
+		percentage := float64(bytesRead) / float64(contentLength) * 100.0
+		fmt.Fprintf(p.Writer, "\rDownloading %s: %v bytes [%.2f%%]", path.Base(sourceURL), bytesRead, percentage)
+	} else {
+		fmt.Fprintf(p.Writer, "\rDownloading %s: %v bytes", path.Base(sourceURL), bytesRead)
+	}
+}
+
+func (p TerminalProgress) FileFinished(sourceURL, targetFile string) {
+	fmt.Fprintln(p.Writer)
+}
+
+func (p TerminalProgress) FileSkipped(sourceURL, localFile string) {
+	fmt.Fprintf(p.Writer, "Skipping %s: unchanged\n", path.Base(sourceURL))
+}
+
+func (p TerminalProgress) FileFailed(sourceURL string, err error) {
+	fmt.Fprintf(p.Writer, "\nDownloading %s failed: %v\n", path.Base(sourceURL), err)
+}
+
+// MyProgress prints the progress of downloads with github.com/grumpypixel/go-webget to stdout.
+//
+// Deprecated: use TerminalProgress with DownloadDatabaseWithOptions.
 type MyProgress struct{}
 
 func (p MyProgress) Start(sourceURL string) {
-	// fmt.Println()
 }
 
 func (p MyProgress) Update(sourceURL string, percentage float64, bytesRead, contentLength int64) {
@@ -41,3 +273,72 @@ func (p MyProgress) Update(sourceURL string, percentage float64, bytesRead, cont
 func (p MyProgress) Done(sourceURL string) {
 	fmt.Println()
 }
+
+type DownloadEventType int
+
+const (
+	DownloadEventStarted DownloadEventType = iota
+	DownloadEventProgress
+	DownloadEventFinished
+	DownloadEventSkipped
+	DownloadEventFailed
+)
+
+type DownloadEvent struct {
+	Type          DownloadEventType
+	SourceURL     string
+	File          string // target file when finished, local file when skipped
+	BytesRead     int64
+	ContentLength int64
+	Err           error
+}
+
+// ChannelProgress sends every event to the Events channel so UIs can render the progress themselves.
+// Sending blocks, so the channel has to be drained while downloading, until the context is done.
+// DownloadDatabaseWithOptions uses DownloadOptions.Context if Context is nil.
+type ChannelProgress struct {
+	Events  chan DownloadEvent
+	Context context.Context // optional, events are dropped once it is done
+}
+
+func NewChannelProgress(bufferSize int) *ChannelProgress {
+	return &ChannelProgress{
+		Events: make(chan DownloadEvent, bufferSize),
+	}
+}
+
+func (p *ChannelProgress) FileStarted(sourceURL string) {
+	p.send(DownloadEvent{Type: DownloadEventStarted, SourceURL: sourceURL})
+}
+
+func (p *ChannelProgress) BytesTransferred(sourceURL string, bytesRead, contentLength int64) {
+	p.send(DownloadEvent{Type: DownloadEventProgress, SourceURL: sourceURL, BytesRead: bytesRead, ContentLength: contentLength})
+}
+
+func (p *ChannelProgress) FileFinished(sourceURL, targetFile string) {
+	p.send(DownloadEvent{Type: DownloadEventFinished, SourceURL: sourceURL, File: targetFile})
+}
+
+func (p *ChannelProgress) FileSkipped(sourceURL, localFile string) {
+	p.send(DownloadEvent{Type: DownloadEventSkipped, SourceURL: sourceURL, File: localFile})
+}
+
+func (p *ChannelProgress) FileFailed(sourceURL string, err error) {
+	p.send(DownloadEvent{Type: DownloadEventFailed, SourceURL: sourceURL, Err: err})
+}
+
+func (p *ChannelProgress) send(event DownloadEvent) {
+	if p.Context == nil {
+		p.Events <- event
+		return
+	}
+	select {
+	case p.Events <- event:
+	case <-p.Context.Done():
+	}
+}
+
+// Close closes the Events channel, call it after the download has returned
+func (p *ChannelProgress) Close() {
+	close(p.Events)
+}
@@ -0,0 +1,192 @@
+package alphafoxtrot
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testDownloadServer serves the OurAirports files with a modification time and counts the GET requests
+type testDownloadServer struct {
+	mutex        sync.Mutex
+	files        map[string][]byte
+	lastModified time.Time
+	gets         map[string]int
+	failing      string
+}
+
+func newTestDownloadServer(t *testing.T) (*testDownloadServer, string) {
+	t.Helper()
+	s := &testDownloadServer{
+		files:        make(map[string][]byte),
+		lastModified: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		gets:         make(map[string]int),
+	}
+	for _, filename := range OurAirportsFiles {
+		// large enough for several progress events
+		s.files[filename] = bytes.Repeat([]byte(filename+"\n"), 10000)
+	}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return s, server.URL + "/"
+}
+
+func (s *testDownloadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	data, ok := s.files[name]
+	if r.Method == http.MethodGet {
+		s.gets[name]++
+	}
+	failing := name == s.failing
+	s.mutex.Unlock()
+	if !ok || failing {
+		http.Error(w, "not available", http.StatusInternalServerError)
+		return
+	}
+	http.ServeContent(w, r, name, s.lastModified, bytes.NewReader(data))
+}
+
+func (s *testDownloadServer) getCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	count := 0
+	for _, n := range s.gets {
+		count += n
+	}
+	return count
+}
+
+// downloadWithEvents downloads and collects the events of a ChannelProgress
+func downloadWithEvents(t *testing.T, targetDir string, options *DownloadOptions) ([]string, []error, []DownloadEvent) {
+	t.Helper()
+	progress := NewChannelProgress(0)
+	options.Progress = progress
+	events := make([]DownloadEvent, 0)
+	done := make(chan bool)
+	go func() {
+		for event := range progress.Events {
+			events = append(events, event)
+		}
+		done <- true
+	}()
+	downloaded, errs := DownloadDatabaseWithOptions(targetDir, options)
+	progress.Close()
+	<-done
+	sort.Strings(downloaded)
+	return downloaded, errs, events
+}
+
+func countEvents(events []DownloadEvent, eventType DownloadEventType) int {
+	count := 0
+	for _, event := range events {
+		if event.Type == eventType {
+			count++
+		}
+	}
+	return count
+}
+
+func TestDownloadDatabase(t *testing.T) {
+	server, baseURL := newTestDownloadServer(t)
+	targetDir := t.TempDir()
+	options := &DownloadOptions{BaseURL: baseURL, SkipUnchanged: true}
+
+	downloaded, errs, events := downloadWithEvents(t, targetDir, options)
+	if len(errs) > 0 || len(downloaded) != len(OurAirportsFiles) {
+		t.Fatalf("downloaded %v: %v", downloaded, errs)
+	}
+	for _, filename := range OurAirportsFiles {
+		targetFile := filepath.Join(targetDir, filename)
+		data, err := os.ReadFile(targetFile)
+		if err != nil || !bytes.Equal(data, server.files[filename]) {
+			t.Errorf("%s: unexpected content (%v)", filename, err)
+		}
+		if info, err := os.Stat(targetFile); err != nil || !info.ModTime().Equal(server.lastModified) {
+			t.Errorf("%s: modification time not set (%v)", filename, err)
+		}
+		if _, err := os.Stat(targetFile + downloadExtension); !os.IsNotExist(err) {
+			t.Errorf("%s: temp file left behind", filename)
+		}
+	}
+	lastProgress := make(map[string]DownloadEvent)
+	for _, event := range events {
+		switch event.Type {
+		case DownloadEventProgress:
+			if previous, ok := lastProgress[event.SourceURL]; ok && event.BytesRead <= previous.BytesRead {
+				t.Errorf("%s: progress went from %d to %d bytes", event.SourceURL, previous.BytesRead, event.BytesRead)
+			}
+			lastProgress[event.SourceURL] = event
+		case DownloadEventFinished:
+			progress := lastProgress[event.SourceURL]
+			if progress.BytesRead != progress.ContentLength || progress.BytesRead == 0 {
+				t.Errorf("%s: finished after %d of %d bytes", event.SourceURL, progress.BytesRead, progress.ContentLength)
+			}
+			if filepath.Dir(event.File) != targetDir {
+				t.Errorf("%s: finished with file %s", event.SourceURL, event.File)
+			}
+		}
+	}
+	if countEvents(events, DownloadEventStarted) != len(OurAirportsFiles) || countEvents(events, DownloadEventFinished) != len(OurAirportsFiles) ||
+		len(lastProgress) != len(OurAirportsFiles) {
+		t.Errorf("got %d events", len(events))
+	}
+
+	// nothing changed, nothing is downloaded
+	gets := server.getCount()
+	downloaded, errs, events = downloadWithEvents(t, targetDir, options)
+	if len(errs) > 0 || len(downloaded) != 0 || countEvents(events, DownloadEventSkipped) != len(OurAirportsFiles) || server.getCount() != gets {
+		t.Errorf("unchanged: downloaded %v with %d skipped events: %v", downloaded, countEvents(events, DownloadEventSkipped), errs)
+	}
+
+	// a changed size is downloaded, a failing file keeps the previous copy
+	airportsFile := OurAirportsFiles[AirportsFileKey]
+	runwaysFile := OurAirportsFiles[RunwaysFileKey]
+	server.mutex.Lock()
+	server.files[airportsFile] = append(server.files[airportsFile], "changed\n"...)
+	server.files[runwaysFile] = append(server.files[runwaysFile], "changed\n"...)
+	server.failing = runwaysFile
+	server.mutex.Unlock()
+	downloaded, errs, events = downloadWithEvents(t, targetDir, options)
+	if len(downloaded) != 1 || downloaded[0] != AirportsFileKey || len(errs) != 1 || countEvents(events, DownloadEventFailed) != 1 {
+		t.Errorf("changed: downloaded %v: %v", downloaded, errs)
+	}
+	if data, _ := os.ReadFile(filepath.Join(targetDir, runwaysFile)); bytes.HasSuffix(data, []byte("changed\n")) {
+		t.Error("failed download replaced the previous copy")
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, runwaysFile+downloadExtension)); !os.IsNotExist(err) {
+		t.Error("failed download left a temp file behind")
+	}
+}
+
+func TestChannelProgressCanceled(t *testing.T) {
+	_, baseURL := newTestDownloadServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// nobody drains the channel, the canceled context must not block the failure events
+	progress := NewChannelProgress(0)
+	result := make(chan []error)
+	go func() {
+		_, errs := DownloadDatabaseWithOptions(t.TempDir(), &DownloadOptions{BaseURL: baseURL, Context: ctx, Progress: progress})
+		result <- errs
+	}()
+	select {
+	case errs := <-result:
+		if len(errs) != len(OurAirportsFiles) {
+			t.Errorf("got %d errors, want %d", len(errs), len(OurAirportsFiles))
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("download blocked on the progress channel")
+	}
+	if progress.Context != nil {
+		t.Error("the progress of the caller was modified")
+	}
+}
@@ -114,6 +114,8 @@ func validateData(dataDir string) {
 	}
 	if downloadFiles {
 		fmt.Println("Downloading CSV files from OurAirports.com...")
-		alphafoxtrot.DownloadDatabase(dataDir)
+		if errs := alphafoxtrot.DownloadDatabase(dataDir); len(errs) > 0 {
+			log.Println("download errors:", errs)
+		}
 	}
 }
@@ -27,6 +27,7 @@ type FrequencyData struct {
 	Type         string
 	Description  string
 	FrequencyMHZ float64
+	Origin       string
 }
 
 type FrequencyDB struct {
@@ -49,8 +50,15 @@ func (db *FrequencyDB) Parse(file string, skipFirstLine bool) error {
 		return err
 	}
 	defer f.Close()
+	return ReadFrequencies(f, skipFirstLine, func(frequency *FrequencyData) error {
+		db.Frequencies[frequency.AirportID] = append(db.Frequencies[frequency.AirportID], frequency)
+		return nil
+	})
+}
 
-	reader := csv.NewReader(f)
+// ReadFrequencies calls fn for every record of the csv and stops at the first error returned by fn
+func ReadFrequencies(r io.Reader, skipFirstLine bool, fn func(*FrequencyData) error) error {
+	reader := csv.NewReader(r)
 	line := -1
 
 	for {
@@ -97,8 +105,11 @@ func (db *FrequencyDB) Parse(file string, skipFirstLine bool) error {
 			Type:         typ,
 			Description:  desc,
 			FrequencyMHZ: mhz,
+			Origin:       OriginBase,
+		}
+		if err := fn(frequency); err != nil {
+			return err
 		}
-		db.Frequencies[airportRef] = append(db.Frequencies[airportRef], frequency)
 	}
 }
 
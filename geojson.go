@@ -0,0 +1,210 @@
+package alphafoxtrot
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// see https://datatracker.ietf.org/doc/html/rfc7946
+
+const (
+	GeoJSONFeatureType           = "Feature"
+	GeoJSONFeatureCollectionType = "FeatureCollection"
+	GeoJSONPointType             = "Point"
+	GeoJSONLineStringType        = "LineString"
+	GeoJSONPolygonType           = "Polygon"
+	GeoJSONMultiPolygonType      = "MultiPolygon"
+)
+
+type GeoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   *GeoJSONGeometry       `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type GeoJSONFeatureCollection struct {
+	Type     string            `json:"type"`
+	Features []*GeoJSONFeature `json:"features"`
+}
+
+func NewGeoJSONFeatureCollection() *GeoJSONFeatureCollection {
+	return &GeoJSONFeatureCollection{
+		Type:     GeoJSONFeatureCollectionType,
+		Features: make([]*GeoJSONFeature, 0),
+	}
+}
+
+func (fc *GeoJSONFeatureCollection) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(fc)
+}
+
+// AirportFeature returns the airport as a Point feature with flat snake_case properties.
+// Runways, frequencies and navaids are left out of the properties, use RunwayFeature and NavaidFeature for them.
+func AirportFeature(airport *Airport) *GeoJSONFeature {
+	properties := map[string]interface{}{
+		"kind":              RecordKindAirport,
+		"id":                airport.ID,
+		"icao_code":         airport.ICAOCode,
+		"type":              airport.Type,
+		"name":              airport.Name,
+		"latitude_deg":      airport.LatitudeDeg,
+		"longitude_deg":     airport.LongitudeDeg,
+		"elevation_ft":      airport.ElevationFt,
+		"continent":         airport.Continent,
+		"municipality":      airport.Municipality,
+		"scheduled_service": airport.ScheduledService,
+		"gps_code":          airport.GPSCode,
+		"iata_code":         airport.IATACode,
+		"local_code":        airport.LocalCode,
+		"home_link":         airport.HomeLink,
+		"wikipedia_link":    airport.WikipediaLink,
+		"keywords":          airport.Keywords,
+		"iso_region":        airport.Region.ISOCode,
+		"region_name":       airport.Region.Name,
+		"iso_country":       airport.Country.ISOCode,
+		"country_name":      airport.Country.Name,
+		"timezone":          airport.TimeZone,
+		"utc_offset_hours":  airport.UTCOffsetHours,
+		"dst":               airport.DST,
+		"origin":            airport.Origin,
+	}
+	if airport.METAR != nil {
+		properties["metar"] = airport.METAR.Raw
+		properties["flight_category"] = airport.FlightCategory
+	}
+	if airport.TAF != nil {
+		properties["taf"] = airport.TAF.Raw
+	}
+	return &GeoJSONFeature{
+		Type:       GeoJSONFeatureType,
+		Geometry:   &GeoJSONGeometry{GeoJSONPointType, geoJSONPosition(airport.LatitudeDeg, airport.LongitudeDeg)},
+		Properties: properties,
+	}
+}
+
+// RunwayFeature returns the runway as a Polygon if its width is known and as a LineString otherwise.
+// The geometry is null if the coordinates of the runway ends are unknown.
+func RunwayFeature(runway *Runway, airportICAOCode string) *GeoJSONFeature {
+	properties := map[string]interface{}{
+		"kind":                      RecordKindRunway,
+		"airport_icao_code":         airportICAOCode,
+		"length_ft":                 runway.LengthFt,
+		"width_ft":                  runway.WidthFt,
+		"surface":                   runway.Surface,
+		"lighted":                   runway.Lighted,
+		"closed":                    runway.Closed,
+		"le_ident":                  runway.LowEndIdent,
+		"le_latitude_deg":           runway.LowEndLatitudeDeg,
+		"le_longitude_deg":          runway.LowEndLongitudeDeg,
+		"le_elevation_ft":           runway.LowEndElevationFt,
+		"le_heading_degT":           runway.LowEndHeadingDegT,
+		"le_displaced_threshold_ft": runway.LowEndDisplacedThresholdFt,
+		"he_ident":                  runway.HighEndIdent,
+		"he_latitude_deg":           runway.HighEndLatitudeDeg,
+		"he_longitude_deg":          runway.HighEndLongitudeDeg,
+		"he_elevation_ft":           runway.HighEndElevationFt,
+		"he_heading_degT":           runway.HighEndHeadingDegT,
+		"he_displaced_threshold_ft": runway.HighEndDisplacedThresholdFt,
+		"origin":                    runway.Origin,
+	}
+	return &GeoJSONFeature{
+		Type:       GeoJSONFeatureType,
+		Geometry:   runwayGeometry(runway),
+		Properties: properties,
+	}
+}
+
+func NavaidFeature(navaid *Navaid) *GeoJSONFeature {
+	properties := map[string]interface{}{
+		"kind":                   RecordKindNavaid,
+		"ident":                  navaid.Ident,
+		"name":                   navaid.Name,
+		"type":                   navaid.Type,
+		"frequency_khz":          navaid.FrequencyKHZ,
+		"latitude_deg":           navaid.LatitudeDeg,
+		"longitude_deg":          navaid.LongitudeDeg,
+		"elevation_ft":           navaid.ElevationFt,
+		"iso_country":            navaid.ISOCountry,
+		"dme_frequency_khz":      navaid.DMEFrequencyKHZ,
+		"dme_channel":            navaid.DMEChannel,
+		"dme_latitude_deg":       navaid.DMELatitudeDeg,
+		"dme_longitude_deg":      navaid.DMELongitudeDeg,
+		"dme_elevation_ft":       navaid.DMEElevationFt,
+		"slaved_variation_deg":   navaid.SlavedVariationDeg,
+		"magnetic_variation_deg": navaid.MagneticVariationDeg,
+		"usage_type":             navaid.UsageType,
+		"power":                  navaid.Power,
+		"associated_airport":     navaid.AssociatedAirport,
+		"origin":                 navaid.Origin,
+	}
+	return &GeoJSONFeature{
+		Type:       GeoJSONFeatureType,
+		Geometry:   &GeoJSONGeometry{GeoJSONPointType, geoJSONPosition(navaid.LatitudeDeg, navaid.LongitudeDeg)},
+		Properties: properties,
+	}
+}
+
+// AirportsFeatureCollection returns the airports as a FeatureCollection, optionally with their runways and associated navaids
+func AirportsFeatureCollection(airports []*Airport, includeRunways, includeNavaids bool) *GeoJSONFeatureCollection {
+	fc := NewGeoJSONFeatureCollection()
+	for _, airport := range airports {
+		fc.Features = append(fc.Features, AirportFeature(airport))
+		if includeRunways {
+			for i := range airport.Runways {
+				fc.Features = append(fc.Features, RunwayFeature(&airport.Runways[i], airport.ICAOCode))
+			}
+		}
+		if includeNavaids {
+			for i := range airport.Navaids {
+				fc.Features = append(fc.Features, NavaidFeature(&airport.Navaids[i]))
+			}
+		}
+	}
+	return fc
+}
+
+func NavaidsFeatureCollection(navaids []*Navaid) *GeoJSONFeatureCollection {
+	fc := NewGeoJSONFeatureCollection()
+	for _, navaid := range navaids {
+		fc.Features = append(fc.Features, NavaidFeature(navaid))
+	}
+	return fc
+}
+
+func runwayGeometry(runway *Runway) *GeoJSONGeometry {
+	if (runway.LowEndLatitudeDeg == 0 && runway.LowEndLongitudeDeg == 0) || (runway.HighEndLatitudeDeg == 0 && runway.HighEndLongitudeDeg == 0) {
+		return nil
+	}
+	if runway.WidthFt <= 0 {
+		return &GeoJSONGeometry{GeoJSONLineStringType, [][]float64{
+			geoJSONPosition(runway.LowEndLatitudeDeg, runway.LowEndLongitudeDeg),
+			geoJSONPosition(runway.HighEndLatitudeDeg, runway.HighEndLongitudeDeg),
+		}}
+	}
+
+	halfWidth := FeetToMeters(float64(runway.WidthFt)) * 0.5
+	bearing := Bearing(runway.LowEndLatitudeDeg, runway.LowEndLongitudeDeg, runway.HighEndLatitudeDeg, runway.HighEndLongitudeDeg)
+	corner := func(latitudeDeg, longitudeDeg, offsetDeg float64) []float64 {
+		lat, lon := Destination(latitudeDeg, longitudeDeg, bearing+offsetDeg, halfWidth)
+		return geoJSONPosition(lat, lon)
+	}
+	// counterclockwise as recommended by RFC 7946
+	lowRight := corner(runway.LowEndLatitudeDeg, runway.LowEndLongitudeDeg, 90)
+	ring := [][]float64{
+		lowRight,
+		corner(runway.HighEndLatitudeDeg, runway.HighEndLongitudeDeg, 90),
+		corner(runway.HighEndLatitudeDeg, runway.HighEndLongitudeDeg, -90),
+		corner(runway.LowEndLatitudeDeg, runway.LowEndLongitudeDeg, -90),
+		lowRight,
+	}
+	return &GeoJSONGeometry{GeoJSONPolygonType, [][][]float64{ring}}
+}
+
+func geoJSONPosition(latitudeDeg, longitudeDeg float64) []float64 {
+	return []float64{longitudeDeg, latitudeDeg}
+}
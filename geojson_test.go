@@ -0,0 +1,135 @@
+package alphafoxtrot
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"testing"
+)
+
+var geoJSONPropertyPattern = regexp.MustCompile(`^[a-z]+(_[a-z]+)*(_degT)?$`)
+
+func checkGeoJSONProperties(t *testing.T, feature *GeoJSONFeature, wantKind string, wantKeys ...string) {
+	t.Helper()
+	if feature.Properties["kind"] != wantKind {
+		t.Errorf("got kind %v, want %s", feature.Properties["kind"], wantKind)
+	}
+	for key, value := range feature.Properties {
+		if !geoJSONPropertyPattern.MatchString(key) {
+			t.Errorf("%s: property %q is not snake_case", wantKind, key)
+		}
+		switch value.(type) {
+		case nil, map[string]interface{}, []interface{}:
+			t.Errorf("%s: property %q is not flat: %v", wantKind, key, value)
+		}
+	}
+	for _, key := range wantKeys {
+		if _, ok := feature.Properties[key]; !ok {
+			t.Errorf("%s: missing property %q", wantKind, key)
+		}
+	}
+}
+
+func TestAirportFeature(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	airport := finder.FindAirportByICAOCode("KLAX")
+	feature := AirportFeature(airport)
+	checkGeoJSONProperties(t, feature, RecordKindAirport, "icao_code", "name", "iso_region", "iso_country", "timezone", "origin")
+	for _, key := range []string{"metar", "flight_category", "taf", "runways", "frequencies", "navaids"} {
+		if _, ok := feature.Properties[key]; ok {
+			t.Errorf("unexpected property %q", key)
+		}
+	}
+	if feature.Properties["icao_code"] != "KLAX" || feature.Properties["iso_region"] != "US-CA" {
+		t.Errorf("unexpected properties %v", feature.Properties)
+	}
+	position, ok := feature.Geometry.Coordinates.([]float64)
+	if feature.Geometry.Type != GeoJSONPointType || !ok || position[0] != airport.LongitudeDeg || position[1] != airport.LatitudeDeg {
+		t.Errorf("unexpected geometry %+v", feature.Geometry)
+	}
+
+	metar, err := ParseMETAR("KLAX 011753Z 25010KT 10SM FEW030 20/12 A2992", testWeatherTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	airport.METAR, airport.FlightCategory = metar, metar.FlightCategory()
+	feature = AirportFeature(airport)
+	checkGeoJSONProperties(t, feature, RecordKindAirport, "metar", "flight_category")
+	if feature.Properties["flight_category"] != FlightCategoryVFR || feature.Properties["metar"] != metar.Raw {
+		t.Errorf("unexpected weather properties %v", feature.Properties)
+	}
+}
+
+func TestRunwayFeature(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	runway := finder.FindAirportByICAOCode("KSMO").Runways[0]
+	feature := RunwayFeature(&runway, "KSMO")
+	checkGeoJSONProperties(t, feature, RecordKindRunway, "airport_icao_code", "le_ident", "he_ident", "le_heading_degT", "length_ft")
+	if feature.Properties["airport_icao_code"] != "KSMO" || feature.Properties["le_ident"] != "03" {
+		t.Errorf("unexpected properties %v", feature.Properties)
+	}
+
+	polygon, ok := feature.Geometry.Coordinates.([][][]float64)
+	if feature.Geometry.Type != GeoJSONPolygonType || !ok || len(polygon) != 1 || len(polygon[0]) != 5 {
+		t.Fatalf("unexpected geometry %+v", feature.Geometry)
+	}
+	ring := polygon[0]
+	if ring[0][0] != ring[4][0] || ring[0][1] != ring[4][1] {
+		t.Error("ring is not closed")
+	}
+	if area := ringArea(ring); area <= 0 {
+		t.Errorf("ring is not counterclockwise, signed area %g", area)
+	}
+	width := Distance(ring[0][1], ring[0][0], ring[3][1], ring[3][0])
+	if want := FeetToMeters(float64(runway.WidthFt)); width < want*0.99 || width > want*1.01 {
+		t.Errorf("got width %.1f m, want %.1f m", width, want)
+	}
+
+	runway.WidthFt = 0
+	if feature := RunwayFeature(&runway, "KSMO"); feature.Geometry.Type != GeoJSONLineStringType {
+		t.Errorf("runway without width: got %s", feature.Geometry.Type)
+	}
+	runway.HighEndLatitudeDeg, runway.HighEndLongitudeDeg = 0, 0
+	if feature := RunwayFeature(&runway, "KSMO"); feature.Geometry != nil {
+		t.Errorf("runway without coordinates: got %+v", feature.Geometry)
+	}
+}
+
+func TestNavaidFeature(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	navaids := finder.FindAirportByICAOCode("KLAX").Navaids
+	if len(navaids) == 0 {
+		t.Fatal("no navaids")
+	}
+	feature := NavaidFeature(&navaids[0])
+	checkGeoJSONProperties(t, feature, RecordKindNavaid, "ident", "frequency_khz", "associated_airport")
+	if feature.Properties["ident"] != "LAX" {
+		t.Errorf("unexpected properties %v", feature.Properties)
+	}
+}
+
+func TestAirportsFeatureCollectionJSON(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	airports := []*Airport{finder.FindAirportByICAOCode("KLAX"), finder.FindAirportByICAOCode("KSMO")}
+	var buf bytes.Buffer
+	if err := AirportsFeatureCollection(airports, true, true).Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var fc struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Type       string                 `json:"type"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &fc); err != nil {
+		t.Fatal(err)
+	}
+	kinds := make(map[string]int)
+	for _, feature := range fc.Features {
+		kinds[feature.Properties["kind"].(string)]++
+	}
+	if fc.Type != GeoJSONFeatureCollectionType || kinds[RecordKindAirport] != 2 || kinds[RecordKindRunway] != 3 || kinds[RecordKindNavaid] != 1 {
+		t.Errorf("got %s with %v", fc.Type, kinds)
+	}
+}
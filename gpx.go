@@ -0,0 +1,210 @@
+package alphafoxtrot
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// see https://www.topografix.com/GPX/1/1/
+
+const (
+	GPXVersion   = "1.1"
+	GPXNamespace = "http://www.topografix.com/GPX/1/1"
+	GPXCreator   = "go-airport-finder"
+)
+
+// symbols as used by Garmin devices
+var gpxAirportSymbols = map[string]string{
+	AirportTypeLargeName:        "Airport",
+	AirportTypeMediumName:       "Airport",
+	AirportTypeSmallName:        "Private Field",
+	AirportTypeHeliportName:     "Heliport",
+	AirportTypeSeaplaneBaseName: "Anchor",
+	AirportTypeClosedName:       "Soft Field",
+}
+
+var gpxNavaidSymbols = map[string]string{
+	"VOR":     "VOR",
+	"VOR-DME": "VOR/DME",
+	"VORTAC":  "VORTAC",
+	"TACAN":   "TACAN",
+	"DME":     "DME",
+	"NDB":     "NDB",
+	"NDB-DME": "NDB",
+}
+
+type GPX struct {
+	XMLName   xml.Name      `xml:"gpx"`
+	Version   string        `xml:"version,attr"`
+	Creator   string        `xml:"creator,attr"`
+	Namespace string        `xml:"xmlns,attr,omitempty"`
+	Waypoints []GPXWaypoint `xml:"wpt"`
+	Routes    []GPXRoute    `xml:"rte"`
+}
+
+type GPXWaypoint struct {
+	LatitudeDeg  float64  `xml:"lat,attr"`
+	LongitudeDeg float64  `xml:"lon,attr"`
+	ElevationM   *float64 `xml:"ele,omitempty"`
+	Name         string   `xml:"name,omitempty"`
+	Comment      string   `xml:"cmt,omitempty"`
+	Description  string   `xml:"desc,omitempty"`
+	Symbol       string   `xml:"sym,omitempty"`
+	Type         string   `xml:"type,omitempty"`
+}
+
+type GPXRoute struct {
+	Name   string        `xml:"name,omitempty"`
+	Points []GPXWaypoint `xml:"rtept"`
+}
+
+func NewGPX() *GPX {
+	return &GPX{
+		Version:   GPXVersion,
+		Creator:   GPXCreator,
+		Namespace: GPXNamespace,
+	}
+}
+
+// NewGPXWaypoints returns a GPX document with the airports and navaids as waypoints
+func NewGPXWaypoints(airports []*Airport, navaids []*Navaid) *GPX {
+	gpx := NewGPX()
+	for _, airport := range airports {
+		gpx.Waypoints = append(gpx.Waypoints, AirportWaypoint(airport))
+	}
+	for _, navaid := range navaids {
+		gpx.Waypoints = append(gpx.Waypoints, NavaidWaypoint(navaid))
+	}
+	return gpx
+}
+
+// NewGPXRoute returns a GPX document with a single route through the given waypoints (see AirportWaypoint and NavaidWaypoint)
+func NewGPXRoute(name string, waypoints []GPXWaypoint) *GPX {
+	gpx := NewGPX()
+	gpx.Routes = append(gpx.Routes, GPXRoute{Name: name, Points: waypoints})
+	return gpx
+}
+
+func AirportWaypoint(airport *Airport) GPXWaypoint {
+	elevation := math.Round(FeetToMeters(float64(airport.ElevationFt))*10) / 10
+	symbol, ok := gpxAirportSymbols[airport.Type]
+	if !ok {
+		symbol = "Airport"
+	}
+	return GPXWaypoint{
+		LatitudeDeg:  airport.LatitudeDeg,
+		LongitudeDeg: airport.LongitudeDeg,
+		ElevationM:   &elevation,
+		Name:         airport.ICAOCode,
+		Comment:      airport.IATACode,
+		Description:  airport.Name,
+		Symbol:       symbol,
+		Type:         airport.Type,
+	}
+}
+
+func NavaidWaypoint(navaid *Navaid) GPXWaypoint {
+	elevation := math.Round(FeetToMeters(float64(navaid.ElevationFt))*10) / 10
+	symbol, ok := gpxNavaidSymbols[navaid.Type]
+	if !ok {
+		symbol = "Navaid, Blue"
+	}
+	return GPXWaypoint{
+		LatitudeDeg:  navaid.LatitudeDeg,
+		LongitudeDeg: navaid.LongitudeDeg,
+		ElevationM:   &elevation,
+		Name:         navaid.Ident,
+		Comment:      fmt.Sprintf("%d kHz", navaid.FrequencyKHZ),
+		Description:  navaid.Name,
+		Symbol:       symbol,
+		Type:         navaid.Type,
+	}
+}
+
+func (gpx *GPX) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(gpx); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func ReadGPX(r io.Reader) (*GPX, error) {
+	gpx := &GPX{}
+	if err := xml.NewDecoder(r).Decode(gpx); err != nil {
+		return nil, err
+	}
+	return gpx, nil
+}
+
+// GPXMatch is a waypoint resolved to a known airport or navaid, both are nil if nothing was found
+type GPXMatch struct {
+	Waypoint       GPXWaypoint
+	Airport        *Airport
+	Navaid         *Navaid
+	DistanceMeters float64
+}
+
+// ResolveGPX resolves all waypoints and route points of the GPX document to airports and navaids within the given radius.
+// A candidate whose code or name matches the waypoint name wins over a closer candidate without a matching name.
+func (af *AirportFinder) ResolveGPX(gpx *GPX, radiusMeters float64) []GPXMatch {
+	waypoints := append([]GPXWaypoint{}, gpx.Waypoints...)
+	for _, route := range gpx.Routes {
+		waypoints = append(waypoints, route.Points...)
+	}
+	matches := make([]GPXMatch, 0, len(waypoints))
+	for _, waypoint := range waypoints {
+		matches = append(matches, af.ResolveWaypoint(waypoint, radiusMeters))
+	}
+	return matches
+}
+
+func (af *AirportFinder) ResolveWaypoint(waypoint GPXWaypoint, radiusMeters float64) GPXMatch {
+	const maxCandidates = 20
+
+	match := GPXMatch{Waypoint: waypoint}
+	name := strings.ToUpper(strings.TrimSpace(waypoint.Name))
+	airports := af.FindNearestAirports(waypoint.LatitudeDeg, waypoint.LongitudeDeg, radiusMeters, maxCandidates, AirportTypeAll)
+	navaids := af.FindNearestNavaids(waypoint.LatitudeDeg, waypoint.LongitudeDeg, radiusMeters, maxCandidates)
+	distanceTo := func(latitudeDeg, longitudeDeg float64) float64 {
+		return Distance(waypoint.LatitudeDeg, waypoint.LongitudeDeg, latitudeDeg, longitudeDeg)
+	}
+
+	if name != "" {
+		for _, airport := range airports {
+			if name == airport.ICAOCode || name == airport.IATACode || name == airport.GPSCode || name == strings.ToUpper(airport.Name) {
+				match.Airport = airport
+				match.DistanceMeters = distanceTo(airport.LatitudeDeg, airport.LongitudeDeg)
+				return match
+			}
+		}
+		for _, navaid := range navaids {
+			if name == navaid.Ident || name == strings.ToUpper(navaid.Name) {
+				match.Navaid = navaid
+				match.DistanceMeters = distanceTo(navaid.LatitudeDeg, navaid.LongitudeDeg)
+				return match
+			}
+		}
+	}
+
+	if len(airports) > 0 {
+		match.Airport = airports[0]
+		match.DistanceMeters = distanceTo(airports[0].LatitudeDeg, airports[0].LongitudeDeg)
+	}
+	if len(navaids) > 0 {
+		if distance := distanceTo(navaids[0].LatitudeDeg, navaids[0].LongitudeDeg); match.Airport == nil || distance < match.DistanceMeters {
+			match.Airport = nil
+			match.Navaid = navaids[0]
+			match.DistanceMeters = distance
+		}
+	}
+	return match
+}
@@ -0,0 +1,93 @@
+package alphafoxtrot
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGPXRoundTrip(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	klax, ksmo := finder.FindAirportByICAOCode("KLAX"), finder.FindAirportByICAOCode("KSMO")
+	navaids := finder.FindNavaidsByAirportICAOCode("KLAX")
+	gpx := NewGPXWaypoints([]*Airport{klax, ksmo}, navaids)
+	gpx.Routes = NewGPXRoute("KSMO - KLAX", []GPXWaypoint{AirportWaypoint(ksmo), NavaidWaypoint(navaids[0]), AirportWaypoint(klax)}).Routes
+
+	var buf bytes.Buffer
+	if err := gpx.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "<?xml") || !strings.Contains(buf.String(), `xmlns="`+GPXNamespace+`"`) {
+		t.Errorf("unexpected GPX header:\n%s", buf.String())
+	}
+	read, err := ReadGPX(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read.XMLName = gpx.XMLName
+	if !reflect.DeepEqual(read, gpx) {
+		t.Errorf("got %+v, want %+v", read, gpx)
+	}
+	waypoint := read.Waypoints[1]
+	if waypoint.Name != "KSMO" || waypoint.Description != ksmo.Name || waypoint.Symbol != "Airport" || waypoint.ElevationM == nil || *waypoint.ElevationM != 53.9 {
+		t.Errorf("unexpected KSMO waypoint %+v", waypoint)
+	}
+	if waypoint := read.Waypoints[2]; waypoint.Name != "LAX" || waypoint.Symbol != "VORTAC" || waypoint.Comment != "113600 kHz" {
+		t.Errorf("unexpected navaid waypoint %+v", waypoint)
+	}
+
+	if _, err := ReadGPX(strings.NewReader("<gpx><wpt lat=\"x\"/></gpx>")); err == nil {
+		t.Error("expected an error for an invalid latitude")
+	}
+}
+
+func TestResolveWaypoint(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	const vortacLat, vortacLon = 33.9331, -118.432
+	klax := finder.FindAirportByICAOCode("KLAX")
+	ksmo := finder.FindAirportByICAOCode("KSMO")
+	tests := []struct {
+		name     string
+		waypoint GPXWaypoint
+		airport  string
+		navaid   string
+	}{
+		{"name beats a closer navaid", GPXWaypoint{LatitudeDeg: vortacLat, LongitudeDeg: vortacLon, Name: "KLAX"}, "KLAX", ""},
+		{"IATA code", GPXWaypoint{LatitudeDeg: vortacLat, LongitudeDeg: vortacLon, Name: "lax"}, "KLAX", ""},
+		{"navaid name", GPXWaypoint{LatitudeDeg: klax.LatitudeDeg, LongitudeDeg: klax.LongitudeDeg, Name: "Los Angeles"}, "", "LAX"},
+		{"nearest navaid", GPXWaypoint{LatitudeDeg: vortacLat, LongitudeDeg: vortacLon}, "", "LAX"},
+		{"nearest airport", GPXWaypoint{LatitudeDeg: ksmo.LatitudeDeg, LongitudeDeg: ksmo.LongitudeDeg}, "KSMO", ""},
+		{"unknown name", GPXWaypoint{LatitudeDeg: ksmo.LatitudeDeg + 0.001, LongitudeDeg: ksmo.LongitudeDeg, Name: "HOME"}, "KSMO", ""},
+		{"name outside the radius", GPXWaypoint{LatitudeDeg: ksmo.LatitudeDeg, LongitudeDeg: ksmo.LongitudeDeg, Name: "KLGB"}, "KSMO", ""},
+		{"nothing in range", GPXWaypoint{LatitudeDeg: 0, LongitudeDeg: -150, Name: "KLAX"}, "", ""},
+	}
+	for _, test := range tests {
+		match := finder.ResolveWaypoint(test.waypoint, KilometersToMeters(10))
+		airport, navaid := "", ""
+		if match.Airport != nil {
+			airport = match.Airport.ICAOCode
+			if distance := Distance(test.waypoint.LatitudeDeg, test.waypoint.LongitudeDeg, match.Airport.LatitudeDeg, match.Airport.LongitudeDeg); distance != match.DistanceMeters {
+				t.Errorf("%s: got distance %.1f m, want %.1f m", test.name, match.DistanceMeters, distance)
+			}
+		}
+		if match.Navaid != nil {
+			navaid = match.Navaid.Ident
+		}
+		if airport != test.airport || navaid != test.navaid || match.Waypoint != test.waypoint {
+			t.Errorf("%s: got airport %q and navaid %q, want %q and %q", test.name, airport, navaid, test.airport, test.navaid)
+		}
+	}
+}
+
+func TestResolveGPX(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	gpx := NewGPX()
+	gpx.Waypoints = []GPXWaypoint{{LatitudeDeg: 33.8177, LongitudeDeg: -118.152, Name: "KLGB"}}
+	gpx.Routes = []GPXRoute{{Points: []GPXWaypoint{{LatitudeDeg: 34.0158, LongitudeDeg: -118.451}, {LatitudeDeg: 0, LongitudeDeg: 0}}}}
+	matches := finder.ResolveGPX(gpx, KilometersToMeters(5))
+	if len(matches) != 3 || matches[0].Airport == nil || matches[0].Airport.ICAOCode != "KLGB" ||
+		matches[1].Airport == nil || matches[1].Airport.ICAOCode != "KSMO" || matches[2].Airport != nil || matches[2].Navaid != nil {
+		t.Errorf("unexpected matches %+v", matches)
+	}
+}
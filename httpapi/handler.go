@@ -0,0 +1,232 @@
+// Package httpapi exposes an AirportFinder as a JSON REST API.
+//
+//	GET /airports?region=&country=&continent=&type=
+//	GET /airports/icao/{icao}
+//	GET /airports/iata/{iata}
+//	GET /airports/nearest?lat=&lon=&radius=&max=&type=
+//	GET /airports/search?q=&max=&type=
+//	GET /airports/{icao}/runways
+//	GET /airports/{icao}/frequencies
+//	GET /airports/{icao}/navaids
+//	GET /navaids/nearest?lat=&lon=&radius=&max=
+//	GET /regions?country=
+//	GET /countries?continent=
+//
+// The type parameter accepts a comma-separated list of airport types (see alphafoxtrot.AirportTypeFilterFromString),
+// the radius is given in meters.
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	alphafoxtrot "github.com/grumpypixel/go-airport-finder"
+)
+
+const (
+	DefaultMaxResults = 10
+	DefaultRadius     = 50000.0
+)
+
+type ErrorResponse struct {
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+}
+
+type Handler struct {
+	finder *alphafoxtrot.AirportFinder
+	mux    *http.ServeMux
+}
+
+func NewHandler(finder *alphafoxtrot.AirportFinder) *Handler {
+	h := &Handler{
+		finder: finder,
+		mux:    http.NewServeMux(),
+	}
+	h.mux.HandleFunc("/airports", h.handleAirports)
+	h.mux.HandleFunc("/airports/", h.handleAirport)
+	h.mux.HandleFunc("/navaids/nearest", h.handleNearestNavaids)
+	h.mux.HandleFunc("/regions", h.handleRegions)
+	h.mux.HandleFunc("/countries", h.handleCountries)
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) handleAirports(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	typeFilter, err := airportTypeFilter(query.Get("type"), alphafoxtrot.AirportTypeAll)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	airports := h.finder.FindAllAirports(query.Get("region"), query.Get("country"), query.Get("continent"), typeFilter)
+	writeJSON(w, http.StatusOK, NewAirportResponses(airports))
+}
+
+func (h *Handler) handleAirport(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/airports/"), "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "nearest":
+		h.handleNearestAirports(w, r)
+	case len(parts) == 1 && parts[0] == "search":
+		h.handleSearch(w, r)
+	case len(parts) == 2 && parts[0] == "icao":
+		h.writeAirport(w, h.finder.FindAirportByICAOCode(strings.ToUpper(parts[1])), parts[1])
+	case len(parts) == 2 && parts[0] == "iata":
+		h.writeAirport(w, h.finder.FindAirportByIATACode(strings.ToUpper(parts[1])), parts[1])
+	case len(parts) == 2:
+		airport := h.finder.FindAirportByICAOCode(strings.ToUpper(parts[0]))
+		if airport == nil {
+			writeError(w, http.StatusNotFound, "airport %s not found", parts[0])
+			return
+		}
+		switch parts[1] {
+		case "runways":
+			writeJSON(w, http.StatusOK, NewRunwayResponses(airport.Runways))
+		case "frequencies":
+			writeJSON(w, http.StatusOK, NewFrequencyResponses(airport.Frequencies))
+		case "navaids":
+			writeJSON(w, http.StatusOK, NewAirportResponse(airport).Navaids)
+		default:
+			writeError(w, http.StatusNotFound, "unknown resource %s", parts[1])
+		}
+	default:
+		writeError(w, http.StatusNotFound, "not found: %s", r.URL.Path)
+	}
+}
+
+func (h *Handler) writeAirport(w http.ResponseWriter, airport *alphafoxtrot.Airport, code string) {
+	if airport == nil {
+		writeError(w, http.StatusNotFound, "airport %s not found", code)
+		return
+	}
+	writeJSON(w, http.StatusOK, NewAirportResponse(airport))
+}
+
+func (h *Handler) handleNearestAirports(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	latitude, longitude, err := position(query.Get("lat"), query.Get("lon"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	radius, maxResults, err := radiusAndMaxResults(query.Get("radius"), query.Get("max"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	typeFilter, err := airportTypeFilter(query.Get("type"), alphafoxtrot.AirportTypeActive)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	airports := h.finder.FindNearestAirports(latitude, longitude, radius, maxResults, typeFilter)
+	writeJSON(w, http.StatusOK, NewAirportResponses(airports))
+}
+
+func (h *Handler) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := query.Get("q")
+	if strings.TrimSpace(q) == "" {
+		writeError(w, http.StatusBadRequest, "missing query parameter q")
+		return
+	}
+	_, maxResults, err := radiusAndMaxResults("", query.Get("max"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	typeFilter, err := airportTypeFilter(query.Get("type"), alphafoxtrot.AirportTypeAll)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	airports := h.finder.SearchAirports(q, maxResults, typeFilter)
+	writeJSON(w, http.StatusOK, NewAirportResponses(airports))
+}
+
+func (h *Handler) handleNearestNavaids(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	latitude, longitude, err := position(query.Get("lat"), query.Get("lon"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	radius, maxResults, err := radiusAndMaxResults(query.Get("radius"), query.Get("max"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	navaids := h.finder.FindNearestNavaids(latitude, longitude, radius, maxResults)
+	writeJSON(w, http.StatusOK, NewNavaidResponses(navaids))
+}
+
+func (h *Handler) handleRegions(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, NewRegionResponses(h.finder.FindAllRegions(r.URL.Query().Get("country"))))
+}
+
+func (h *Handler) handleCountries(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, NewCountryResponses(h.finder.FindAllCountries(r.URL.Query().Get("continent"))))
+}
+
+func position(lat, lon string) (float64, float64, error) {
+	if lat == "" || lon == "" {
+		return 0, 0, fmt.Errorf("missing query parameters lat and lon")
+	}
+	latitude, err := strconv.ParseFloat(lat, 64)
+	if err != nil || latitude < -90 || latitude > 90 {
+		return 0, 0, fmt.Errorf("invalid latitude: %s", lat)
+	}
+	longitude, err := strconv.ParseFloat(lon, 64)
+	if err != nil || longitude < -180 || longitude > 180 {
+		return 0, 0, fmt.Errorf("invalid longitude: %s", lon)
+	}
+	return latitude, longitude, nil
+}
+
+func radiusAndMaxResults(radiusParam, maxParam string) (float64, int, error) {
+	radius := DefaultRadius
+	if radiusParam != "" {
+		value, err := strconv.ParseFloat(radiusParam, 64)
+		if err != nil || value < 0 {
+			return 0, 0, fmt.Errorf("invalid radius: %s", radiusParam)
+		}
+		radius = value
+	}
+	maxResults := DefaultMaxResults
+	if maxParam != "" {
+		value, err := strconv.Atoi(maxParam)
+		if err != nil || value < 0 {
+			return 0, 0, fmt.Errorf("invalid max: %s", maxParam)
+		}
+		maxResults = value
+	}
+	return radius, maxResults, nil
+}
+
+func airportTypeFilter(param string, defaultFilter uint64) (uint64, error) {
+	if param == "" {
+		return defaultFilter, nil
+	}
+	return alphafoxtrot.AirportTypeFilterFromString(param)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	writeJSON(w, status, ErrorResponse{Status: status, Error: fmt.Sprintf(format, args...)})
+}
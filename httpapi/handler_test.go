@@ -0,0 +1,142 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	alphafoxtrot "github.com/grumpypixel/go-airport-finder"
+)
+
+const testDataDir = "../testdata/ourairports"
+
+func loadTestFinder(t *testing.T) *alphafoxtrot.AirportFinder {
+	t.Helper()
+	finder := alphafoxtrot.NewAirportFinder()
+	if errs := finder.Load(alphafoxtrot.PresetLoadOptions(testDataDir), alphafoxtrot.AirportTypeAll); len(errs) > 0 {
+		t.Fatalf("load: %v", errs)
+	}
+	return finder
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(NewHandler(loadTestFinder(t)))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, server *httptest.Server, path string, wantStatus int, value interface{}) {
+	t.Helper()
+	resp, err := http.Get(server.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != wantStatus {
+		t.Fatalf("GET %s: got status %d, want %d", path, resp.StatusCode, wantStatus)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("GET %s: got content type %q", path, contentType)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(value); err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+}
+
+func TestAirportByCode(t *testing.T) {
+	server := newTestServer(t)
+	for _, path := range []string{"/airports/icao/KLAX", "/airports/icao/klax", "/airports/iata/LAX"} {
+		var airport map[string]interface{}
+		get(t, server, path, http.StatusOK, &airport)
+		if airport["icao_code"] != "KLAX" || airport["type"] != "large_airport" {
+			t.Errorf("GET %s: unexpected airport %v", path, airport)
+		}
+		if runways, ok := airport["runways"].([]interface{}); !ok || len(runways) != 2 {
+			t.Errorf("GET %s: got runways %v", path, airport["runways"])
+		}
+		if region, ok := airport["region"].(map[string]interface{}); !ok || region["iso_code"] != "US-CA" {
+			t.Errorf("GET %s: got region %v", path, airport["region"])
+		}
+	}
+}
+
+func TestSubResources(t *testing.T) {
+	server := newTestServer(t)
+	var runways []RunwayResponse
+	get(t, server, "/airports/KSMO/runways", http.StatusOK, &runways)
+	if len(runways) != 1 || runways[0].LowEndIdent != "03" || runways[0].LengthFt != 3500 {
+		t.Errorf("unexpected runways %+v", runways)
+	}
+	var frequencies []FrequencyResponse
+	get(t, server, "/airports/KLAX/frequencies", http.StatusOK, &frequencies)
+	if len(frequencies) != 2 {
+		t.Errorf("got %d frequencies, want 2", len(frequencies))
+	}
+	var navaids []NavaidResponse
+	get(t, server, "/airports/KLAX/navaids", http.StatusOK, &navaids)
+	if len(navaids) != 1 || navaids[0].Ident != "LAX" {
+		t.Errorf("unexpected navaids %+v", navaids)
+	}
+}
+
+func TestNearestAirports(t *testing.T) {
+	server := newTestServer(t)
+	var airports []AirportResponse
+	get(t, server, "/airports/nearest?lat=33.94&lon=-118.41&radius=30000&max=2&type=large,medium", http.StatusOK, &airports)
+	if len(airports) != 2 || airports[0].ICAOCode != "KLAX" || airports[1].ICAOCode != "KSMO" {
+		t.Errorf("unexpected airports %+v", airports)
+	}
+}
+
+func TestListsAndSearch(t *testing.T) {
+	server := newTestServer(t)
+	var airports []AirportResponse
+	get(t, server, "/airports?country=DE", http.StatusOK, &airports)
+	if len(airports) != 1 || airports[0].ICAOCode != "EDDF" {
+		t.Errorf("unexpected airports %+v", airports)
+	}
+	get(t, server, "/airports/search?q=long%20beach", http.StatusOK, &airports)
+	if len(airports) != 1 || airports[0].ICAOCode != "KLGB" {
+		t.Errorf("unexpected search results %+v", airports)
+	}
+	var regions []RegionResponse
+	get(t, server, "/regions?country=US", http.StatusOK, &regions)
+	if len(regions) != 1 || regions[0].ISOCode != "US-CA" {
+		t.Errorf("unexpected regions %+v", regions)
+	}
+	var countries []CountryResponse
+	get(t, server, "/countries?continent=EU", http.StatusOK, &countries)
+	if len(countries) != 1 || countries[0].ISOCode != "DE" {
+		t.Errorf("unexpected countries %+v", countries)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/airports/icao/XXXX", http.StatusNotFound},
+		{"/airports/iata/XXX", http.StatusNotFound},
+		{"/airports/XXXX/runways", http.StatusNotFound},
+		{"/airports/KLAX/gates", http.StatusNotFound},
+		{"/airports/a/b/c", http.StatusNotFound},
+		{"/airports/nearest?lat=33.94", http.StatusBadRequest},
+		{"/airports/nearest?lat=91&lon=0", http.StatusBadRequest},
+		{"/airports/nearest?lat=33.94&lon=-118.41&radius=-1", http.StatusBadRequest},
+		{"/airports/nearest?lat=33.94&lon=-118.41&max=many", http.StatusBadRequest},
+		{"/airports/nearest?lat=33.94&lon=-118.41&type=spaceport", http.StatusBadRequest},
+		{"/airports/search", http.StatusBadRequest},
+		{"/navaids/nearest?lon=0", http.StatusBadRequest},
+	}
+	server := newTestServer(t)
+	for _, test := range tests {
+		var response ErrorResponse
+		get(t, server, test.path, test.status, &response)
+		if response.Status != test.status || response.Error == "" {
+			t.Errorf("GET %s: unexpected error body %+v", test.path, response)
+		}
+	}
+}
@@ -0,0 +1,546 @@
+package httpapi
+
+import (
+	"time"
+
+	alphafoxtrot "github.com/grumpypixel/go-airport-finder"
+)
+
+// The response types decouple the JSON of the API from the library types.
+
+type AirportResponse struct {
+	ID               uint64              `json:"id"`
+	ICAOCode         string              `json:"icao_code"`
+	Type             string              `json:"type"`
+	Name             string              `json:"name"`
+	LatitudeDeg      float64             `json:"latitude_deg"`
+	LongitudeDeg     float64             `json:"longitude_deg"`
+	ElevationFt      int64               `json:"elevation_ft"`
+	Continent        string              `json:"continent"`
+	Municipality     string              `json:"municipality"`
+	ScheduledService bool                `json:"scheduled_service"`
+	GPSCode          string              `json:"gps_code"`
+	IATACode         string              `json:"iata_code"`
+	LocalCode        string              `json:"local_code"`
+	HomeLink         string              `json:"home_link"`
+	WikipediaLink    string              `json:"wikipedia_link"`
+	Keywords         string              `json:"keywords"`
+	Region           RegionResponse      `json:"region"`
+	Country          CountryResponse     `json:"country"`
+	Runways          []RunwayResponse    `json:"runways"`
+	Frequencies      []FrequencyResponse `json:"frequencies"`
+	Navaids          []NavaidResponse    `json:"navaids"`
+	TimeZone         string              `json:"timezone"`
+	UTCOffsetHours   float64             `json:"utc_offset_hours"`
+	DST              string              `json:"dst"`
+	Origin           string              `json:"origin"`
+	METAR            *METARResponse      `json:"metar,omitempty"`
+	FlightCategory   string              `json:"flight_category,omitempty"`
+	TAF              *TAFResponse        `json:"taf,omitempty"`
+}
+
+type FrequencyResponse struct {
+	Type         string  `json:"type"`
+	Description  string  `json:"description"`
+	FrequencyMHZ float64 `json:"frequency_mhz"`
+	Origin       string  `json:"origin"`
+}
+
+type RunwayResponse struct {
+	LengthFt                    int64   `json:"length_ft"`
+	WidthFt                     int64   `json:"width_ft"`
+	Surface                     string  `json:"surface"`
+	Lighted                     bool    `json:"lighted"`
+	Closed                      bool    `json:"closed"`
+	LowEndIdent                 string  `json:"le_ident"`
+	LowEndLatitudeDeg           float64 `json:"le_latitude_deg"`
+	LowEndLongitudeDeg          float64 `json:"le_longitude_deg"`
+	LowEndElevationFt           int64   `json:"le_elevation_ft"`
+	LowEndHeadingDegT           float64 `json:"le_heading_degT"`
+	LowEndDisplacedThresholdFt  int64   `json:"le_displaced_threshold_ft"`
+	HighEndIdent                string  `json:"he_ident"`
+	HighEndLatitudeDeg          float64 `json:"he_latitude_deg"`
+	HighEndLongitudeDeg         float64 `json:"he_longitude_deg"`
+	HighEndElevationFt          int64   `json:"he_elevation_ft"`
+	HighEndHeadingDegT          float64 `json:"he_heading_degT"`
+	HighEndDisplacedThresholdFt int64   `json:"he_displaced_threshold_ft"`
+	Origin                      string  `json:"origin"`
+}
+
+type RegionResponse struct {
+	ISOCode       string `json:"iso_code"`
+	LocalCode     string `json:"local_code"`
+	Name          string `json:"name"`
+	WikipediaLink string `json:"wikipedia_link"`
+	Keywords      string `json:"keywords"`
+}
+
+type CountryResponse struct {
+	ISOCode       string `json:"iso_code"`
+	Name          string `json:"name"`
+	Continent     string `json:"continent"`
+	WikipediaLink string `json:"wikipedia_link"`
+	Keywords      string `json:"keywords"`
+}
+
+type NavaidResponse struct {
+	Ident                string  `json:"ident"`
+	Name                 string  `json:"name"`
+	Type                 string  `json:"type"`
+	FrequencyKHZ         uint64  `json:"frequency_khz"`
+	LatitudeDeg          float64 `json:"latitude_deg"`
+	LongitudeDeg         float64 `json:"longitude_deg"`
+	ElevationFt          int64   `json:"elevation_ft"`
+	ISOCountry           string  `json:"iso_country"`
+	DMEFrequencyKHZ      uint64  `json:"dme_frequency_khz"`
+	DMEChannel           string  `json:"dme_channel"`
+	DMELatitudeDeg       float64 `json:"dme_latitude_deg"`
+	DMELongitudeDeg      float64 `json:"dme_longitude_deg"`
+	DMEElevationFt       int64   `json:"dme_elevation_ft"`
+	SlavedVariationDeg   float64 `json:"slaved_variation_deg"`
+	MagneticVariationDeg float64 `json:"magnetic_variation_deg"`
+	UsageType            string  `json:"usage_type"`
+	Power                string  `json:"power"`
+	AssociatedAirport    string  `json:"associated_airport"`
+	Origin               string  `json:"origin"`
+}
+
+func NewAirportResponse(airport *alphafoxtrot.Airport) *AirportResponse {
+	response := &AirportResponse{
+		ID:               airport.ID,
+		ICAOCode:         airport.ICAOCode,
+		Type:             airport.Type,
+		Name:             airport.Name,
+		LatitudeDeg:      airport.LatitudeDeg,
+		LongitudeDeg:     airport.LongitudeDeg,
+		ElevationFt:      airport.ElevationFt,
+		Continent:        airport.Continent,
+		Municipality:     airport.Municipality,
+		ScheduledService: airport.ScheduledService,
+		GPSCode:          airport.GPSCode,
+		IATACode:         airport.IATACode,
+		LocalCode:        airport.LocalCode,
+		HomeLink:         airport.HomeLink,
+		WikipediaLink:    airport.WikipediaLink,
+		Keywords:         airport.Keywords,
+		Region:           *NewRegionResponse(&airport.Region),
+		Country:          *NewCountryResponse(&airport.Country),
+		Runways:          NewRunwayResponses(airport.Runways),
+		Frequencies:      NewFrequencyResponses(airport.Frequencies),
+		Navaids:          make([]NavaidResponse, 0, len(airport.Navaids)),
+		TimeZone:         airport.TimeZone,
+		UTCOffsetHours:   airport.UTCOffsetHours,
+		DST:              airport.DST,
+		Origin:           airport.Origin,
+		FlightCategory:   airport.FlightCategory,
+	}
+	if airport.METAR != nil {
+		response.METAR = NewMETARResponse(airport.METAR)
+	}
+	if airport.TAF != nil {
+		response.TAF = NewTAFResponse(airport.TAF)
+	}
+	for i := range airport.Navaids {
+		response.Navaids = append(response.Navaids, *NewNavaidResponse(&airport.Navaids[i]))
+	}
+	return response
+}
+
+func NewAirportResponses(airports []*alphafoxtrot.Airport) []*AirportResponse {
+	responses := make([]*AirportResponse, 0, len(airports))
+	for _, airport := range airports {
+		responses = append(responses, NewAirportResponse(airport))
+	}
+	return responses
+}
+
+func NewFrequencyResponses(frequencies []alphafoxtrot.Frequency) []FrequencyResponse {
+	responses := make([]FrequencyResponse, 0, len(frequencies))
+	for _, frequency := range frequencies {
+		responses = append(responses, FrequencyResponse{
+			Type:         frequency.Type,
+			Description:  frequency.Description,
+			FrequencyMHZ: frequency.FrequencyMHZ,
+			Origin:       frequency.Origin,
+		})
+	}
+	return responses
+}
+
+func NewRunwayResponses(runways []alphafoxtrot.Runway) []RunwayResponse {
+	responses := make([]RunwayResponse, 0, len(runways))
+	for _, runway := range runways {
+		responses = append(responses, RunwayResponse{
+			LengthFt:                    runway.LengthFt,
+			WidthFt:                     runway.WidthFt,
+			Surface:                     runway.Surface,
+			Lighted:                     runway.Lighted,
+			Closed:                      runway.Closed,
+			LowEndIdent:                 runway.LowEndIdent,
+			LowEndLatitudeDeg:           runway.LowEndLatitudeDeg,
+			LowEndLongitudeDeg:          runway.LowEndLongitudeDeg,
+			LowEndElevationFt:           runway.LowEndElevationFt,
+			LowEndHeadingDegT:           runway.LowEndHeadingDegT,
+			LowEndDisplacedThresholdFt:  runway.LowEndDisplacedThresholdFt,
+			HighEndIdent:                runway.HighEndIdent,
+			HighEndLatitudeDeg:          runway.HighEndLatitudeDeg,
+			HighEndLongitudeDeg:         runway.HighEndLongitudeDeg,
+			HighEndElevationFt:          runway.HighEndElevationFt,
+			HighEndHeadingDegT:          runway.HighEndHeadingDegT,
+			HighEndDisplacedThresholdFt: runway.HighEndDisplacedThresholdFt,
+			Origin:                      runway.Origin,
+		})
+	}
+	return responses
+}
+
+func NewRegionResponse(region *alphafoxtrot.Region) *RegionResponse {
+	return &RegionResponse{
+		ISOCode:       region.ISOCode,
+		LocalCode:     region.LocalCode,
+		Name:          region.Name,
+		WikipediaLink: region.WikipediaLink,
+		Keywords:      region.Keywords,
+	}
+}
+
+func NewRegionResponses(regions []*alphafoxtrot.Region) []*RegionResponse {
+	responses := make([]*RegionResponse, 0, len(regions))
+	for _, region := range regions {
+		responses = append(responses, NewRegionResponse(region))
+	}
+	return responses
+}
+
+func NewCountryResponse(country *alphafoxtrot.Country) *CountryResponse {
+	return &CountryResponse{
+		ISOCode:       country.ISOCode,
+		Name:          country.Name,
+		Continent:     country.Continent,
+		WikipediaLink: country.WikipediaLink,
+		Keywords:      country.Keywords,
+	}
+}
+
+func NewCountryResponses(countries []*alphafoxtrot.Country) []*CountryResponse {
+	responses := make([]*CountryResponse, 0, len(countries))
+	for _, country := range countries {
+		responses = append(responses, NewCountryResponse(country))
+	}
+	return responses
+}
+
+func NewNavaidResponse(navaid *alphafoxtrot.Navaid) *NavaidResponse {
+	return &NavaidResponse{
+		Ident:                navaid.Ident,
+		Name:                 navaid.Name,
+		Type:                 navaid.Type,
+		FrequencyKHZ:         navaid.FrequencyKHZ,
+		LatitudeDeg:          navaid.LatitudeDeg,
+		LongitudeDeg:         navaid.LongitudeDeg,
+		ElevationFt:          navaid.ElevationFt,
+		ISOCountry:           navaid.ISOCountry,
+		DMEFrequencyKHZ:      navaid.DMEFrequencyKHZ,
+		DMEChannel:           navaid.DMEChannel,
+		DMELatitudeDeg:       navaid.DMELatitudeDeg,
+		DMELongitudeDeg:      navaid.DMELongitudeDeg,
+		DMEElevationFt:       navaid.DMEElevationFt,
+		SlavedVariationDeg:   navaid.SlavedVariationDeg,
+		MagneticVariationDeg: navaid.MagneticVariationDeg,
+		UsageType:            navaid.UsageType,
+		Power:                navaid.Power,
+		AssociatedAirport:    navaid.AssociatedAirport,
+		Origin:               navaid.Origin,
+	}
+}
+
+func NewNavaidResponses(navaids []*alphafoxtrot.Navaid) []*NavaidResponse {
+	responses := make([]*NavaidResponse, 0, len(navaids))
+	for _, navaid := range navaids {
+		responses = append(responses, NewNavaidResponse(navaid))
+	}
+	return responses
+}
+
+type WindResponse struct {
+	DirectionDeg    int  `json:"direction_deg"`
+	Variable        bool `json:"variable"`
+	SpeedKt         int  `json:"speed_kt"`
+	GustKt          int  `json:"gust_kt"`
+	VariableFromDeg int  `json:"variable_from_deg"`
+	VariableToDeg   int  `json:"variable_to_deg"`
+}
+
+type CloudResponse struct {
+	Cover  string `json:"cover"`
+	BaseFt int64  `json:"base_ft"`
+	Type   string `json:"type"`
+}
+
+// ConditionsResponse is embedded, so its fields appear in the METAR, TAF group and forecast objects
+type ConditionsResponse struct {
+	Wind             *WindResponse   `json:"wind,omitempty"`
+	VisibilityMeters float64         `json:"visibility_meters"`
+	HasVisibility    bool            `json:"has_visibility"`
+	CAVOK            bool            `json:"cavok"`
+	Weather          []string        `json:"weather"`
+	Clouds           []CloudResponse `json:"clouds"`
+	SkyClear         bool            `json:"sky_clear"`
+}
+
+type RVRResponse struct {
+	Runway    string  `json:"runway"`
+	MinMeters float64 `json:"min_meters"`
+	MaxMeters float64 `json:"max_meters"`
+	Trend     string  `json:"trend"`
+}
+
+type METARResponse struct {
+	Raw       string    `json:"raw"`
+	Station   string    `json:"station"`
+	Time      time.Time `json:"time"`
+	Speci     bool      `json:"speci"`
+	Auto      bool      `json:"auto"`
+	Corrected bool      `json:"corrected"`
+	ConditionsResponse
+	RVR            []RVRResponse `json:"rvr"`
+	TemperatureC   float64       `json:"temperature_c"`
+	DewpointC      float64       `json:"dewpoint_c"`
+	HasTemperature bool          `json:"has_temperature"`
+	HasDewpoint    bool          `json:"has_dewpoint"`
+	AltimeterHPa   float64       `json:"altimeter_hpa"`
+	Trend          string        `json:"trend"`
+	Remarks        string        `json:"remarks"`
+}
+
+type TAFGroupResponse struct {
+	Type        string    `json:"type"`
+	Probability int       `json:"probability"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	ConditionsResponse
+}
+
+type TAFResponse struct {
+	Raw       string             `json:"raw"`
+	Station   string             `json:"station"`
+	IssueTime time.Time          `json:"issue_time"`
+	ValidFrom time.Time          `json:"valid_from"`
+	ValidTo   time.Time          `json:"valid_to"`
+	Amended   bool               `json:"amended"`
+	Corrected bool               `json:"corrected"`
+	Groups    []TAFGroupResponse `json:"groups"`
+	Remarks   string             `json:"remarks"`
+}
+
+type ForecastResponse struct {
+	Station        string    `json:"station"`
+	Time           time.Time `json:"time"`
+	FlightCategory string    `json:"flight_category"`
+	ConditionsResponse
+	Temporary           []TAFGroupResponse `json:"temporary"`
+	WorstFlightCategory string             `json:"worst_flight_category"`
+}
+
+type DiversionResponse struct {
+	Airport              *AirportResponse `json:"airport"`
+	DistanceMeters       float64          `json:"distance_meters"`
+	BearingDeg           float64          `json:"bearing_deg"`
+	TimeEnrouteMinutes   float64          `json:"time_enroute_minutes"`
+	ETA                  time.Time        `json:"eta"`
+	FuelRemainingMinutes float64          `json:"fuel_remaining_minutes"`
+	Runways              []RunwayResponse `json:"runways"`
+	LongestRunwayFt      int64            `json:"longest_runway_ft"`
+	FlightCategory       string           `json:"flight_category"`
+	Score                float64          `json:"score"`
+}
+
+type AirportInRangeResponse struct {
+	Airport        *AirportResponse `json:"airport"`
+	Base           string           `json:"base"`
+	Bases          []string         `json:"bases"`
+	DistanceMeters float64          `json:"distance_meters"`
+	BearingDeg     float64          `json:"bearing_deg"`
+}
+
+type RangeRingResponse struct {
+	Base         *AirportResponse          `json:"base"`
+	RadiusMeters float64                   `json:"radius_meters"`
+	Ring         [][]float64               `json:"ring"`
+	Airports     []*AirportInRangeResponse `json:"airports"`
+}
+
+// CoverageResponse leaves out the rings of the bases, Polygons holds their union
+type CoverageResponse struct {
+	Bases        []*AirportResponse        `json:"bases"`
+	RadiusMeters float64                   `json:"radius_meters"`
+	Polygons     [][][][]float64           `json:"polygons"`
+	Airports     []*AirportInRangeResponse `json:"airports"`
+}
+
+type RouteLegResponse struct {
+	From           *AirportResponse `json:"from"`
+	To             *AirportResponse `json:"to"`
+	DistanceMeters float64          `json:"distance_meters"`
+	BearingDeg     float64          `json:"bearing_deg"`
+}
+
+type RouteResponse struct {
+	Legs           []RouteLegResponse `json:"legs"`
+	DistanceMeters float64            `json:"distance_meters"`
+}
+
+func NewConditionsResponse(conditions *alphafoxtrot.Conditions) ConditionsResponse {
+	response := ConditionsResponse{
+		VisibilityMeters: conditions.VisibilityMeters,
+		HasVisibility:    conditions.HasVisibility,
+		CAVOK:            conditions.CAVOK,
+		Weather:          append(make([]string, 0, len(conditions.Weather)), conditions.Weather...),
+		Clouds:           make([]CloudResponse, 0, len(conditions.Clouds)),
+		SkyClear:         conditions.SkyClear,
+	}
+	if wind := conditions.Wind; wind != nil {
+		response.Wind = &WindResponse{
+			DirectionDeg:    wind.DirectionDeg,
+			Variable:        wind.Variable,
+			SpeedKt:         wind.SpeedKt,
+			GustKt:          wind.GustKt,
+			VariableFromDeg: wind.VariableFromDeg,
+			VariableToDeg:   wind.VariableToDeg,
+		}
+	}
+	for _, cloud := range conditions.Clouds {
+		response.Clouds = append(response.Clouds, CloudResponse{Cover: cloud.Cover, BaseFt: cloud.BaseFt, Type: cloud.Type})
+	}
+	return response
+}
+
+func NewMETARResponse(metar *alphafoxtrot.METAR) *METARResponse {
+	response := &METARResponse{
+		Raw:                metar.Raw,
+		Station:            metar.Station,
+		Time:               metar.Time,
+		Speci:              metar.Speci,
+		Auto:               metar.Auto,
+		Corrected:          metar.Corrected,
+		ConditionsResponse: NewConditionsResponse(&metar.Conditions),
+		RVR:                make([]RVRResponse, 0, len(metar.RVR)),
+		TemperatureC:       metar.TemperatureC,
+		DewpointC:          metar.DewpointC,
+		HasTemperature:     metar.HasTemperature,
+		HasDewpoint:        metar.HasDewpoint,
+		AltimeterHPa:       metar.AltimeterHPa,
+		Trend:              metar.Trend,
+		Remarks:            metar.Remarks,
+	}
+	for _, rvr := range metar.RVR {
+		response.RVR = append(response.RVR, RVRResponse{Runway: rvr.Runway, MinMeters: rvr.MinMeters, MaxMeters: rvr.MaxMeters, Trend: rvr.Trend})
+	}
+	return response
+}
+
+func NewTAFGroupResponses(groups []alphafoxtrot.TAFGroup) []TAFGroupResponse {
+	responses := make([]TAFGroupResponse, 0, len(groups))
+	for i := range groups {
+		responses = append(responses, TAFGroupResponse{
+			Type:               groups[i].Type,
+			Probability:        groups[i].Probability,
+			From:               groups[i].From,
+			To:                 groups[i].To,
+			ConditionsResponse: NewConditionsResponse(&groups[i].Conditions),
+		})
+	}
+	return responses
+}
+
+func NewTAFResponse(taf *alphafoxtrot.TAF) *TAFResponse {
+	return &TAFResponse{
+		Raw:       taf.Raw,
+		Station:   taf.Station,
+		IssueTime: taf.IssueTime,
+		ValidFrom: taf.ValidFrom,
+		ValidTo:   taf.ValidTo,
+		Amended:   taf.Amended,
+		Corrected: taf.Corrected,
+		Groups:    NewTAFGroupResponses(taf.Groups),
+		Remarks:   taf.Remarks,
+	}
+}
+
+func NewForecastResponse(forecast *alphafoxtrot.Forecast) *ForecastResponse {
+	return &ForecastResponse{
+		Station:             forecast.Station,
+		Time:                forecast.Time,
+		FlightCategory:      forecast.FlightCategory,
+		ConditionsResponse:  NewConditionsResponse(&forecast.Conditions),
+		Temporary:           NewTAFGroupResponses(forecast.Temporary),
+		WorstFlightCategory: forecast.WorstFlightCategory,
+	}
+}
+
+func NewDiversionResponses(diversions []*alphafoxtrot.Diversion) []*DiversionResponse {
+	responses := make([]*DiversionResponse, 0, len(diversions))
+	for _, diversion := range diversions {
+		responses = append(responses, &DiversionResponse{
+			Airport:              NewAirportResponse(diversion.Airport),
+			DistanceMeters:       diversion.DistanceMeters,
+			BearingDeg:           diversion.BearingDeg,
+			TimeEnrouteMinutes:   diversion.TimeEnrouteMinutes,
+			ETA:                  diversion.ETA,
+			FuelRemainingMinutes: diversion.FuelRemainingMinutes,
+			Runways:              NewRunwayResponses(diversion.Runways),
+			LongestRunwayFt:      diversion.LongestRunwayFt,
+			FlightCategory:       diversion.FlightCategory,
+			Score:                diversion.Score,
+		})
+	}
+	return responses
+}
+
+func NewAirportInRangeResponses(airports []*alphafoxtrot.AirportInRange) []*AirportInRangeResponse {
+	responses := make([]*AirportInRangeResponse, 0, len(airports))
+	for _, inRange := range airports {
+		responses = append(responses, &AirportInRangeResponse{
+			Airport:        NewAirportResponse(inRange.Airport),
+			Base:           inRange.Base,
+			Bases:          append(make([]string, 0, len(inRange.Bases)), inRange.Bases...),
+			DistanceMeters: inRange.DistanceMeters,
+			BearingDeg:     inRange.BearingDeg,
+		})
+	}
+	return responses
+}
+
+func NewRangeRingResponse(rangeRing *alphafoxtrot.RangeRing) *RangeRingResponse {
+	return &RangeRingResponse{
+		Base:         NewAirportResponse(rangeRing.Base),
+		RadiusMeters: rangeRing.RadiusMeters,
+		Ring:         rangeRing.Ring,
+		Airports:     NewAirportInRangeResponses(rangeRing.Airports),
+	}
+}
+
+func NewCoverageResponse(coverage *alphafoxtrot.Coverage) *CoverageResponse {
+	return &CoverageResponse{
+		Bases:        NewAirportResponses(coverage.Bases),
+		RadiusMeters: coverage.RadiusMeters,
+		Polygons:     coverage.Polygons,
+		Airports:     NewAirportInRangeResponses(coverage.Airports),
+	}
+}
+
+func NewRouteResponse(route *alphafoxtrot.Route) *RouteResponse {
+	response := &RouteResponse{
+		Legs:           make([]RouteLegResponse, 0, len(route.Legs)),
+		DistanceMeters: route.DistanceMeters,
+	}
+	for _, leg := range route.Legs {
+		response.Legs = append(response.Legs, RouteLegResponse{
+			From:           NewAirportResponse(leg.From),
+			To:             NewAirportResponse(leg.To),
+			DistanceMeters: leg.DistanceMeters,
+			BearingDeg:     leg.BearingDeg,
+		})
+	}
+	return response
+}
@@ -0,0 +1,116 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	alphafoxtrot "github.com/grumpypixel/go-airport-finder"
+)
+
+var jsonKeyPattern = regexp.MustCompile(`^[a-z]+(_[a-z]+)*(_degT)?$`)
+
+// checkJSONKeys marshals the value and reports every object key which is not snake_case
+func checkJSONKeys(t *testing.T, name string, value interface{}) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	var walk func(path string, value interface{})
+	walk = func(path string, value interface{}) {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			for key, child := range value {
+				if !jsonKeyPattern.MatchString(key) {
+					t.Errorf("%s: key %s.%s is not snake_case", name, path, key)
+				}
+				walk(path+"."+key, child)
+			}
+		case []interface{}:
+			for _, child := range value {
+				walk(path+"[]", child)
+			}
+		}
+	}
+	walk("", decoded)
+	return decoded
+}
+
+func TestAirportWeatherResponse(t *testing.T) {
+	now := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+	weatherDB := alphafoxtrot.NewWeatherDB()
+	if err := weatherDB.ParseMETARs("../testdata/metar/metars.txt", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := weatherDB.ParseTAFs("../testdata/metar/tafs.txt", now); err != nil {
+		t.Fatal(err)
+	}
+	finder := loadTestFinder(t)
+	finder.SetWeatherDB(weatherDB)
+	server := httptest.NewServer(NewHandler(finder))
+	defer server.Close()
+
+	var airport map[string]interface{}
+	get(t, server, "/airports/icao/KLAX", http.StatusOK, &airport)
+	airport = checkJSONKeys(t, "KLAX", airport)
+	metar, ok := airport["metar"].(map[string]interface{})
+	if !ok || metar["station"] != "KLAX" || airport["flight_category"] != alphafoxtrot.FlightCategoryVFR {
+		t.Fatalf("unexpected weather %v %v", airport["metar"], airport["flight_category"])
+	}
+	// the conditions are flattened into the METAR
+	if wind, ok := metar["wind"].(map[string]interface{}); !ok || wind["speed_kt"] != 12.0 || wind["gust_kt"] != 18.0 {
+		t.Errorf("unexpected wind %v", metar["wind"])
+	}
+	if clouds, ok := metar["clouds"].([]interface{}); !ok || len(clouds) != 2 {
+		t.Errorf("unexpected clouds %v", metar["clouds"])
+	}
+	if taf, ok := airport["taf"].(map[string]interface{}); !ok || taf["station"] != "KLAX" {
+		t.Errorf("unexpected TAF %v", airport["taf"])
+	}
+
+	var ksmo AirportResponse
+	get(t, server, "/airports/icao/KSMO", http.StatusOK, &ksmo)
+	if ksmo.METAR == nil || len(ksmo.METAR.RVR) != 1 || ksmo.METAR.RVR[0].Runway != "21" || ksmo.TAF != nil {
+		t.Errorf("unexpected KSMO weather %+v %+v", ksmo.METAR, ksmo.TAF)
+	}
+}
+
+func TestResultResponses(t *testing.T) {
+	finder := loadTestFinder(t)
+	diversions, err := finder.FindDiversions(&alphafoxtrot.DiversionRequest{
+		LatitudeDeg: 33.9, LongitudeDeg: -118.3, GroundSpeedKt: 120, EnduranceMinutes: 60,
+	})
+	if err != nil || len(diversions) == 0 {
+		t.Fatalf("got %d diversions: %v", len(diversions), err)
+	}
+	checkJSONKeys(t, "diversion", NewDiversionResponses(diversions)[0])
+
+	rangeRing := finder.FindRangeRing("KLAX", 50000, 0, alphafoxtrot.AirportTypeAll)
+	checkJSONKeys(t, "range ring", NewRangeRingResponse(rangeRing))
+	coverage, err := finder.FindCoverage([]string{"KLAX", "KLGB"}, 30000, 0, alphafoxtrot.AirportTypeAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := checkJSONKeys(t, "coverage", NewCoverageResponse(coverage))
+	if _, ok := decoded["rings"]; ok {
+		t.Error("coverage lists the rings")
+	}
+
+	route, err := finder.FindRoute(&alphafoxtrot.RouteRequest{Origin: "KSMO", Destination: "KLGB", MaxLegMeters: 100000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := NewRouteResponse(route)
+	checkJSONKeys(t, "route", response)
+	if len(response.Legs) != 1 || response.Legs[0].From.ICAOCode != "KSMO" || response.Legs[0].To.ICAOCode != "KLGB" {
+		t.Errorf("unexpected route %+v", response)
+	}
+}
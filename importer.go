@@ -0,0 +1,98 @@
+package alphafoxtrot
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// Importer reads a vendor data file (e.g. FAA NASR or ARINC 424) into the databases.
+// Imported records get IDs following the highest ID already in the databases, so several files can be imported into the same databases.
+type Importer interface {
+	Import(r io.Reader, databases *Databases, airportTypeFilter uint64) error
+}
+
+func NewDatabases() *Databases {
+	return &Databases{
+		Airports:    NewAirportDB(),
+		Frequencies: NewFrequencyDB(),
+		Runways:     NewRunwayDB(),
+		Regions:     NewRegionDB(),
+		Countries:   NewCountryDB(),
+		Navaids:     NewNavaidDB(),
+	}
+}
+
+func ImportFile(importer Importer, file string, databases *Databases, airportTypeFilter uint64) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return importer.Import(f, databases, airportTypeFilter)
+}
+
+// importIDs hands out the IDs of imported records
+type importIDs struct {
+	airport, runway, navaid uint64
+}
+
+func newImportIDs(databases *Databases) *importIDs {
+	ids := &importIDs{}
+	for _, airport := range databases.Airports.Airports {
+		if airport.ID > ids.airport {
+			ids.airport = airport.ID
+		}
+	}
+	for _, runways := range databases.Runways.Runways {
+		for _, runway := range runways {
+			if runway.ID > ids.runway {
+				ids.runway = runway.ID
+			}
+		}
+	}
+	for _, navaid := range databases.Navaids.Navaids {
+		if navaid.ID > ids.navaid {
+			ids.navaid = navaid.ID
+		}
+	}
+	return ids
+}
+
+func (ids *importIDs) nextAirport() uint64 {
+	ids.airport++
+	return ids.airport
+}
+
+func (ids *importIDs) nextRunway() uint64 {
+	ids.runway++
+	return ids.runway
+}
+
+func (ids *importIDs) nextNavaid() uint64 {
+	ids.navaid++
+	return ids.navaid
+}
+
+// fixedField returns the trimmed field at the 1-based start column with the given length, as used by layout specifications
+func fixedField(line string, start, length int) string {
+	if start-1 >= len(line) {
+		return ""
+	}
+	end := start - 1 + length
+	if end > len(line) {
+		end = len(line)
+	}
+	return strings.TrimSpace(line[start-1 : end])
+}
+
+// airportTypeFromLongestRunway is used for sources without airport sizes
+func airportTypeFromLongestRunway(lengthFt int64) string {
+	switch {
+	case lengthFt >= 10000:
+		return AirportTypeLargeName
+	case lengthFt >= 5000:
+		return AirportTypeMediumName
+	}
+	return AirportTypeSmallName
+}
@@ -0,0 +1,253 @@
+package alphafoxtrot
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// see https://developers.google.com/kml/documentation/kmlreference
+
+const kmlIconBaseURL = "http://maps.google.com/mapfiles/kml/"
+
+type kmlStyle struct {
+	id    string
+	icon  string
+	color string // aabbggrr
+	scale float64
+}
+
+var kmlAirportStyles = map[string]kmlStyle{
+	AirportTypeLargeName:        {"airport-large", "shapes/airports.png", "ff0000ff", 1.3},
+	AirportTypeMediumName:       {"airport-medium", "shapes/airports.png", "ff00a5ff", 1.1},
+	AirportTypeSmallName:        {"airport-small", "shapes/airports.png", "ff00ffff", 0.9},
+	AirportTypeHeliportName:     {"airport-heliport", "paddle/H.png", "ffffffff", 0.9},
+	AirportTypeSeaplaneBaseName: {"airport-seaplane-base", "shapes/sailing.png", "ffffff00", 0.9},
+	AirportTypeClosedName:       {"airport-closed", "shapes/forbidden.png", "ff808080", 0.8},
+	AirportTypeUnknownName:      {"airport-unknown", "shapes/placemark_circle.png", "ffffffff", 0.8},
+}
+
+var kmlNavaidStyles = map[string]kmlStyle{
+	"VOR":     {"navaid-vor", "shapes/polygon.png", "ffff0000", 0.9},
+	"VOR-DME": {"navaid-vor-dme", "shapes/triangle.png", "ffff0000", 0.9},
+	"VORTAC":  {"navaid-vortac", "shapes/triangle.png", "ffff00ff", 0.9},
+	"TACAN":   {"navaid-tacan", "shapes/star.png", "ffff00ff", 0.9},
+	"DME":     {"navaid-dme", "shapes/placemark_square.png", "ffff0000", 0.8},
+	"NDB":     {"navaid-ndb", "shapes/target.png", "ff800080", 0.9},
+	"NDB-DME": {"navaid-ndb-dme", "shapes/target.png", "ffff0080", 0.9},
+	"":        {"navaid-other", "shapes/placemark_circle.png", "ffc0c0c0", 0.8},
+}
+
+const kmlRunwayStyleID = "runway"
+
+type KMLOptions struct {
+	Name           string // document name
+	IncludeRunways bool
+}
+
+// WriteKML writes the airports, foldered by country and region, and the navaids, foldered by country, as KML
+func WriteKML(w io.Writer, airports []*Airport, navaids []*Navaid, options *KMLOptions) error {
+	if options == nil {
+		options = &KMLOptions{}
+	}
+	bw := bufio.NewWriter(w)
+	kw := &kmlWriter{w: bw}
+
+	kw.printf("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	kw.printf("<kml xmlns=\"http://www.opengis.net/kml/2.2\">\n<Document>\n")
+	kw.printf("<name>%s</name>\n", kmlEscape(options.Name))
+	kw.writeStyles()
+
+	countryNames := make(map[string]string)
+	if len(airports) > 0 {
+		kw.printf("<Folder><name>Airports</name>\n")
+		byCountry := make(map[string]map[string][]*Airport)
+		regionNames := make(map[string]string)
+		for _, airport := range airports {
+			countryCode, regionCode := airport.Country.ISOCode, airport.Region.ISOCode
+			countryNames[countryCode] = kmlFolderName(airport.Country.Name, countryCode)
+			regionNames[regionCode] = kmlFolderName(airport.Region.Name, regionCode)
+			if byCountry[countryCode] == nil {
+				byCountry[countryCode] = make(map[string][]*Airport)
+			}
+			byCountry[countryCode][regionCode] = append(byCountry[countryCode][regionCode], airport)
+		}
+		countryCodes := make([]string, 0, len(byCountry))
+		for countryCode := range byCountry {
+			countryCodes = append(countryCodes, countryCode)
+		}
+		sort.Strings(countryCodes)
+		for _, countryCode := range countryCodes {
+			kw.printf("<Folder><name>%s</name>\n", kmlEscape(countryNames[countryCode]))
+			byRegion := byCountry[countryCode]
+			regionCodes := make([]string, 0, len(byRegion))
+			for regionCode := range byRegion {
+				regionCodes = append(regionCodes, regionCode)
+			}
+			sort.Strings(regionCodes)
+			for _, regionCode := range regionCodes {
+				kw.printf("<Folder><name>%s</name>\n", kmlEscape(regionNames[regionCode]))
+				for _, airport := range byRegion[regionCode] {
+					kw.writeAirport(airport, options.IncludeRunways)
+				}
+				kw.printf("</Folder>\n")
+			}
+			kw.printf("</Folder>\n")
+		}
+		kw.printf("</Folder>\n")
+	}
+
+	if len(navaids) > 0 {
+		kw.printf("<Folder><name>Navaids</name>\n")
+		byCountry := make(map[string][]*Navaid)
+		for _, navaid := range navaids {
+			byCountry[navaid.ISOCountry] = append(byCountry[navaid.ISOCountry], navaid)
+		}
+		countryCodes := make([]string, 0, len(byCountry))
+		for countryCode := range byCountry {
+			countryCodes = append(countryCodes, countryCode)
+		}
+		sort.Strings(countryCodes)
+		for _, countryCode := range countryCodes {
+			name, ok := countryNames[countryCode]
+			if !ok {
+				name = kmlFolderName("", countryCode)
+			}
+			kw.printf("<Folder><name>%s</name>\n", kmlEscape(name))
+			for _, navaid := range byCountry[countryCode] {
+				kw.writeNavaid(navaid)
+			}
+			kw.printf("</Folder>\n")
+		}
+		kw.printf("</Folder>\n")
+	}
+
+	kw.printf("</Document>\n</kml>\n")
+	if kw.err != nil {
+		return kw.err
+	}
+	return bw.Flush()
+}
+
+// WriteKMZ writes the KML zipped as KMZ
+func WriteKMZ(w io.Writer, airports []*Airport, navaids []*Navaid, options *KMLOptions) error {
+	zw := zip.NewWriter(w)
+	f, err := zw.Create("doc.kml")
+	if err == nil {
+		err = WriteKML(f, airports, navaids, options)
+	}
+	if err != nil {
+		// the zip writer is closed either way, its error is secondary
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+type kmlWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (kw *kmlWriter) printf(format string, args ...interface{}) {
+	if kw.err != nil {
+		return
+	}
+	_, kw.err = fmt.Fprintf(kw.w, format, args...)
+}
+
+func (kw *kmlWriter) writeStyles() {
+	styles := make([]kmlStyle, 0, len(kmlAirportStyles)+len(kmlNavaidStyles))
+	for _, style := range kmlAirportStyles {
+		styles = append(styles, style)
+	}
+	for _, style := range kmlNavaidStyles {
+		styles = append(styles, style)
+	}
+	sort.Slice(styles, func(i, j int) bool {
+		return styles[i].id < styles[j].id
+	})
+	for _, style := range styles {
+		kw.printf("<Style id=\"%s\"><IconStyle><color>%s</color><scale>%.1f</scale><Icon><href>%s%s</href></Icon></IconStyle></Style>\n",
+			style.id, style.color, style.scale, kmlIconBaseURL, style.icon)
+	}
+	kw.printf("<Style id=\"%s\"><LineStyle><color>ff404040</color><width>4</width></LineStyle></Style>\n", kmlRunwayStyleID)
+}
+
+func (kw *kmlWriter) writeAirport(airport *Airport, includeRunways bool) {
+	style, ok := kmlAirportStyles[airport.Type]
+	if !ok {
+		style = kmlAirportStyles[AirportTypeUnknownName]
+	}
+	kw.printf("<Placemark><name>%s</name><styleUrl>#%s</styleUrl>\n", kmlEscape(airport.ICAOCode), style.id)
+	kw.printf("<description><![CDATA[%s]]></description>\n", kmlAirportBalloon(airport))
+	kw.printf("<Point><coordinates>%f,%f,%f</coordinates></Point></Placemark>\n",
+		airport.LongitudeDeg, airport.LatitudeDeg, FeetToMeters(float64(airport.ElevationFt)))
+
+	if !includeRunways {
+		return
+	}
+	for _, runway := range airport.Runways {
+		if (runway.LowEndLatitudeDeg == 0 && runway.LowEndLongitudeDeg == 0) || (runway.HighEndLatitudeDeg == 0 && runway.HighEndLongitudeDeg == 0) {
+			continue
+		}
+		kw.printf("<Placemark><name>%s %s/%s</name><styleUrl>#%s</styleUrl>\n", kmlEscape(airport.ICAOCode), kmlEscape(runway.LowEndIdent), kmlEscape(runway.HighEndIdent), kmlRunwayStyleID)
+		kw.printf("<description>%d x %d ft, %s</description>\n", runway.LengthFt, runway.WidthFt, kmlEscape(runway.Surface))
+		kw.printf("<LineString><tessellate>1</tessellate><coordinates>%f,%f %f,%f</coordinates></LineString></Placemark>\n",
+			runway.LowEndLongitudeDeg, runway.LowEndLatitudeDeg, runway.HighEndLongitudeDeg, runway.HighEndLatitudeDeg)
+	}
+}
+
+func (kw *kmlWriter) writeNavaid(navaid *Navaid) {
+	style, ok := kmlNavaidStyles[navaid.Type]
+	if !ok {
+		style = kmlNavaidStyles[""]
+	}
+	kw.printf("<Placemark><name>%s</name><styleUrl>#%s</styleUrl>\n", kmlEscape(navaid.Ident), style.id)
+	kw.printf("<description>%s</description>\n", kmlEscape(fmt.Sprintf("%s %s %d kHz", navaid.Name, navaid.Type, navaid.FrequencyKHZ)))
+	kw.printf("<Point><coordinates>%f,%f,%f</coordinates></Point></Placemark>\n",
+		navaid.LongitudeDeg, navaid.LatitudeDeg, FeetToMeters(float64(navaid.ElevationFt)))
+}
+
+func kmlAirportBalloon(airport *Airport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<h3>%s</h3>", kmlEscape(airport.Name))
+	fmt.Fprintf(&b, "<p>%s %s<br/>%s<br/>Elevation: %d ft</p>", kmlEscape(airport.ICAOCode), kmlEscape(airport.IATACode), kmlEscape(airport.Type), airport.ElevationFt)
+	if len(airport.Frequencies) > 0 {
+		b.WriteString("<table border=\"1\"><tr><th>Type</th><th>MHz</th><th>Description</th></tr>")
+		for _, frequency := range airport.Frequencies {
+			fmt.Fprintf(&b, "<tr><td>%s</td><td>%.3f</td><td>%s</td></tr>", kmlEscape(frequency.Type), frequency.FrequencyMHZ, kmlEscape(frequency.Description))
+		}
+		b.WriteString("</table>")
+	}
+	if len(airport.Runways) > 0 {
+		b.WriteString("<table border=\"1\"><tr><th>Runway</th><th>Length ft</th><th>Width ft</th><th>Surface</th></tr>")
+		for _, runway := range airport.Runways {
+			fmt.Fprintf(&b, "<tr><td>%s/%s</td><td>%d</td><td>%d</td><td>%s</td></tr>", kmlEscape(runway.LowEndIdent), kmlEscape(runway.HighEndIdent), runway.LengthFt, runway.WidthFt, kmlEscape(runway.Surface))
+		}
+		b.WriteString("</table>")
+	}
+	// a CDATA section must not contain its own terminator
+	return strings.ReplaceAll(b.String(), "]]>", "]]&gt;")
+}
+
+func kmlFolderName(name, code string) string {
+	if name == "" {
+		if code == "" {
+			return "Unknown"
+		}
+		return code
+	}
+	return name
+}
+
+func kmlEscape(str string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(str))
+	return buf.String()
+}
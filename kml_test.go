@@ -0,0 +1,157 @@
+package alphafoxtrot
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+type kmlTestPlacemark struct {
+	Name     string `xml:"name"`
+	StyleURL string `xml:"styleUrl"`
+}
+
+type kmlTestFolder struct {
+	Name       string             `xml:"name"`
+	Folders    []kmlTestFolder    `xml:"Folder"`
+	Placemarks []kmlTestPlacemark `xml:"Placemark"`
+}
+
+type kmlTestDocument struct {
+	Document struct {
+		Name   string `xml:"name"`
+		Styles []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"Style"`
+		Folders []kmlTestFolder `xml:"Folder"`
+	} `xml:"Document"`
+}
+
+func parseTestKML(t *testing.T, data []byte) *kmlTestDocument {
+	t.Helper()
+	doc := &kmlTestDocument{}
+	if err := xml.Unmarshal(data, doc); err != nil {
+		t.Fatalf("invalid KML: %v", err)
+	}
+	return doc
+}
+
+func kmlFolderNames(folders []kmlTestFolder) string {
+	names := make([]string, 0, len(folders))
+	for _, folder := range folders {
+		names = append(names, folder.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func writeTestKML(t *testing.T, options *KMLOptions) ([]*Airport, []*Navaid, []byte) {
+	t.Helper()
+	finder := loadTestFinder(t, AirportTypeAll)
+	airports := []*Airport{finder.FindAirportByICAOCode("KSMO"), finder.FindAirportByICAOCode("EDDF"), finder.FindAirportByICAOCode("KLAX")}
+	navaids := finder.FindNavaidsByAirportICAOCode("KLAX")
+	var buf bytes.Buffer
+	if err := WriteKML(&buf, airports, navaids, options); err != nil {
+		t.Fatal(err)
+	}
+	return airports, navaids, buf.Bytes()
+}
+
+func TestWriteKML(t *testing.T) {
+	_, _, data := writeTestKML(t, &KMLOptions{Name: "Test & Co", IncludeRunways: true})
+	doc := parseTestKML(t, data)
+	if doc.Document.Name != "Test & Co" {
+		t.Errorf("got name %q", doc.Document.Name)
+	}
+
+	styles := make(map[string]bool)
+	for _, style := range doc.Document.Styles {
+		if styles[style.ID] {
+			t.Errorf("duplicate style %s", style.ID)
+		}
+		styles[style.ID] = true
+	}
+	if len(styles) != len(kmlAirportStyles)+len(kmlNavaidStyles)+1 {
+		t.Errorf("got %d styles", len(styles))
+	}
+
+	if names := kmlFolderNames(doc.Document.Folders); names != "Airports,Navaids" {
+		t.Fatalf("got folders %s", names)
+	}
+	airports, navaids := doc.Document.Folders[0], doc.Document.Folders[1]
+	if names := kmlFolderNames(airports.Folders); names != "Germany,United States" {
+		t.Errorf("got country folders %s", names)
+	}
+	var california *kmlTestFolder
+	for i, country := range airports.Folders {
+		if len(country.Folders) != 1 {
+			t.Fatalf("%s: got region folders %s", country.Name, kmlFolderNames(country.Folders))
+		}
+		if country.Name == "United States" {
+			california = &airports.Folders[i].Folders[0]
+		}
+	}
+	if california == nil || california.Name != "California" {
+		t.Fatalf("no California folder")
+	}
+	placemarks := make([]string, 0)
+	for _, placemark := range california.Placemarks {
+		placemarks = append(placemarks, placemark.Name)
+		if !styles[strings.TrimPrefix(placemark.StyleURL, "#")] {
+			t.Errorf("%s: unknown style %s", placemark.Name, placemark.StyleURL)
+		}
+	}
+	if got := strings.Join(placemarks, ","); got != "KSMO,KSMO 03/21,KLAX,KLAX 07L/25R,KLAX 07R/25L" {
+		t.Errorf("got placemarks %s", got)
+	}
+	if len(navaids.Folders) != 1 || navaids.Folders[0].Name != "United States" || len(navaids.Folders[0].Placemarks) != 1 ||
+		navaids.Folders[0].Placemarks[0].Name != "LAX" || !styles[strings.TrimPrefix(navaids.Folders[0].Placemarks[0].StyleURL, "#")] {
+		t.Errorf("unexpected navaid folders %+v", navaids.Folders)
+	}
+
+	_, _, data = writeTestKML(t, nil)
+	if strings.Contains(string(data), "<LineString>") {
+		t.Error("runways are written without IncludeRunways")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriteKMZ(t *testing.T) {
+	airports, navaids, want := writeTestKML(t, &KMLOptions{IncludeRunways: true})
+	var buf bytes.Buffer
+	if err := WriteKMZ(&buf, airports, navaids, &KMLOptions{IncludeRunways: true}); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != "doc.kml" {
+		t.Fatalf("unexpected KMZ files %v", zr.File)
+	}
+	f, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Error("doc.kml differs from the KML")
+	}
+	parseTestKML(t, data)
+
+	if err := WriteKMZ(failingWriter{}, airports, navaids, nil); err == nil {
+		t.Error("expected an error from the writer")
+	}
+}
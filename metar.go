@@ -0,0 +1,166 @@
+package alphafoxtrot
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// e.g. METAR KSMO 011751Z 25012G18KT 220V280 10SM FEW015 BKN250 21/12 A2992 RMK AO2 SLP131
+
+var (
+	rvrPattern         = regexp.MustCompile(`^R(\d{2}[LCR]?)/([PM]?)(\d{4})(?:V([PM]?)(\d{4}))?(FT)?/?([UDN])?$`)
+	temperaturePattern = regexp.MustCompile(`^(M?\d{2})/(M?\d{2})?$`)
+	altimeterPattern   = regexp.MustCompile(`^([AQ])(\d{4})$`)
+	stationPattern     = regexp.MustCompile(`^[A-Z][A-Z0-9]{3}$`)
+)
+
+// RVR is the runway visual range, MaxMeters is set for variable ranges
+type RVR struct {
+	Runway    string
+	MinMeters float64
+	MaxMeters float64
+	Trend     string
+}
+
+type METAR struct {
+	Raw       string
+	Station   string
+	Time      time.Time
+	Speci     bool
+	Auto      bool
+	Corrected bool
+	Conditions
+	RVR            []RVR
+	TemperatureC   float64
+	DewpointC      float64
+	HasTemperature bool
+	HasDewpoint    bool
+	AltimeterHPa   float64
+	Trend          string
+	Remarks        string
+}
+
+// AltimeterInHg returns the altimeter setting in inches of mercury, 0 if none was reported
+func (m *METAR) AltimeterInHg() float64 {
+	return m.AltimeterHPa / 33.8639
+}
+
+// ParseMETAR decodes a METAR or SPECI, the day of month in the report is resolved relative to now
+func ParseMETAR(report string, now time.Time) (*METAR, error) {
+	tokens := reportTokens(report)
+	metar := &METAR{Raw: strings.Join(tokens, " ")}
+	if len(tokens) > 0 && (tokens[0] == "METAR" || tokens[0] == "SPECI") {
+		metar.Speci = tokens[0] == "SPECI"
+		tokens = tokens[1:]
+	}
+	if len(tokens) > 0 && tokens[0] == "COR" {
+		metar.Corrected = true
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 || !stationPattern.MatchString(tokens[0]) {
+		return nil, fmt.Errorf("metar: missing station in %q", report)
+	}
+	metar.Station = tokens[0]
+	tokens = tokens[1:]
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("metar: missing time in %q", report)
+	}
+	if tokens[0] == "NIL" {
+		return nil, fmt.Errorf("metar: missing report for %s", metar.Station)
+	}
+	match := reportTimePattern.FindStringSubmatch(tokens[0])
+	if match == nil {
+		return nil, fmt.Errorf("metar: invalid time %q", tokens[0])
+	}
+	day, _ := strconv.Atoi(match[1])
+	hour, _ := strconv.Atoi(match[2])
+	minute, _ := strconv.Atoi(match[3])
+	metar.Time = parseReportTime(day, hour, minute, now)
+	tokens = tokens[1:]
+	// e.g. KXYZ 011755Z NIL
+	if len(tokens) > 0 && tokens[0] == "NIL" {
+		return nil, fmt.Errorf("metar: missing report for %s", metar.Station)
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		switch token {
+		case "AUTO":
+			metar.Auto = true
+			continue
+		case "COR":
+			metar.Corrected = true
+			continue
+		case "RMK":
+			metar.Remarks = strings.Join(tokens[i+1:], " ")
+			return metar, nil
+		case "NOSIG", "BECMG", "TEMPO":
+			end := len(tokens)
+			for j := i; j < len(tokens); j++ {
+				if tokens[j] == "RMK" {
+					end = j
+					break
+				}
+			}
+			metar.Trend = strings.Join(tokens[i:end], " ")
+			i = end - 1
+			continue
+		}
+		if n := metar.Conditions.parseToken(tokens, i); n > 0 {
+			i += n - 1
+			continue
+		}
+		if match := rvrPattern.FindStringSubmatch(token); match != nil {
+			metar.RVR = append(metar.RVR, newRVR(match))
+			continue
+		}
+		if match := temperaturePattern.FindStringSubmatch(token); match != nil {
+			metar.TemperatureC, metar.HasTemperature = parseTemperature(match[1])
+			metar.DewpointC, metar.HasDewpoint = parseTemperature(match[2])
+			continue
+		}
+		if match := altimeterPattern.FindStringSubmatch(token); match != nil {
+			value, _ := strconv.ParseFloat(match[2], 64)
+			if match[1] == "A" {
+				metar.AltimeterHPa = value / 100 * 33.8639
+			} else {
+				metar.AltimeterHPa = value
+			}
+		}
+		// unknown groups, e.g. recent weather or wind shear, are skipped
+	}
+	return metar, nil
+}
+
+func newRVR(match []string) RVR {
+	toMeters := func(value string) float64 {
+		meters, _ := strconv.ParseFloat(value, 64)
+		if match[6] == "FT" {
+			meters = FeetToMeters(meters)
+		}
+		return meters
+	}
+	rvr := RVR{Runway: match[1], MinMeters: toMeters(match[3]), Trend: match[7]}
+	if match[5] != "" {
+		rvr.MaxMeters = toMeters(match[5])
+	}
+	return rvr
+}
+
+func parseTemperature(str string) (float64, bool) {
+	if str == "" {
+		return 0, false
+	}
+	negative := strings.HasPrefix(str, "M")
+	value, err := strconv.ParseFloat(strings.TrimPrefix(str, "M"), 64)
+	if err != nil {
+		return 0, false
+	}
+	if negative {
+		value = -value
+	}
+	return value, true
+}
@@ -0,0 +1,248 @@
+package alphafoxtrot
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var testWeatherTime = time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+
+func TestParseMETAR(t *testing.T) {
+	metar, err := ParseMETAR("METAR KLAX 011753Z 25012G18KT 220V280 10SM FEW015 BKN250 21/12 A2992 RMK AO2 SLP131", testWeatherTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metar.Station != "KLAX" || !metar.Time.Equal(time.Date(2024, 5, 1, 17, 53, 0, 0, time.UTC)) || metar.Speci || metar.Auto {
+		t.Errorf("unexpected header %+v", metar)
+	}
+	wind := Wind{DirectionDeg: 250, SpeedKt: 12, GustKt: 18, VariableFromDeg: 220, VariableToDeg: 280}
+	if metar.Wind == nil || *metar.Wind != wind {
+		t.Errorf("got wind %+v", metar.Wind)
+	}
+	if !metar.HasVisibility || math.Abs(metar.VisibilityStatuteMiles()-10) > 1e-9 {
+		t.Errorf("got visibility %f SM", metar.VisibilityStatuteMiles())
+	}
+	if len(metar.Clouds) != 2 || metar.Clouds[0] != (Cloud{Cover: CloudCoverFew, BaseFt: 1500}) || metar.Clouds[1] != (Cloud{Cover: CloudCoverBroken, BaseFt: 25000}) {
+		t.Errorf("got clouds %+v", metar.Clouds)
+	}
+	if metar.TemperatureC != 21 || metar.DewpointC != 12 || !metar.HasTemperature || !metar.HasDewpoint {
+		t.Errorf("got %f/%f", metar.TemperatureC, metar.DewpointC)
+	}
+	if math.Abs(metar.AltimeterInHg()-29.92) > 1e-9 || metar.Remarks != "AO2 SLP131" {
+		t.Errorf("got altimeter %f, remarks %q", metar.AltimeterInHg(), metar.Remarks)
+	}
+}
+
+func TestParseMETARGroups(t *testing.T) {
+	tests := []struct {
+		report       string
+		visibilitySM float64
+		visibilityM  float64 // checked instead of visibilitySM if set
+		rvr          []RVR
+		weather      []string
+		temperatureC float64
+		hasDewpoint  bool
+		altimeterHPa float64
+		trend        string
+		auto, speci  bool
+		category     string
+		windSpeedKt  int
+		windVariable bool
+	}{
+		{
+			report:       "KSMO 011751Z AUTO VRB03KT 1 1/2SM R21/2400V4000FT/U -RA BR OVC008 M01/M03 A2990 RMK AO2",
+			visibilitySM: 1.5,
+			rvr:          []RVR{{Runway: "21", MinMeters: FeetToMeters(2400), MaxMeters: FeetToMeters(4000), Trend: "U"}},
+			weather:      []string{"-RA", "BR"},
+			temperatureC: -1, hasDewpoint: true, altimeterHPa: 29.90 * 33.8639,
+			auto: true, category: FlightCategoryIFR, windSpeedKt: 3, windVariable: true,
+		},
+		{
+			report:       "SPECI KSFO 011756Z 28015KT 1/4SM R28L/P6000FT R28R/M0600FT/D FG VV002 16/ A3001",
+			visibilitySM: 0.25,
+			rvr: []RVR{
+				{Runway: "28L", MinMeters: FeetToMeters(6000)},
+				{Runway: "28R", MinMeters: FeetToMeters(600), Trend: "D"},
+			},
+			weather:      []string{"FG"},
+			temperatureC: 16, altimeterHPa: 30.01 * 33.8639,
+			speci: true, category: FlightCategoryLIFR, windSpeedKt: 15,
+		},
+		{
+			report:       "EDDF 011750Z 27008MPS 9999 SCT030 BKN045 12/06 Q1015 NOSIG=",
+			visibilityM:  10000,
+			temperatureC: 12, hasDewpoint: true, altimeterHPa: 1015, trend: "NOSIG",
+			category: FlightCategoryVFR, windSpeedKt: 16,
+		},
+		{
+			report:      "LFPG 011730Z 36005KT 0800 R27L/0550N R26R/1000V1500U FZFG BECMG 2000 BR",
+			visibilityM: 800,
+			rvr:         []RVR{{Runway: "27L", MinMeters: 550, Trend: "N"}, {Runway: "26R", MinMeters: 1000, MaxMeters: 1500, Trend: "U"}},
+			weather:     []string{"FZFG"},
+			trend:       "BECMG 2000 BR",
+			category:    FlightCategoryLIFR, windSpeedKt: 5,
+		},
+		{
+			report:       "KXYZ 011755Z 00000KT M1/4SM FG VV001 05/05 A3000",
+			visibilitySM: 0.25,
+			weather:      []string{"FG"},
+			temperatureC: 5, hasDewpoint: true, altimeterHPa: 30.00 * 33.8639,
+			category: FlightCategoryLIFR,
+		},
+		{
+			report:       "KXYZ 011755Z 18010KT P6SM SKC 25/10 A3000",
+			visibilitySM: 6,
+			temperatureC: 25, hasDewpoint: true, altimeterHPa: 30.00 * 33.8639,
+			category: FlightCategoryVFR, windSpeedKt: 10,
+		},
+	}
+	for _, test := range tests {
+		metar, err := ParseMETAR(test.report, testWeatherTime)
+		if err != nil {
+			t.Errorf("%s: %v", test.report, err)
+			continue
+		}
+		if test.visibilityM > 0 {
+			if metar.VisibilityMeters != test.visibilityM {
+				t.Errorf("%s: got visibility %f m", metar.Station, metar.VisibilityMeters)
+			}
+		} else if math.Abs(metar.VisibilityStatuteMiles()-test.visibilitySM) > 1e-9 || !metar.HasVisibility {
+			t.Errorf("%s: got visibility %f SM", metar.Station, metar.VisibilityStatuteMiles())
+		}
+		if len(metar.RVR) != len(test.rvr) {
+			t.Errorf("%s: got RVR %+v", metar.Station, metar.RVR)
+		} else {
+			for i, rvr := range metar.RVR {
+				want := test.rvr[i]
+				if rvr.Runway != want.Runway || math.Abs(rvr.MinMeters-want.MinMeters) > 1e-9 || math.Abs(rvr.MaxMeters-want.MaxMeters) > 1e-9 || rvr.Trend != want.Trend {
+					t.Errorf("%s: got RVR %+v, want %+v", metar.Station, rvr, want)
+				}
+			}
+		}
+		if len(metar.Weather) != len(test.weather) {
+			t.Errorf("%s: got weather %v", metar.Station, metar.Weather)
+		} else {
+			for i := range metar.Weather {
+				if metar.Weather[i] != test.weather[i] {
+					t.Errorf("%s: got weather %v", metar.Station, metar.Weather)
+				}
+			}
+		}
+		if metar.TemperatureC != test.temperatureC || metar.HasDewpoint != test.hasDewpoint || math.Abs(metar.AltimeterHPa-test.altimeterHPa) > 1e-9 {
+			t.Errorf("%s: got %f °C, dewpoint %v, %f hPa", metar.Station, metar.TemperatureC, metar.HasDewpoint, metar.AltimeterHPa)
+		}
+		if metar.Trend != test.trend || metar.Auto != test.auto || metar.Speci != test.speci {
+			t.Errorf("%s: got trend %q, auto %v, speci %v", metar.Station, metar.Trend, metar.Auto, metar.Speci)
+		}
+		if category := metar.FlightCategory(); category != test.category {
+			t.Errorf("%s: got %s, want %s", metar.Station, category, test.category)
+		}
+		if metar.Wind == nil || metar.Wind.SpeedKt != test.windSpeedKt || metar.Wind.Variable != test.windVariable {
+			t.Errorf("%s: got wind %+v", metar.Station, metar.Wind)
+		}
+	}
+}
+
+func TestParseMETARErrors(t *testing.T) {
+	for _, report := range []string{"", "METAR", "KXYZ NIL", "KXYZ 011755Z NIL", "KXYZ", "KXYZ 0117Z 18010KT", "X 011755Z 18010KT"} {
+		if metar, err := ParseMETAR(report, testWeatherTime); err == nil {
+			t.Errorf("%q: got %+v", report, metar)
+		}
+	}
+}
+
+func TestParseMETARTime(t *testing.T) {
+	tests := []struct {
+		report string
+		time   time.Time
+	}{
+		{"KLAX 011753Z 25012KT 10SM CLR", time.Date(2024, 5, 1, 17, 53, 0, 0, time.UTC)},
+		{"KLAX 302353Z 25012KT 10SM CLR", time.Date(2024, 4, 30, 23, 53, 0, 0, time.UTC)},
+		{"KLAX 020030Z 25012KT 10SM CLR", time.Date(2024, 5, 2, 0, 30, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		metar, err := ParseMETAR(test.report, testWeatherTime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !metar.Time.Equal(test.time) {
+			t.Errorf("%s: got %v, want %v", test.report, metar.Time, test.time)
+		}
+	}
+}
+
+func TestReadMETARs(t *testing.T) {
+	db := NewWeatherDB()
+	if err := db.ParseMETARs("testdata/metar/metars.txt", testWeatherTime); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		icao     string
+		category string
+	}{
+		{"KLAX", FlightCategoryVFR},
+		{"KSMO", FlightCategoryIFR},
+		{"EDDF", FlightCategoryVFR},
+		{"KSFO", FlightCategoryIFR},
+	}
+	for _, test := range tests {
+		metar := db.FindMETARByICAOCode(test.icao)
+		if metar == nil {
+			t.Errorf("%s: not found", test.icao)
+			continue
+		}
+		if category := metar.FlightCategory(); category != test.category {
+			t.Errorf("%s: got %s, want %s", test.icao, category, test.category)
+		}
+	}
+	// the indented line continues the report
+	if ksmo := db.FindMETARByICAOCode("KSMO"); ksmo.Remarks != "AO2" {
+		t.Errorf("got remarks %q", ksmo.Remarks)
+	}
+	if db.FindMETARByICAOCode("KXYZ") != nil {
+		t.Error("the NIL report was stored")
+	}
+}
+
+func TestFlightCategory(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions Conditions
+		category   string
+	}{
+		{"no data", Conditions{}, FlightCategoryUnknown},
+		{"clouds only", Conditions{Clouds: []Cloud{{Cover: CloudCoverScattered, BaseFt: 2000}}}, FlightCategoryVFR},
+		{"CAVOK", Conditions{CAVOK: true, HasVisibility: true, VisibilityMeters: 10000}, FlightCategoryVFR},
+		{"ceiling 3100 ft", testConditions(10, CloudCoverBroken, 3100), FlightCategoryVFR},
+		{"ceiling 3000 ft", testConditions(10, CloudCoverBroken, 3000), FlightCategoryMVFR},
+		{"ceiling 1000 ft", testConditions(10, CloudCoverOvercast, 1000), FlightCategoryMVFR},
+		{"ceiling 900 ft", testConditions(10, CloudCoverOvercast, 900), FlightCategoryIFR},
+		{"ceiling 500 ft", testConditions(10, CloudCoverOvercast, 500), FlightCategoryIFR},
+		{"ceiling 400 ft", testConditions(10, CloudCoverOvercast, 400), FlightCategoryLIFR},
+		{"vertical visibility 200 ft", testConditions(10, CloudCoverVerticalVisibility, 200), FlightCategoryLIFR},
+		{"few at 400 ft is no ceiling", testConditions(10, CloudCoverFew, 400), FlightCategoryVFR},
+		{"visibility 6 SM", testConditions(6, "", 0), FlightCategoryVFR},
+		{"visibility 5 SM", testConditions(5, "", 0), FlightCategoryMVFR},
+		{"visibility 3 SM", testConditions(3, "", 0), FlightCategoryMVFR},
+		{"visibility 2 3/4 SM", testConditions(2.75, "", 0), FlightCategoryIFR},
+		{"visibility 1 SM", testConditions(1, "", 0), FlightCategoryIFR},
+		{"visibility 3/4 SM", testConditions(0.75, "", 0), FlightCategoryLIFR},
+		{"the lower category wins", testConditions(2, CloudCoverBroken, 3000), FlightCategoryIFR},
+	}
+	for _, test := range tests {
+		if category := test.conditions.FlightCategory(); category != test.category {
+			t.Errorf("%s: got %s, want %s", test.name, category, test.category)
+		}
+	}
+}
+
+// testConditions returns conditions with the visibility and, if cover isn't empty, one cloud layer
+func testConditions(visibilitySM float64, cover string, baseFt int64) Conditions {
+	conditions := Conditions{}
+	conditions.setVisibilityStatuteMiles(visibilitySM)
+	if cover != "" {
+		conditions.Clouds = []Cloud{{Cover: cover, BaseFt: baseFt}}
+	}
+	return conditions
+}
@@ -0,0 +1,350 @@
+package alphafoxtrot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strings"
+)
+
+// FAA NASR 28-day subscription, legacy fixed-width text files.
+// see https://www.faa.gov/air_traffic/flight_info/aeronav/aero_data/NASR_Subscription/ (apt_rf.txt, nav_rf.txt)
+// columns are 1-based start positions and lengths as in the layout files
+
+const OriginNASR = "nasr"
+
+// APT.txt, record type APT
+const (
+	nasrAptSiteNumber       = 4    // 11
+	nasrAptFacilityType     = 15   // 13
+	nasrAptLocationIdent    = 28   // 4
+	nasrAptState            = 49   // 2
+	nasrAptCity             = 94   // 40
+	nasrAptName             = 134  // 50
+	nasrAptLatitudeSecs     = 539  // 12, e.g. 122193.1000N
+	nasrAptLongitudeSecs    = 566  // 12, e.g. 425668.8000W
+	nasrAptElevationFt      = 579  // 7, tenths
+	nasrAptStatusCode       = 841  // 2, O, CI, CP
+	nasrAptICAOIdent        = 1211 // 7
+	nasrRwyIdent            = 17   // 7, e.g. 07L/25R
+	nasrRwyLengthFt         = 24   // 5
+	nasrRwyWidthFt          = 29   // 4
+	nasrRwySurface          = 33   // 12, e.g. CONC-G
+	nasrRwyEdgeLights       = 61   // 5
+	nasrRwyBaseEnd          = 66   // start of the base end block
+	nasrRwyReciprocalEnd    = 288  // start of the reciprocal end block
+	nasrRwyEndIdent         = 0    // 3, offsets within an end block
+	nasrRwyEndTrueHeading   = 3    // 3
+	nasrRwyEndLatitudeSecs  = 38   // 12
+	nasrRwyEndLongitudeSecs = 65   // 12
+	nasrRwyEndElevationFt   = 77   // 7, tenths
+	nasrRwyEndDisplacedFt   = 152  // 4
+)
+
+// NAV.txt, record type NAV1
+const (
+	nasrNavIdent         = 5   // 4
+	nasrNavType          = 9   // 20
+	nasrNavName          = 43  // 30
+	nasrNavCountryCode   = 178 // 2, blank for the US
+	nasrNavLatitudeSecs  = 386 // 11
+	nasrNavLongitudeSecs = 411 // 11
+	nasrNavElevationFt   = 473 // 7, tenths
+	nasrNavMagVariation  = 480 // 5, e.g. 12E
+	nasrNavPower         = 490 // 4
+	nasrNavTACANChannel  = 526 // 4
+	nasrNavFrequency     = 530 // 6, MHz or kHz for NDBs
+)
+
+var nasrFacilityTypes = map[string]string{
+	"AIRPORT":       AirportTypeSmallName,
+	"GLIDERPORT":    AirportTypeSmallName,
+	"ULTRALIGHT":    AirportTypeSmallName,
+	"BALLOONPORT":   AirportTypeSmallName,
+	"HELIPORT":      AirportTypeHeliportName,
+	"SEAPLANE BASE": AirportTypeSeaplaneBaseName,
+}
+
+var nasrNavaidTypes = map[string]string{
+	"VOR":     "VOR",
+	"VOR/DME": "VOR-DME",
+	"VORTAC":  "VORTAC",
+	"TACAN":   "TACAN",
+	"DME":     "DME",
+	"NDB":     "NDB",
+	"NDB/DME": "NDB-DME",
+}
+
+var nasrSurfaces = map[string]string{
+	"ASPH":  "ASP",
+	"CONC":  "CON",
+	"TURF":  "TURF",
+	"GRASS": "TURF",
+	"DIRT":  "DIRT",
+	"GRVL":  "GRAVEL",
+	"WATER": "WATER",
+	"SNOW":  "SNOW",
+}
+
+// NASRAirportImporter imports APT.txt: airports (APT records) and runways (RWY records).
+// NASR has no airport sizes, they are derived from the longest runway: large from 10000 ft, medium from 5000 ft.
+type NASRAirportImporter struct{}
+
+func (NASRAirportImporter) Import(r io.Reader, databases *Databases, airportTypeFilter uint64) error {
+	ids := newImportIDs(databases)
+	airports := make([]*AirportData, 0)
+	bySiteNumber := make(map[string]*AirportData)
+	runways := make(map[uint64][]*RunwayData)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "APT"):
+			airport, err := parseNASRAirport(text)
+			if err != nil {
+				log.Println(line, err)
+				continue
+			}
+			airport.ID = ids.nextAirport()
+			airports = append(airports, airport)
+			bySiteNumber[fixedField(text, nasrAptSiteNumber, 11)] = airport
+
+		case strings.HasPrefix(text, "RWY"):
+			airport, ok := bySiteNumber[fixedField(text, nasrAptSiteNumber, 11)]
+			if !ok {
+				continue
+			}
+			runway, err := parseNASRRunway(text, airport)
+			if err != nil {
+				log.Println(line, airport.ICAOCode, err)
+				continue
+			}
+			runway.ID = ids.nextRunway()
+			runways[airport.ID] = append(runways[airport.ID], runway)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for _, airport := range airports {
+		if airport.Type == AirportTypeSmallName {
+			longest := int64(0)
+			for _, runway := range runways[airport.ID] {
+				if runway.LengthFt > longest {
+					longest = runway.LengthFt
+				}
+			}
+			airport.Type = airportTypeFromLongestRunway(longest)
+		}
+		airport.TypeFlag = AirportTypeFromString(airport.Type)
+		if airport.TypeFlag&airportTypeFilter == 0 {
+			continue
+		}
+		databases.Airports.Airports = append(databases.Airports.Airports, airport)
+		if len(runways[airport.ID]) > 0 {
+			databases.Runways.Runways[airport.ID] = append(databases.Runways.Runways[airport.ID], runways[airport.ID]...)
+		}
+	}
+	return nil
+}
+
+func parseNASRAirport(text string) (*AirportData, error) {
+	typ, ok := nasrFacilityTypes[fixedField(text, nasrAptFacilityType, 13)]
+	if !ok {
+		return nil, fmt.Errorf("nasr: unknown facility type %q", fixedField(text, nasrAptFacilityType, 13))
+	}
+	latitude, err := parseNASRSeconds(fixedField(text, nasrAptLatitudeSecs, 12))
+	if err != nil {
+		return nil, err
+	}
+	longitude, err := parseNASRSeconds(fixedField(text, nasrAptLongitudeSecs, 12))
+	if err != nil {
+		return nil, err
+	}
+	elevation, _ := ParseFloat(fixedField(text, nasrAptElevationFt, 7))
+	if status := fixedField(text, nasrAptStatusCode, 2); status == "CI" || status == "CP" {
+		typ = AirportTypeClosedName
+	}
+
+	locationIdent := fixedField(text, nasrAptLocationIdent, 4)
+	icaoIdent := fixedField(text, nasrAptICAOIdent, 7)
+	ident := icaoIdent
+	if ident == "" {
+		ident = locationIdent
+	}
+	state := fixedField(text, nasrAptState, 2)
+	airport := &AirportData{
+		ICAOCode:     ident,
+		Type:         typ,
+		Name:         fixedField(text, nasrAptName, 50),
+		LatitudeDeg:  latitude,
+		LongitudeDeg: longitude,
+		ElevationFt:  int64(math.Round(elevation)),
+		Continent:    "NA",
+		ISOCountry:   "US",
+		Municipality: fixedField(text, nasrAptCity, 40),
+		GPSCode:      icaoIdent,
+		LocalCode:    locationIdent,
+		Origin:       OriginNASR,
+	}
+	if state != "" {
+		airport.ISORegion = "US-" + state
+	}
+	return airport, nil
+}
+
+func parseNASRRunway(text string, airport *AirportData) (*RunwayData, error) {
+	length, err := ParseInt(fixedField(text, nasrRwyLengthFt, 5))
+	if err != nil {
+		return nil, fmt.Errorf("nasr: runway %s: %v", fixedField(text, nasrRwyIdent, 7), err)
+	}
+	width, _ := ParseInt(fixedField(text, nasrRwyWidthFt, 4))
+	surface := strings.SplitN(fixedField(text, nasrRwySurface, 12), "-", 2)[0]
+	if mapped, ok := nasrSurfaces[surface]; ok {
+		surface = mapped
+	}
+	lights := fixedField(text, nasrRwyEdgeLights, 5)
+
+	runway := &RunwayData{
+		AirportID:    airport.ID,
+		AirportIdent: airport.ICAOCode,
+		LengthFt:     length,
+		WidthFt:      width,
+		Surface:      surface,
+		Lighted:      lights != "" && lights != "NONE",
+		Origin:       OriginNASR,
+	}
+	base := nasrRunwayEnd(text, nasrRwyBaseEnd)
+	reciprocal := nasrRunwayEnd(text, nasrRwyReciprocalEnd)
+	runway.LowEndIdent, runway.LowEndLatitudeDeg, runway.LowEndLongitudeDeg = base.ident, base.latitude, base.longitude
+	runway.LowEndElevationFt, runway.LowEndHeadingDegT, runway.LowEndDisplacedThresholdFt = base.elevationFt, base.headingDegT, base.displacedFt
+	runway.HighEndIdent, runway.HighEndLatitudeDeg, runway.HighEndLongitudeDeg = reciprocal.ident, reciprocal.latitude, reciprocal.longitude
+	runway.HighEndElevationFt, runway.HighEndHeadingDegT, runway.HighEndDisplacedThresholdFt = reciprocal.elevationFt, reciprocal.headingDegT, reciprocal.displacedFt
+	return runway, nil
+}
+
+type runwayEnd struct {
+	ident       string
+	latitude    float64
+	longitude   float64
+	elevationFt int64
+	headingDegT float64
+	displacedFt int64
+}
+
+// nasrRunwayEnd reads the base or reciprocal end block of a RWY record, missing values are zero
+func nasrRunwayEnd(text string, start int) runwayEnd {
+	end := runwayEnd{ident: fixedField(text, start+nasrRwyEndIdent, 3)}
+	end.latitude, _ = parseNASRSeconds(fixedField(text, start+nasrRwyEndLatitudeSecs, 12))
+	end.longitude, _ = parseNASRSeconds(fixedField(text, start+nasrRwyEndLongitudeSecs, 12))
+	elevation, _ := ParseFloat(fixedField(text, start+nasrRwyEndElevationFt, 7))
+	end.elevationFt = int64(math.Round(elevation))
+	end.headingDegT, _ = ParseFloat(fixedField(text, start+nasrRwyEndTrueHeading, 3))
+	end.displacedFt, _ = ParseInt(fixedField(text, start+nasrRwyEndDisplacedFt, 4))
+	return end
+}
+
+// NASRNavaidImporter imports the NAV1 records of NAV.txt, fan markers and VOTs are skipped
+type NASRNavaidImporter struct{}
+
+func (NASRNavaidImporter) Import(r io.Reader, databases *Databases, airportTypeFilter uint64) error {
+	ids := newImportIDs(databases)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if !strings.HasPrefix(text, "NAV1") {
+			continue
+		}
+		typ, ok := nasrNavaidTypes[fixedField(text, nasrNavType, 20)]
+		if !ok {
+			continue
+		}
+		ident := fixedField(text, nasrNavIdent, 4)
+		latitude, err := parseNASRSeconds(fixedField(text, nasrNavLatitudeSecs, 11))
+		if err != nil {
+			log.Println(line, ident, err)
+			continue
+		}
+		longitude, err := parseNASRSeconds(fixedField(text, nasrNavLongitudeSecs, 11))
+		if err != nil {
+			log.Println(line, ident, err)
+			continue
+		}
+		frequency, _ := ParseFloat(fixedField(text, nasrNavFrequency, 6))
+		if !strings.HasPrefix(typ, "NDB") {
+			frequency *= 1000
+		}
+		elevation, _ := ParseFloat(fixedField(text, nasrNavElevationFt, 7))
+		country := fixedField(text, nasrNavCountryCode, 2)
+		if country == "" {
+			country = "US"
+		}
+		databases.Navaids.Navaids = append(databases.Navaids.Navaids, &NavaidData{
+			ID:                   ids.nextNavaid(),
+			Ident:                ident,
+			Name:                 fixedField(text, nasrNavName, 30),
+			Type:                 typ,
+			FrequencyKHZ:         uint64(math.Round(frequency)),
+			LatitudeDeg:          latitude,
+			LongitudeDeg:         longitude,
+			ElevationFt:          int64(math.Round(elevation)),
+			ISOCountry:           country,
+			DMEChannel:           fixedField(text, nasrNavTACANChannel, 4),
+			MagneticVariationDeg: parseVariation(fixedField(text, nasrNavMagVariation, 5)),
+			Power:                fixedField(text, nasrNavPower, 4),
+			Origin:               OriginNASR,
+		})
+	}
+	return scanner.Err()
+}
+
+// parseNASRSeconds parses a coordinate in seconds with a trailing hemisphere, e.g. 122193.1000N
+func parseNASRSeconds(str string) (float64, error) {
+	if len(str) < 2 {
+		return 0, fmt.Errorf("nasr: invalid coordinate %q", str)
+	}
+	seconds, err := ParseFloat(str[:len(str)-1])
+	if err != nil {
+		return 0, err
+	}
+	switch str[len(str)-1] {
+	case 'N', 'E':
+		return seconds / 3600, nil
+	case 'S', 'W':
+		return -seconds / 3600, nil
+	}
+	return 0, fmt.Errorf("nasr: invalid hemisphere in %q", str)
+}
+
+// parseVariation parses a variation with a leading or trailing E/W, e.g. 12E or W0140 (tenths), east is positive
+func parseVariation(str string) float64 {
+	if str == "" {
+		return 0
+	}
+	sign := 1.0
+	switch {
+	case strings.HasSuffix(str, "W"):
+		sign = -1
+		fallthrough
+	case strings.HasSuffix(str, "E"):
+		value, _ := ParseFloat(strings.TrimSpace(str[:len(str)-1]))
+		return sign * value
+	case strings.HasPrefix(str, "W"):
+		sign = -1
+		fallthrough
+	case strings.HasPrefix(str, "E"):
+		value, _ := ParseFloat(str[1:])
+		return sign * value / 10
+	}
+	return 0
+}
@@ -0,0 +1,112 @@
+package alphafoxtrot
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNASRAirportImporter(t *testing.T) {
+	databases := NewDatabases()
+	if err := ImportFile(NASRAirportImporter{}, "testdata/nasr/APT.txt", databases, AirportTypeAll); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ident     string
+		typ       string
+		latitude  float64
+		longitude float64
+		elevation int64
+		runways   int
+	}{
+		{"KLAX", AirportTypeLargeName, 33.942528, -118.407997, 128, 2},
+		{"KSMO", AirportTypeSmallName, 34.01585, -118.451, 177, 1},
+		{"CA38", AirportTypeHeliportName, 34.0751, -118.3809, 95, 0},
+		{"L99", AirportTypeClosedName, 34.1, -118.1, 88, 0},
+	}
+	if len(databases.Airports.Airports) != len(tests) {
+		t.Fatalf("got %d airports, want %d", len(databases.Airports.Airports), len(tests))
+	}
+	for _, test := range tests {
+		airport := databases.Airports.FindByICAOCode(test.ident)
+		if airport == nil {
+			t.Errorf("%s: not found", test.ident)
+			continue
+		}
+		if airport.Type != test.typ || airport.ElevationFt != test.elevation || airport.ISORegion != "US-CA" || airport.Origin != OriginNASR {
+			t.Errorf("%s: got %s %d ft %s %s", test.ident, airport.Type, airport.ElevationFt, airport.ISORegion, airport.Origin)
+		}
+		if math.Abs(airport.LatitudeDeg-test.latitude) > 1e-5 || math.Abs(airport.LongitudeDeg-test.longitude) > 1e-5 {
+			t.Errorf("%s: got position %f,%f", test.ident, airport.LatitudeDeg, airport.LongitudeDeg)
+		}
+		if runways := databases.Runways.FindByAirportID(airport.ID); len(runways) != test.runways {
+			t.Errorf("%s: got %d runways, want %d", test.ident, len(runways), test.runways)
+		}
+	}
+
+	klax := databases.Airports.FindByICAOCode("KLAX")
+	if klax.LocalCode != "LAX" || klax.Name != "LOS ANGELES INTL" || klax.Municipality != "LOS ANGELES" {
+		t.Errorf("unexpected airport %+v", klax)
+	}
+	runway := databases.Runways.FindByAirportID(klax.ID)[0]
+	if runway.LowEndIdent != "07L" || runway.HighEndIdent != "25R" || runway.LengthFt != 12923 || runway.WidthFt != 150 || runway.Surface != "CON" || !runway.Lighted {
+		t.Errorf("unexpected runway %+v", runway)
+	}
+	if runway.LowEndElevationFt != 114 || runway.HighEndElevationFt != 94 || runway.HighEndDisplacedThresholdFt != 957 {
+		t.Errorf("unexpected runway ends %+v", runway)
+	}
+	if math.Abs(runway.LowEndLatitudeDeg-33.935897) > 1e-5 || math.Abs(runway.LowEndLongitudeDeg+118.419072) > 1e-5 {
+		t.Errorf("got low end %f,%f", runway.LowEndLatitudeDeg, runway.LowEndLongitudeDeg)
+	}
+}
+
+func TestNASRNavaidImporter(t *testing.T) {
+	databases := NewDatabases()
+	if err := ImportFile(NASRAirportImporter{}, "testdata/nasr/APT.txt", databases, AirportTypeAll); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportFile(NASRNavaidImporter{}, "testdata/nasr/NAV.txt", databases, AirportTypeAll); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ident        string
+		typ          string
+		frequencyKHZ uint64
+		latitude     float64
+		longitude    float64
+		elevation    int64
+		variation    float64
+	}{
+		{"LAX", "VORTAC", 113600, 33.9333, -118.432453, 183, 15},
+		{"SMO", "VOR-DME", 110800, 34.014831, -118.4504, 120, 15},
+		{"OS", "NDB", 338, 33.921667, -118.515, 50, 14},
+	}
+	// the fan marker is skipped
+	if len(databases.Navaids.Navaids) != len(tests) {
+		t.Fatalf("got %d navaids, want %d", len(databases.Navaids.Navaids), len(tests))
+	}
+	for i, test := range tests {
+		navaid := databases.Navaids.Navaids[i]
+		if navaid.Ident != test.ident || navaid.Type != test.typ || navaid.FrequencyKHZ != test.frequencyKHZ || navaid.ElevationFt != test.elevation {
+			t.Errorf("%s: got %s %s %d kHz %d ft", test.ident, navaid.Ident, navaid.Type, navaid.FrequencyKHZ, navaid.ElevationFt)
+		}
+		if math.Abs(navaid.LatitudeDeg-test.latitude) > 1e-5 || math.Abs(navaid.LongitudeDeg-test.longitude) > 1e-5 {
+			t.Errorf("%s: got position %f,%f", test.ident, navaid.LatitudeDeg, navaid.LongitudeDeg)
+		}
+		if navaid.MagneticVariationDeg != test.variation || navaid.ISOCountry != "US" || navaid.ID != uint64(i+1) {
+			t.Errorf("%s: unexpected navaid %+v", test.ident, navaid)
+		}
+	}
+	if lax := databases.Navaids.Navaids[0]; lax.DMEChannel != "084X" || lax.Power != "200" {
+		t.Errorf("unexpected navaid %+v", lax)
+	}
+}
+
+func TestNASRAirportImporterFilter(t *testing.T) {
+	databases := NewDatabases()
+	if err := ImportFile(NASRAirportImporter{}, "testdata/nasr/APT.txt", databases, AirportTypeRunways); err != nil {
+		t.Fatal(err)
+	}
+	if len(databases.Airports.Airports) != 2 || len(databases.Runways.Runways) != 2 {
+		t.Errorf("got %d airports, %d with runways", len(databases.Airports.Airports), len(databases.Runways.Runways))
+	}
+}
@@ -57,6 +57,7 @@ type NavaidData struct {
 	UsageType            string
 	Power                string
 	AssociatedAirport    string
+	Origin               string
 }
 
 type NavaidDB struct {
@@ -79,8 +80,15 @@ func (db *NavaidDB) Parse(file string, skipFirstLine bool) error {
 		return err
 	}
 	defer f.Close()
+	return ReadNavaids(f, skipFirstLine, func(navaid *NavaidData) error {
+		db.Navaids = append(db.Navaids, navaid)
+		return nil
+	})
+}
 
-	reader := csv.NewReader(f)
+// ReadNavaids calls fn for every record of the csv and stops at the first error returned by fn
+func ReadNavaids(r io.Reader, skipFirstLine bool, fn func(*NavaidData) error) error {
+	reader := csv.NewReader(r)
 	line := -1
 
 	for {
@@ -148,8 +156,11 @@ func (db *NavaidDB) Parse(file string, skipFirstLine bool) error {
 			UsageType:            usageType,
 			Power:                power,
 			AssociatedAirport:    associatedAirport,
+			Origin:               OriginBase,
+		}
+		if err := fn(navaid); err != nil {
+			return err
 		}
-		db.Navaids = append(db.Navaids, navaid)
 	}
 }
 
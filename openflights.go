@@ -0,0 +1,295 @@
+package alphafoxtrot
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// https://openflights.org/data.html#airport
+// columns: Airport ID, Name, City, Country, IATA, ICAO, Latitude, Longitude, Altitude, Timezone, DST, Tz database time zone, Type, Source
+// e.g. 3484,"Los Angeles International Airport","Los Angeles","United States","LAX","KLAX",33.94250107,-118.4079971,125,-8,"A","America/Los_Angeles","airport","OurAirports"
+// missing values are \N, the file has no header
+
+const (
+	colOpenFlightsID = iota
+	colOpenFlightsName
+	colOpenFlightsCity
+	colOpenFlightsCountry
+	colOpenFlightsIATA
+	colOpenFlightsICAO
+	colOpenFlightsLatitudeDeg
+	colOpenFlightsLongitudeDeg
+	colOpenFlightsAltitudeFt
+	colOpenFlightsTimezone
+	colOpenFlightsDST
+	colOpenFlightsTz
+	colOpenFlightsType
+	colOpenFlightsSource
+)
+
+// OpenFlightsIDOffset is added to the OpenFlights IDs, which would otherwise collide with the OurAirports IDs
+const OpenFlightsIDOffset uint64 = 1 << 32
+
+const (
+	OriginOpenFlights   = "openflights"
+	openFlightsNull     = `\N`
+	openFlightsMinCols  = colOpenFlightsTz + 1
+	openFlightsTypeName = "airport"
+)
+
+// ParseOpenFlights reads an OpenFlights airports.dat file into the database.
+// OpenFlights has no airport sizes, so airports are stored as small airports, train stations and ferry ports are skipped.
+// The IDs are offset by OpenFlightsIDOffset.
+// The optional country database resolves the country names of OpenFlights to ISO codes and continents.
+func (db *AirportDB) ParseOpenFlights(file string, airportTypeFilter uint64, countries *CountryDB) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return db.ReadOpenFlights(f, airportTypeFilter, countries)
+}
+
+func (db *AirportDB) ReadOpenFlights(r io.Reader, airportTypeFilter uint64, countries *CountryDB) error {
+	if AirportTypeSmall&airportTypeFilter == 0 {
+		return nil
+	}
+	countriesByName := make(map[string]*CountryData)
+	if countries != nil {
+		for _, country := range countries.Countries {
+			countriesByName[strings.ToLower(country.Name)] = country
+		}
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	// fields like "Tz" may contain a stray quote
+	reader.LazyQuotes = true
+
+	line := -1
+	for {
+		row, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return err
+		}
+
+		line++
+
+		if len(row) < openFlightsMinCols {
+			log.Println(line, "openflights: too few columns")
+			continue
+		}
+		for i := range row {
+			if row[i] == openFlightsNull {
+				row[i] = ""
+			}
+		}
+		if len(row) > colOpenFlightsType && row[colOpenFlightsType] != "" && row[colOpenFlightsType] != openFlightsTypeName {
+			continue
+		}
+
+		id, err := ParseUint(row[colOpenFlightsID])
+		if err != nil {
+			log.Println(line, err)
+			continue
+		}
+		latitude, err := ParseFloat(row[colOpenFlightsLatitudeDeg])
+		if err != nil {
+			log.Println(line, row[colOpenFlightsICAO], row[colOpenFlightsName], err)
+			continue
+		}
+		longitude, err := ParseFloat(row[colOpenFlightsLongitudeDeg])
+		if err != nil {
+			log.Println(line, row[colOpenFlightsICAO], row[colOpenFlightsName], err)
+			continue
+		}
+		elevation, _ := ParseInt(row[colOpenFlightsAltitudeFt])
+		utcOffset, _ := ParseFloat(row[colOpenFlightsTimezone])
+
+		airport := &AirportData{
+			ID:             id + OpenFlightsIDOffset,
+			ICAOCode:       row[colOpenFlightsICAO],
+			Type:           AirportTypeSmallName,
+			TypeFlag:       AirportTypeSmall,
+			Name:           row[colOpenFlightsName],
+			LatitudeDeg:    latitude,
+			LongitudeDeg:   longitude,
+			ElevationFt:    elevation,
+			Municipality:   row[colOpenFlightsCity],
+			GPSCode:        row[colOpenFlightsICAO],
+			IATACode:       row[colOpenFlightsIATA],
+			TimeZone:       row[colOpenFlightsTz],
+			UTCOffsetHours: utcOffset,
+			DST:            row[colOpenFlightsDST],
+			Origin:         OriginOpenFlights,
+		}
+		if country, ok := countriesByName[strings.ToLower(row[colOpenFlightsCountry])]; ok {
+			airport.ISOCountry = country.ISOCode
+			airport.Continent = country.Continent
+		}
+		db.Airports = append(db.Airports, airport)
+	}
+}
+
+const (
+	MergeConflictCoordinates = "coordinates"
+	MergeConflictName        = "name"
+)
+
+type OpenFlightsMergeOptions struct {
+	MaxDistanceMeters float64 // coordinates further apart are a conflict and the record isn't enriched
+	MinNameSimilarity float64 // names with a lower word overlap (0-1) are a conflict, the record is enriched anyway
+}
+
+func DefaultOpenFlightsMergeOptions() *OpenFlightsMergeOptions {
+	return &OpenFlightsMergeOptions{
+		MaxDistanceMeters: 5000,
+		MinNameSimilarity: 0.3,
+	}
+}
+
+type MergeConflict struct {
+	Conflict       string
+	ICAOCode       string
+	IATACode       string
+	Base           string // value of the OurAirports record
+	Other          string // value of the OpenFlights record
+	DistanceMeters float64
+}
+
+func (conflict MergeConflict) String() string {
+	code := conflict.ICAOCode
+	if code == "" {
+		code = conflict.IATACode
+	}
+	if conflict.Conflict == MergeConflictCoordinates {
+		return fmt.Sprintf("%s: %s differ by %.0f m (%s / %s)", code, conflict.Conflict, conflict.DistanceMeters, conflict.Base, conflict.Other)
+	}
+	return fmt.Sprintf("%s: %s differs (%q / %q)", code, conflict.Conflict, conflict.Base, conflict.Other)
+}
+
+type OpenFlightsMergeResult struct {
+	Enriched  int            // number of records which received timezone data
+	Unmatched []*AirportData // OpenFlights records without an OurAirports counterpart
+	Conflicts []MergeConflict
+}
+
+// MergeOpenFlights enriches the airports with the timezone data of the OpenFlights database (see ParseOpenFlights).
+// Records are matched by ICAO code first, then by IATA code.
+func (db *AirportDB) MergeOpenFlights(openFlights *AirportDB, options *OpenFlightsMergeOptions) *OpenFlightsMergeResult {
+	if options == nil {
+		options = DefaultOpenFlightsMergeOptions()
+	}
+	byICAOCode := make(map[string]*AirportData, len(db.Airports))
+	byIATACode := make(map[string]*AirportData, len(db.Airports))
+	for _, airport := range db.Airports {
+		byICAOCode[airport.ICAOCode] = airport
+		if airport.GPSCode != "" && byICAOCode[airport.GPSCode] == nil {
+			byICAOCode[airport.GPSCode] = airport
+		}
+		if airport.IATACode != "" {
+			byIATACode[airport.IATACode] = airport
+		}
+	}
+
+	result := &OpenFlightsMergeResult{Unmatched: make([]*AirportData, 0), Conflicts: make([]MergeConflict, 0)}
+	for _, other := range openFlights.Airports {
+		var base *AirportData
+		if other.ICAOCode != "" {
+			base = byICAOCode[other.ICAOCode]
+		}
+		if base == nil && other.IATACode != "" {
+			base = byIATACode[other.IATACode]
+		}
+		if base == nil {
+			result.Unmatched = append(result.Unmatched, other)
+			continue
+		}
+
+		if similarity := NameSimilarity(base.Name, other.Name); similarity < options.MinNameSimilarity {
+			result.Conflicts = append(result.Conflicts, MergeConflict{
+				Conflict: MergeConflictName,
+				ICAOCode: base.ICAOCode,
+				IATACode: base.IATACode,
+				Base:     base.Name,
+				Other:    other.Name,
+			})
+		}
+		distance := Distance(base.LatitudeDeg, base.LongitudeDeg, other.LatitudeDeg, other.LongitudeDeg)
+		if options.MaxDistanceMeters > 0 && distance > options.MaxDistanceMeters {
+			result.Conflicts = append(result.Conflicts, MergeConflict{
+				Conflict:       MergeConflictCoordinates,
+				ICAOCode:       base.ICAOCode,
+				IATACode:       base.IATACode,
+				Base:           fmt.Sprintf("%f,%f", base.LatitudeDeg, base.LongitudeDeg),
+				Other:          fmt.Sprintf("%f,%f", other.LatitudeDeg, other.LongitudeDeg),
+				DistanceMeters: distance,
+			})
+			continue
+		}
+
+		if other.TimeZone == "" && other.DST == "" {
+			continue
+		}
+		base.TimeZone = other.TimeZone
+		base.UTCOffsetHours = other.UTCOffsetHours
+		base.DST = other.DST
+		result.Enriched++
+	}
+	return result
+}
+
+// MergeOpenFlights enriches the airports of the finder with the timezone data of the OpenFlights database
+func (af *AirportFinder) MergeOpenFlights(openFlights *AirportDB, options *OpenFlightsMergeOptions) *OpenFlightsMergeResult {
+	af.mutex.Lock()
+	defer af.mutex.Unlock()
+	return af.airportDB.MergeOpenFlights(openFlights, options)
+}
+
+// words which say nothing about the identity of an airport
+var nameSimilarityStopWords = map[string]bool{
+	"airport": true, "international": true, "intl": true, "regional": true, "airfield": true,
+	"aerodrome": true, "airstrip": true, "field": true, "municipal": true, "the": true, "of": true,
+}
+
+// NameSimilarity returns the overlap (0-1) of the significant words of two airport names
+func NameSimilarity(a, b string) float64 {
+	wordsA, wordsB := nameWords(a), nameWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		if strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b)) {
+			return 1
+		}
+		return 0
+	}
+	common := 0
+	for word := range wordsA {
+		if wordsB[word] {
+			common++
+		}
+	}
+	smaller := len(wordsA)
+	if len(wordsB) < smaller {
+		smaller = len(wordsB)
+	}
+	return float64(common) / float64(smaller)
+}
+
+func nameWords(name string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !nameSimilarityStopWords[word] {
+			words[word] = true
+		}
+	}
+	return words
+}
@@ -0,0 +1,74 @@
+package alphafoxtrot
+
+import "testing"
+
+func TestParseOpenFlights(t *testing.T) {
+	db := NewAirportDB()
+	if err := db.ParseOpenFlights("testdata/openflights/airports.dat", AirportTypeAll, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(db.Airports) != 6 {
+		t.Fatalf("got %d airports, want 6 (no station, no broken row)", len(db.Airports))
+	}
+	klax := db.Airports[0]
+	if klax.ID != 3484+OpenFlightsIDOffset || klax.ICAOCode != "KLAX" || klax.IATACode != "LAX" || klax.TypeFlag != AirportTypeSmall {
+		t.Errorf("unexpected airport %+v", klax)
+	}
+	if klax.TimeZone != "America/Los_Angeles" || klax.UTCOffsetHours != -8 || klax.DST != "A" || klax.ElevationFt != 125 {
+		t.Errorf("unexpected timezone data %+v", klax)
+	}
+	if ksmo := db.Airports[1]; ksmo.IATACode != "" {
+		t.Errorf(`\N was not cleared: %q`, ksmo.IATACode)
+	}
+
+	empty := NewAirportDB()
+	if err := empty.ParseOpenFlights("testdata/openflights/airports.dat", AirportTypeLarge, nil); err != nil || len(empty.Airports) != 0 {
+		t.Errorf("small airports were not filtered: %d airports, %v", len(empty.Airports), err)
+	}
+}
+
+func TestOpenFlightsIDsDoNotCollide(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	ids := make(map[uint64]bool)
+	for _, airport := range finder.airportDB.Airports {
+		ids[airport.ID] = true
+	}
+	// KSMO has the ID 3878 in both databases
+	if err := finder.airportDB.ParseOpenFlights("testdata/openflights/airports.dat", AirportTypeAll, finder.countryDB); err != nil {
+		t.Fatal(err)
+	}
+	for _, airport := range finder.airportDB.Airports {
+		if airport.Origin == OriginOpenFlights && ids[airport.ID] {
+			t.Errorf("%s: ID %d is taken by an OurAirports record", airport.ICAOCode, airport.ID)
+		}
+	}
+}
+
+func TestMergeOpenFlights(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	openFlights := NewAirportDB()
+	if err := openFlights.ParseOpenFlights("testdata/openflights/airports.dat", AirportTypeAll, finder.countryDB); err != nil {
+		t.Fatal(err)
+	}
+	result := finder.MergeOpenFlights(openFlights, nil)
+	if result.Enriched != 4 {
+		t.Errorf("got %d enriched airports, want 4", result.Enriched)
+	}
+	if len(result.Unmatched) != 1 || result.Unmatched[0].ICAOCode != "EDHI" || result.Unmatched[0].ISOCountry != "DE" {
+		t.Errorf("unexpected unmatched airports %+v", result.Unmatched)
+	}
+	conflicts := make(map[string]string)
+	for _, conflict := range result.Conflicts {
+		conflicts[conflict.ICAOCode] = conflict.Conflict
+	}
+	if len(conflicts) != 2 || conflicts["KLGB"] != MergeConflictName || conflicts["EDDF"] != MergeConflictCoordinates {
+		t.Errorf("unexpected conflicts %v", result.Conflicts)
+	}
+	// matched by IATA code
+	if ksfo := finder.airportDB.FindByICAOCode("KSFO"); ksfo.TimeZone != "America/Los_Angeles" {
+		t.Errorf("KSFO was not enriched: %q", ksfo.TimeZone)
+	}
+	if eddf := finder.airportDB.FindByICAOCode("EDDF"); eddf.TimeZone != "" {
+		t.Errorf("EDDF was enriched despite the coordinate conflict: %q", eddf.TimeZone)
+	}
+}
@@ -0,0 +1,519 @@
+package alphafoxtrot
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// Origin of a record: either the base data or a local override
+const (
+	OriginBase     = "base"
+	OriginOverride = "override"
+)
+
+const (
+	PatchAdd    = "add"
+	PatchModify = "modify"
+	PatchDelete = "delete"
+)
+
+// Patch adds, modifies or deletes a single airport, runway, frequency or navaid.
+// Records are referenced by their ID or by their ident:
+// airports by ICAO code ("KLAX"), navaids by ident ("LAX"),
+// runways by airport ident and runway end ("KLAX:07L") and frequencies by airport ident and type ("KLAX:ATIS").
+// Idents matching several records (e.g. two TWR frequencies) are rejected, those records need the ID.
+// Fields are set by the names of the fields of AirportData, RunwayData, FrequencyData and NavaidData (case-insensitive).
+//
+// JSON patch file:
+//
+//	{"patches": [
+//	  {"action": "add", "kind": "airport", "id": 9000001, "fields": {"ICAOCode": "XPVT", "Type": "small_airport", "LatitudeDeg": 47.1, "LongitudeDeg": 8.2}},
+//	  {"action": "modify", "kind": "runway", "ident": "KSMO:03", "fields": {"Closed": true}},
+//	  {"action": "delete", "kind": "frequency", "id": 60768}
+//	]}
+//
+// CSV patch file with one field per row, consecutive rows referencing the same record are merged:
+//
+//	action,kind,id,ident,field,value
+//	add,airport,9000001,,ICAOCode,XPVT
+//	add,airport,9000001,,Type,small_airport
+//	modify,runway,,KSMO:03,Closed,true
+//	delete,frequency,60768,,,
+type Patch struct {
+	Action string                 `json:"action"`
+	Kind   string                 `json:"kind"` // see RecordKindAirport etc.
+	ID     uint64                 `json:"id,omitempty"`
+	Ident  string                 `json:"ident,omitempty"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+type PatchFile struct {
+	Patches []Patch `json:"patches"`
+}
+
+const (
+	colPatchAction = iota
+	colPatchKind
+	colPatchID
+	colPatchIdent
+	colPatchField
+	colPatchValue
+)
+
+// ParsePatchFile reads a patch file, files ending with .csv are read as CSV, all others as JSON
+func ParsePatchFile(file string) ([]Patch, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(file), ".csv") {
+		return ParsePatchCSV(f, true)
+	}
+	return ParsePatchJSON(f)
+}
+
+func ParsePatchJSON(r io.Reader) ([]Patch, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	var patchFile PatchFile
+	if err := decoder.Decode(&patchFile); err != nil {
+		return nil, err
+	}
+	return patchFile.Patches, nil
+}
+
+func ParsePatchCSV(r io.Reader, skipFirstLine bool) ([]Patch, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	patches := make([]Patch, 0)
+	line := -1
+	for {
+		row, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return patches, err
+		}
+
+		line++
+		if line == 0 && skipFirstLine {
+			continue
+		}
+		if len(row) <= colPatchIdent {
+			return nil, fmt.Errorf("patch line %d: expected at least %d columns", line, colPatchIdent+1)
+		}
+
+		var id uint64
+		if row[colPatchID] != "" {
+			if id, err = ParseUint(row[colPatchID]); err != nil {
+				return nil, fmt.Errorf("patch line %d: %v", line, err)
+			}
+		}
+		patch := Patch{
+			Action: row[colPatchAction],
+			Kind:   row[colPatchKind],
+			ID:     id,
+			Ident:  row[colPatchIdent],
+		}
+		if n := len(patches); n > 0 {
+			last := &patches[n-1]
+			if last.Action == patch.Action && last.Kind == patch.Kind && last.ID == patch.ID && last.Ident == patch.Ident {
+				patch = *last
+				patches = patches[:n-1]
+			}
+		}
+		if len(row) > colPatchValue && row[colPatchField] != "" {
+			if patch.Fields == nil {
+				patch.Fields = make(map[string]interface{})
+			}
+			patch.Fields[row[colPatchField]] = row[colPatchValue]
+		}
+		patches = append(patches, patch)
+	}
+}
+
+// ApplyPatches applies the patches to the loaded data.
+// Airports which don't match the airport type filter after patching are removed, added ones are reported.
+// Airports excluded by the filter when loading can't be patched here, set LoadOptions.OverridesFilename to reach them.
+func (af *AirportFinder) ApplyPatches(patches []Patch, airportTypeFilter uint64) []error {
+	af.mutex.Lock()
+	defer af.mutex.Unlock()
+	return af.applyPatches(patches, airportTypeFilter)
+}
+
+func (af *AirportFinder) applyPatches(patches []Patch, airportTypeFilter uint64) []error {
+	errors := make([]error, 0)
+	added := make(map[*AirportData]bool)
+	for i, patch := range patches {
+		var err error
+		switch patch.Kind {
+		case RecordKindAirport:
+			err = af.applyAirportPatch(&patch)
+			if err == nil && patch.Action == PatchAdd {
+				added[af.airportDB.Airports[len(af.airportDB.Airports)-1]] = true
+			}
+		case RecordKindRunway:
+			err = af.applyRunwayPatch(&patch)
+		case RecordKindFrequency:
+			err = af.applyFrequencyPatch(&patch)
+		case RecordKindNavaid:
+			err = af.applyNavaidPatch(&patch)
+		default:
+			err = fmt.Errorf("unknown kind %q", patch.Kind)
+		}
+		if err != nil {
+			errors = append(errors, fmt.Errorf("patch #%d (%s %s %d %s): %v", i+1, patch.Action, patch.Kind, patch.ID, patch.Ident, err))
+		}
+	}
+
+	airports := af.airportDB.Airports[:0]
+	for _, airport := range af.airportDB.Airports {
+		if airport.TypeFlag&airportTypeFilter != 0 {
+			airports = append(airports, airport)
+		} else if added[airport] {
+			errors = append(errors, fmt.Errorf("airport %d %s: type %s is excluded by the airport type filter", airport.ID, airport.ICAOCode, airport.Type))
+		}
+	}
+	af.airportDB.Airports = airports
+	af.airportDB.InvalidateIndex()
+	return errors
+}
+
+func (af *AirportFinder) applyAirportPatch(patch *Patch) error {
+	index := -1
+	for i, airport := range af.airportDB.Airports {
+		if (patch.ID != 0 && airport.ID == patch.ID) || (patch.ID == 0 && patch.Ident != "" && airport.ICAOCode == patch.Ident) {
+			index = i
+			break
+		}
+	}
+
+	switch patch.Action {
+	case PatchAdd:
+		if index >= 0 {
+			return fmt.Errorf("airport already exists")
+		}
+		airport := &AirportData{ID: patch.ID, ICAOCode: patch.Ident}
+		if err := setRecordFields(airport, patch.Fields); err != nil {
+			return err
+		}
+		if airport.ID == 0 {
+			return fmt.Errorf("missing ID")
+		}
+		if err := setAirportTypeFlag(airport); err != nil {
+			return err
+		}
+		airport.Origin = OriginOverride
+		af.airportDB.Airports = append(af.airportDB.Airports, airport)
+	case PatchModify:
+		if index < 0 {
+			return fmt.Errorf("airport not found")
+		}
+		// the fields are set on a copy so a bad value leaves the airport as it was
+		airport := *af.airportDB.Airports[index]
+		if err := setRecordFields(&airport, patch.Fields); err != nil {
+			return err
+		}
+		if err := setAirportTypeFlag(&airport); err != nil {
+			return err
+		}
+		airport.Origin = OriginOverride
+		*af.airportDB.Airports[index] = airport
+	case PatchDelete:
+		if index < 0 {
+			return fmt.Errorf("airport not found")
+		}
+		airport := af.airportDB.Airports[index]
+		af.airportDB.Airports = append(af.airportDB.Airports[:index], af.airportDB.Airports[index+1:]...)
+		delete(af.runwayDB.Runways, airport.ID)
+		delete(af.frequencyDB.Frequencies, airport.ID)
+	default:
+		return fmt.Errorf("unknown action %q", patch.Action)
+	}
+	return nil
+}
+
+func (af *AirportFinder) applyRunwayPatch(patch *Patch) error {
+	var found *RunwayData
+	airportIdent, runwayIdent := splitPatchIdent(patch.Ident)
+	for _, runways := range af.runwayDB.Runways {
+		for _, runway := range runways {
+			if (patch.ID != 0 && runway.ID == patch.ID) || (patch.ID == 0 && patch.Ident != "" &&
+				runway.AirportIdent == airportIdent && (runway.LowEndIdent == runwayIdent || runway.HighEndIdent == runwayIdent)) {
+				if found != nil {
+					return fmt.Errorf("runway ident is ambiguous, please use the ID")
+				}
+				found = runway
+			}
+		}
+	}
+
+	switch patch.Action {
+	case PatchAdd:
+		if found != nil {
+			return fmt.Errorf("runway already exists")
+		}
+		runway := &RunwayData{ID: patch.ID}
+		runway.AirportIdent, runway.LowEndIdent = splitPatchIdent(patch.Ident)
+		if err := setRecordFields(runway, patch.Fields); err != nil {
+			return err
+		}
+		if runway.ID == 0 {
+			return fmt.Errorf("missing ID")
+		}
+		if err := af.resolvePatchAirportRef(&runway.AirportID, &runway.AirportIdent); err != nil {
+			return err
+		}
+		runway.Origin = OriginOverride
+		af.runwayDB.Runways[runway.AirportID] = append(af.runwayDB.Runways[runway.AirportID], runway)
+	case PatchModify:
+		if found == nil {
+			return fmt.Errorf("runway not found")
+		}
+		runway := *found
+		if err := setRecordFields(&runway, patch.Fields); err != nil {
+			return err
+		}
+		runway.Origin = OriginOverride
+		af.removeRunway(found)
+		*found = runway
+		af.runwayDB.Runways[found.AirportID] = append(af.runwayDB.Runways[found.AirportID], found)
+	case PatchDelete:
+		if found == nil {
+			return fmt.Errorf("runway not found")
+		}
+		af.removeRunway(found)
+	default:
+		return fmt.Errorf("unknown action %q", patch.Action)
+	}
+	return nil
+}
+
+func (af *AirportFinder) removeRunway(runway *RunwayData) {
+	runways := af.runwayDB.Runways[runway.AirportID]
+	for i := range runways {
+		if runways[i] == runway {
+			runways = append(runways[:i], runways[i+1:]...)
+			break
+		}
+	}
+	if len(runways) == 0 {
+		delete(af.runwayDB.Runways, runway.AirportID)
+	} else {
+		af.runwayDB.Runways[runway.AirportID] = runways
+	}
+}
+
+func (af *AirportFinder) applyFrequencyPatch(patch *Patch) error {
+	var found *FrequencyData
+	airportIdent, typ := splitPatchIdent(patch.Ident)
+	for _, frequencies := range af.frequencyDB.Frequencies {
+		for _, frequency := range frequencies {
+			if (patch.ID != 0 && frequency.ID == patch.ID) || (patch.ID == 0 && patch.Ident != "" &&
+				frequency.AirportIdent == airportIdent && frequency.Type == typ) {
+				if found != nil {
+					return fmt.Errorf("frequency ident is ambiguous, please use the ID")
+				}
+				found = frequency
+			}
+		}
+	}
+
+	switch patch.Action {
+	case PatchAdd:
+		if found != nil {
+			return fmt.Errorf("frequency already exists")
+		}
+		frequency := &FrequencyData{ID: patch.ID}
+		frequency.AirportIdent, frequency.Type = splitPatchIdent(patch.Ident)
+		if err := setRecordFields(frequency, patch.Fields); err != nil {
+			return err
+		}
+		if frequency.ID == 0 {
+			return fmt.Errorf("missing ID")
+		}
+		if err := af.resolvePatchAirportRef(&frequency.AirportID, &frequency.AirportIdent); err != nil {
+			return err
+		}
+		frequency.Origin = OriginOverride
+		af.frequencyDB.Frequencies[frequency.AirportID] = append(af.frequencyDB.Frequencies[frequency.AirportID], frequency)
+	case PatchModify:
+		if found == nil {
+			return fmt.Errorf("frequency not found")
+		}
+		frequency := *found
+		if err := setRecordFields(&frequency, patch.Fields); err != nil {
+			return err
+		}
+		frequency.Origin = OriginOverride
+		af.removeFrequency(found)
+		*found = frequency
+		af.frequencyDB.Frequencies[found.AirportID] = append(af.frequencyDB.Frequencies[found.AirportID], found)
+	case PatchDelete:
+		if found == nil {
+			return fmt.Errorf("frequency not found")
+		}
+		af.removeFrequency(found)
+	default:
+		return fmt.Errorf("unknown action %q", patch.Action)
+	}
+	return nil
+}
+
+func (af *AirportFinder) removeFrequency(frequency *FrequencyData) {
+	frequencies := af.frequencyDB.Frequencies[frequency.AirportID]
+	for i := range frequencies {
+		if frequencies[i] == frequency {
+			frequencies = append(frequencies[:i], frequencies[i+1:]...)
+			break
+		}
+	}
+	if len(frequencies) == 0 {
+		delete(af.frequencyDB.Frequencies, frequency.AirportID)
+	} else {
+		af.frequencyDB.Frequencies[frequency.AirportID] = frequencies
+	}
+}
+
+func (af *AirportFinder) applyNavaidPatch(patch *Patch) error {
+	index := -1
+	for i, navaid := range af.navaidDB.Navaids {
+		if (patch.ID != 0 && navaid.ID == patch.ID) || (patch.ID == 0 && patch.Ident != "" && navaid.Ident == patch.Ident) {
+			if index >= 0 {
+				return fmt.Errorf("navaid ident is ambiguous, please use the ID")
+			}
+			index = i
+		}
+	}
+
+	switch patch.Action {
+	case PatchAdd:
+		if index >= 0 {
+			return fmt.Errorf("navaid already exists")
+		}
+		navaid := &NavaidData{ID: patch.ID, Ident: patch.Ident}
+		if err := setRecordFields(navaid, patch.Fields); err != nil {
+			return err
+		}
+		if navaid.ID == 0 {
+			return fmt.Errorf("missing ID")
+		}
+		navaid.Origin = OriginOverride
+		af.navaidDB.Navaids = append(af.navaidDB.Navaids, navaid)
+	case PatchModify:
+		if index < 0 {
+			return fmt.Errorf("navaid not found")
+		}
+		navaid := *af.navaidDB.Navaids[index]
+		if err := setRecordFields(&navaid, patch.Fields); err != nil {
+			return err
+		}
+		navaid.Origin = OriginOverride
+		*af.navaidDB.Navaids[index] = navaid
+	case PatchDelete:
+		if index < 0 {
+			return fmt.Errorf("navaid not found")
+		}
+		af.navaidDB.Navaids = append(af.navaidDB.Navaids[:index], af.navaidDB.Navaids[index+1:]...)
+	default:
+		return fmt.Errorf("unknown action %q", patch.Action)
+	}
+	return nil
+}
+
+// resolvePatchAirportRef fills in the airport ID or ident of an added runway or frequency if only one of them was given
+func (af *AirportFinder) resolvePatchAirportRef(airportID *uint64, airportIdent *string) error {
+	for _, airport := range af.airportDB.Airports {
+		if (*airportID != 0 && airport.ID == *airportID) || (*airportID == 0 && airport.ICAOCode == *airportIdent) {
+			*airportID = airport.ID
+			*airportIdent = airport.ICAOCode
+			return nil
+		}
+	}
+	return fmt.Errorf("airport %d %s not found", *airportID, *airportIdent)
+}
+
+func setAirportTypeFlag(airport *AirportData) error {
+	if airport.Type == "" {
+		return fmt.Errorf("missing Type")
+	}
+	airport.TypeFlag = AirportTypeFromString(airport.Type)
+	if airport.TypeFlag == AirportTypeUnknown {
+		return fmt.Errorf("unknown Type %q", airport.Type)
+	}
+	return nil
+}
+
+func splitPatchIdent(ident string) (string, string) {
+	parts := strings.SplitN(ident, ":", 2)
+	if len(parts) < 2 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+func setRecordFields(record interface{}, fields map[string]interface{}) error {
+	value := reflect.ValueOf(record).Elem()
+	for name, fieldValue := range fields {
+		matched := ""
+		field := value.FieldByNameFunc(func(fieldName string) bool {
+			if strings.EqualFold(fieldName, name) {
+				matched = fieldName
+				return true
+			}
+			return false
+		})
+		if !field.IsValid() || matched == "TypeFlag" || matched == "Origin" {
+			return fmt.Errorf("unknown field %q", name)
+		}
+		if err := setRecordField(field, fieldValue); err != nil {
+			return fmt.Errorf("field %q: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func setRecordField(field reflect.Value, value interface{}) error {
+	str := fmt.Sprint(value)
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(str)
+	case reflect.Bool:
+		if b, ok := value.(bool); ok {
+			field.SetBool(b)
+		} else {
+			field.SetBool(ParseBool(str))
+		}
+	case reflect.Int64:
+		i, err := ParseInt(str)
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint64:
+		u, err := ParseUint(str)
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Float64:
+		f, err := ParseFloat(str)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Kind())
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package alphafoxtrot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testDataDir = "testdata/ourairports"
+
+func loadTestFinder(t *testing.T, airportFilter uint64) *AirportFinder {
+	t.Helper()
+	finder := NewAirportFinder()
+	if errs := finder.Load(PresetLoadOptions(testDataDir), airportFilter); len(errs) > 0 {
+		t.Fatalf("load: %v", errs)
+	}
+	return finder
+}
+
+func TestParsePatchCSV(t *testing.T) {
+	csv := `action,kind,id,ident,field,value
+add,airport,9000001,,ICAOCode,XPVT
+add,airport,9000001,,Type,small_airport
+modify,runway,,KSMO:03,Closed,true
+delete,frequency,60768,,,
+`
+	patches, err := ParsePatchCSV(strings.NewReader(csv), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patches) != 3 {
+		t.Fatalf("got %d patches, want 3", len(patches))
+	}
+	if patches[0].ID != 9000001 || patches[0].Fields["ICAOCode"] != "XPVT" || patches[0].Fields["Type"] != "small_airport" {
+		t.Errorf("rows of the same record were not merged: %+v", patches[0])
+	}
+	if patches[1].Ident != "KSMO:03" || patches[2].Action != PatchDelete || patches[2].Fields != nil {
+		t.Errorf("unexpected patches: %+v", patches[1:])
+	}
+}
+
+func TestApplyPatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   Patch
+		wantErr string
+		check   func(t *testing.T, finder *AirportFinder)
+	}{
+		{
+			name:  "modify runway",
+			patch: Patch{Action: PatchModify, Kind: RecordKindRunway, Ident: "KSMO:03", Fields: map[string]interface{}{"Closed": true}},
+			check: func(t *testing.T, finder *AirportFinder) {
+				runways := finder.FindAirportByICAOCode("KSMO").Runways
+				if len(runways) != 1 || !runways[0].Closed || runways[0].Origin != OriginOverride {
+					t.Errorf("runway not patched: %+v", runways)
+				}
+			},
+		},
+		{
+			name:    "bad runway field keeps the runway",
+			patch:   Patch{Action: PatchModify, Kind: RecordKindRunway, ID: 241412, Fields: map[string]interface{}{"Closed": true, "LengthFt": "long"}},
+			wantErr: `field "LengthFt"`,
+			check: func(t *testing.T, finder *AirportFinder) {
+				runways := finder.FindAirportByICAOCode("KSMO").Runways
+				if len(runways) != 1 || runways[0].Closed || runways[0].LengthFt != 3500 || runways[0].Origin != OriginBase {
+					t.Errorf("runway changed: %+v", runways)
+				}
+			},
+		},
+		{
+			name:    "bad frequency field keeps the frequency",
+			patch:   Patch{Action: PatchModify, Kind: RecordKindFrequency, Ident: "KLAX:ATIS", Fields: map[string]interface{}{"Description": "D-ATIS", "FrequencyMHZ": "high"}},
+			wantErr: `field "FrequencyMHZ"`,
+			check: func(t *testing.T, finder *AirportFinder) {
+				frequencies := finder.FindAirportByICAOCode("KLAX").Frequencies
+				if len(frequencies) != 2 {
+					t.Fatalf("got %d frequencies, want 2", len(frequencies))
+				}
+				for _, frequency := range frequencies {
+					if frequency.Type == "ATIS" && (frequency.Description != "ATIS" || frequency.FrequencyMHZ != 133.8) {
+						t.Errorf("frequency changed: %+v", frequency)
+					}
+				}
+			},
+		},
+		{
+			name:    "bad airport field keeps the airport",
+			patch:   Patch{Action: PatchModify, Kind: RecordKindAirport, Ident: "KLGB", Fields: map[string]interface{}{"Name": "Long Beach", "ElevationFt": "low"}},
+			wantErr: `field "ElevationFt"`,
+			check: func(t *testing.T, finder *AirportFinder) {
+				if airport := finder.FindAirportByICAOCode("KLGB"); airport.Name != "Long Beach Airport (Daugherty Field)" {
+					t.Errorf("airport changed: %s", airport.Name)
+				}
+			},
+		},
+		{
+			name:    "added airport without type",
+			patch:   Patch{Action: PatchAdd, Kind: RecordKindAirport, ID: 9000001, Ident: "XPVT", Fields: map[string]interface{}{"LatitudeDeg": 47.1, "LongitudeDeg": 8.2}},
+			wantErr: "missing Type",
+			check: func(t *testing.T, finder *AirportFinder) {
+				if finder.FindAirportByICAOCode("XPVT") != nil {
+					t.Error("airport without type was added")
+				}
+			},
+		},
+		{
+			name:  "add airport",
+			patch: Patch{Action: PatchAdd, Kind: RecordKindAirport, ID: 9000001, Ident: "XPVT", Fields: map[string]interface{}{"Type": "small_airport", "LatitudeDeg": 47.1, "LongitudeDeg": 8.2}},
+			check: func(t *testing.T, finder *AirportFinder) {
+				airport := finder.FindAirportByICAOCode("XPVT")
+				if airport == nil || airport.Origin != OriginOverride || airport.LatitudeDeg != 47.1 {
+					t.Errorf("airport not added: %+v", airport)
+				}
+				if nearest := finder.FindNearestAirport(47.1, 8.2, 1000, AirportTypeAll); nearest == nil || nearest.ICAOCode != "XPVT" {
+					t.Errorf("added airport is not indexed: %+v", nearest)
+				}
+			},
+		},
+		{
+			name:  "delete airport",
+			patch: Patch{Action: PatchDelete, Kind: RecordKindAirport, Ident: "KSMO"},
+			check: func(t *testing.T, finder *AirportFinder) {
+				if finder.FindAirportByICAOCode("KSMO") != nil || len(finder.runwayDB.FindByAirportID(3878)) != 0 {
+					t.Error("airport or its runways still present")
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			finder := loadTestFinder(t, AirportTypeAll)
+			errs := finder.ApplyPatches([]Patch{test.patch}, AirportTypeAll)
+			if test.wantErr == "" && len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if test.wantErr != "" && (len(errs) != 1 || !strings.Contains(errs[0].Error(), test.wantErr)) {
+				t.Fatalf("got errors %v, want %q", errs, test.wantErr)
+			}
+			test.check(t, finder)
+		})
+	}
+}
+
+func TestApplyPatchesReportsFilteredAddedAirport(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeLarge)
+	patch := Patch{Action: PatchAdd, Kind: RecordKindAirport, ID: 9000001, Ident: "XPVT", Fields: map[string]interface{}{"Type": "small_airport"}}
+	errs := finder.ApplyPatches([]Patch{patch}, AirportTypeLarge)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "excluded by the airport type filter") {
+		t.Fatalf("got errors %v", errs)
+	}
+	if finder.FindAirportByICAOCode("XPVT") != nil {
+		t.Error("filtered airport was kept")
+	}
+}
+
+func TestLoadPatchesAirportsExcludedByFilter(t *testing.T) {
+	overrides := filepath.Join(t.TempDir(), "overrides.json")
+	data := `{"patches": [{"action": "modify", "kind": "airport", "ident": "KSMO", "fields": {"Type": "large_airport"}}]}`
+	if err := os.WriteFile(overrides, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	options := PresetLoadOptions(testDataDir)
+	options.OverridesFilename = overrides
+	finder := NewAirportFinder()
+	if errs := finder.Load(options, AirportTypeLarge); len(errs) > 0 {
+		t.Fatalf("load: %v", errs)
+	}
+	if airport := finder.FindAirportByICAOCode("KSMO"); airport == nil || airport.Type != "large_airport" {
+		t.Errorf("KSMO was not patched: %+v", airport)
+	}
+	if finder.FindAirportByICAOCode("KLGB") != nil {
+		t.Error("KLGB should be excluded by the filter")
+	}
+}
+
+func TestApplyPatchesAmbiguousIdents(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	errs := finder.ApplyPatches([]Patch{
+		{Action: PatchAdd, Kind: RecordKindFrequency, ID: 9000010, Ident: "KLAX:TWR", Fields: map[string]interface{}{"Description": "TWR SOUTH", "FrequencyMHZ": 120.95}},
+		{Action: PatchAdd, Kind: RecordKindRunway, ID: 9000020, Ident: "KLAX:07L", Fields: map[string]interface{}{"HighEndIdent": "25R", "LengthFt": 3000}},
+	}, AirportTypeAll)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	errs = finder.ApplyPatches([]Patch{
+		{Action: PatchModify, Kind: RecordKindFrequency, Ident: "KLAX:TWR", Fields: map[string]interface{}{"Description": "TOWER"}},
+		{Action: PatchDelete, Kind: RecordKindRunway, Ident: "KLAX:25R"},
+	}, AirportTypeAll)
+	if len(errs) != 2 || !strings.Contains(errs[0].Error(), "frequency ident is ambiguous") || !strings.Contains(errs[1].Error(), "runway ident is ambiguous") {
+		t.Fatalf("got errors %v, want ambiguous idents", errs)
+	}
+	airport := finder.FindAirportByICAOCode("KLAX")
+	if len(airport.Runways) != 3 {
+		t.Errorf("got %d runways, want 3", len(airport.Runways))
+	}
+	for _, frequency := range airport.Frequencies {
+		if frequency.Description == "TOWER" {
+			t.Errorf("ambiguous frequency was patched: %+v", frequency)
+		}
+	}
+
+	errs = finder.ApplyPatches([]Patch{
+		{Action: PatchModify, Kind: RecordKindFrequency, ID: 9000010, Fields: map[string]interface{}{"Description": "TOWER"}},
+	}, AirportTypeAll)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	for _, frequency := range finder.FindAirportByICAOCode("KLAX").Frequencies {
+		if (frequency.Description == "TOWER") != (frequency.FrequencyMHZ == 120.95) {
+			t.Errorf("wrong frequency patched: %+v", frequency)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package alphafoxtrot
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+const StandardAltimeterInHg = 29.92
+
+// pavedSurfaces are the prefixes of the surface codes and names of paved runways
+var pavedSurfaces = []string{"ASP", "CON", "PEM", "BIT", "TAR", "PAVED", "ASPH", "CONC"}
+
+// PressureAltitudeFt returns the pressure altitude for the elevation and altimeter setting
+func PressureAltitudeFt(elevationFt, altimeterInHg float64) float64 {
+	return elevationFt + (StandardAltimeterInHg-altimeterInHg)*1000
+}
+
+// ISATemperatureC returns the standard temperature at the pressure altitude
+func ISATemperatureC(pressureAltitudeFt float64) float64 {
+	return 15 - 1.98*pressureAltitudeFt/1000
+}
+
+// DensityAltitudeFt returns the density altitude using the 120 ft per degree rule
+func DensityAltitudeFt(pressureAltitudeFt, temperatureC float64) float64 {
+	return pressureAltitudeFt + 118.8*(temperatureC-ISATemperatureC(pressureAltitudeFt))
+}
+
+// RunwaySlopePercent returns the slope from the low end to the high end, positive is uphill.
+// The slope is unknown if the length or an end elevation is missing. The runway data has no presence flag for
+// the end elevations, missing values are 0, so runways with an end at exactly 0 ft (sea level) count as unknown too.
+func RunwaySlopePercent(runway *Runway) (float64, bool) {
+	if runway.LengthFt <= 0 || runway.LowEndElevationFt == 0 || runway.HighEndElevationFt == 0 {
+		return 0, false
+	}
+	return float64(runway.HighEndElevationFt-runway.LowEndElevationFt) / float64(runway.LengthFt) * 100, true
+}
+
+// IsPavedSurface returns true for asphalt, concrete and similar surfaces
+func IsPavedSurface(surface string) bool {
+	surface = strings.ToUpper(strings.TrimSpace(surface))
+	for _, paved := range pavedSurfaces {
+		if strings.HasPrefix(surface, paved) {
+			return true
+		}
+	}
+	return false
+}
+
+// TakeoffConditions are the conditions for a takeoff in one runway direction
+type TakeoffConditions struct {
+	PressureAltitudeFt float64
+	DensityAltitudeFt  float64
+	TemperatureC       float64
+	SlopePercent       float64 // positive is uphill in the direction of the takeoff
+	HeadwindKt         float64 // negative is a tailwind
+	Paved              bool
+}
+
+// TakeoffModel estimates the takeoff distance of an aircraft
+type TakeoffModel interface {
+	TakeoffDistanceFt(conditions *TakeoffConditions) float64
+}
+
+// TakeoffModelFunc adapts a function to the TakeoffModel interface
+type TakeoffModelFunc func(conditions *TakeoffConditions) float64
+
+func (fn TakeoffModelFunc) TakeoffDistanceFt(conditions *TakeoffConditions) float64 {
+	return fn(conditions)
+}
+
+// SimpleTakeoffModel scales a sea level ISA takeoff distance with rules of thumb
+type SimpleTakeoffModel struct {
+	SeaLevelDistanceFt    float64 // distance to clear an obstacle at sea level, ISA, no wind, paved runway
+	DensityAltitudeFactor float64 // increase per 1000 ft density altitude, e.g. 0.12
+	UphillFactor          float64 // increase per percent upslope, e.g. 0.10
+	HeadwindFactorPerKnot float64 // decrease per knot headwind, e.g. 0.01
+	TailwindFactorPerKnot float64 // increase per knot tailwind, e.g. 0.05
+	UnpavedFactor         float64 // increase on grass, gravel or dirt, e.g. 0.20
+	SafetyFactor          float64 // applied to the result, 1 if 0
+}
+
+func NewSimpleTakeoffModel(seaLevelDistanceFt float64) *SimpleTakeoffModel {
+	return &SimpleTakeoffModel{
+		SeaLevelDistanceFt:    seaLevelDistanceFt,
+		DensityAltitudeFactor: 0.12,
+		UphillFactor:          0.10,
+		HeadwindFactorPerKnot: 0.01,
+		TailwindFactorPerKnot: 0.05,
+		UnpavedFactor:         0.20,
+		SafetyFactor:          1.15,
+	}
+}
+
+func (m *SimpleTakeoffModel) TakeoffDistanceFt(conditions *TakeoffConditions) float64 {
+	distance := m.SeaLevelDistanceFt * math.Pow(1+m.DensityAltitudeFactor, math.Max(conditions.DensityAltitudeFt, 0)/1000)
+	if conditions.SlopePercent > 0 {
+		distance *= 1 + m.UphillFactor*conditions.SlopePercent
+	}
+	// only half of the headwind is taken into account
+	if conditions.HeadwindKt > 0 {
+		distance *= math.Max(1-m.HeadwindFactorPerKnot*conditions.HeadwindKt/2, 0.5)
+	} else {
+		distance *= 1 - m.TailwindFactorPerKnot*conditions.HeadwindKt
+	}
+	if !conditions.Paved {
+		distance *= 1 + m.UnpavedFactor
+	}
+	if m.SafetyFactor > 0 {
+		distance *= m.SafetyFactor
+	}
+	return distance
+}
+
+// DefaultTakeoffDistanceFt is the sea level distance of the model used if none is given, about that of a light single
+const DefaultTakeoffDistanceFt = 1500
+
+// PerformanceOptions configure the takeoff check; the temperature and altimeter setting are used if there is no METAR.
+// Without a temperature the ISA temperature at the pressure altitude is used, which is optimistic on hot days.
+type PerformanceOptions struct {
+	Model         TakeoffModel // NewSimpleTakeoffModel(DefaultTakeoffDistanceFt) if nil
+	TemperatureC  *float64     // ISA if nil
+	AltimeterInHg float64      // 29.92 if 0
+	IgnoreMETAR   bool
+}
+
+// RunwayPerformance is the takeoff check for one runway direction
+type RunwayPerformance struct {
+	Ident        string
+	Runway       Runway
+	SlopePercent float64
+	HeadwindKt   float64
+	RequiredFt   float64
+	AvailableFt  float64
+	CanDepart    bool
+}
+
+type AirportPerformance struct {
+	Airport            *Airport
+	TemperatureC       float64
+	AltimeterInHg      float64
+	PressureAltitudeFt float64
+	DensityAltitudeFt  float64
+	Runways            []RunwayPerformance
+	CanDepart          bool
+}
+
+// CheckTakeoffPerformance checks every open runway direction of the airport, using the airport's METAR if it has one.
+// The options may be nil.
+func CheckTakeoffPerformance(airport *Airport, options *PerformanceOptions) *AirportPerformance {
+	if options == nil {
+		options = &PerformanceOptions{}
+	}
+	model := options.Model
+	if model == nil {
+		model = NewSimpleTakeoffModel(DefaultTakeoffDistanceFt)
+	}
+	temperatureC, altimeterInHg := options.TemperatureC, options.AltimeterInHg
+	if altimeterInHg == 0 {
+		altimeterInHg = StandardAltimeterInHg
+	}
+	var wind *Wind
+	if metar := airport.METAR; metar != nil && !options.IgnoreMETAR {
+		if metar.HasTemperature {
+			temperatureC = &metar.TemperatureC
+		}
+		if metar.AltimeterHPa > 0 {
+			altimeterInHg = metar.AltimeterInHg()
+		}
+		wind = metar.Wind
+	}
+	pressureAltitude := PressureAltitudeFt(float64(airport.ElevationFt), altimeterInHg)
+	temperature := ISATemperatureC(pressureAltitude)
+	if temperatureC != nil {
+		temperature = *temperatureC
+	}
+	performance := &AirportPerformance{
+		Airport:            airport,
+		TemperatureC:       temperature,
+		AltimeterInHg:      altimeterInHg,
+		PressureAltitudeFt: pressureAltitude,
+		DensityAltitudeFt:  DensityAltitudeFt(pressureAltitude, temperature),
+		Runways:            make([]RunwayPerformance, 0),
+	}
+	for i := range airport.Runways {
+		runway := &airport.Runways[i]
+		if runway.Closed || runway.LengthFt <= 0 {
+			continue
+		}
+		slope, _ := RunwaySlopePercent(runway)
+		directions := []struct {
+			ident      string
+			headingDeg float64
+			slope      float64
+		}{
+			{runway.LowEndIdent, runway.LowEndHeadingDegT, slope},
+			{runway.HighEndIdent, runway.HighEndHeadingDegT, -slope},
+		}
+		for _, direction := range directions {
+			if direction.ident == "" {
+				continue
+			}
+			headwind := headwindKt(wind, direction.headingDeg, direction.ident)
+			conditions := &TakeoffConditions{
+				PressureAltitudeFt: pressureAltitude,
+				DensityAltitudeFt:  performance.DensityAltitudeFt,
+				TemperatureC:       temperature,
+				SlopePercent:       direction.slope,
+				HeadwindKt:         headwind,
+				Paved:              IsPavedSurface(runway.Surface),
+			}
+			required := model.TakeoffDistanceFt(conditions)
+			result := RunwayPerformance{
+				Ident:        direction.ident,
+				Runway:       *runway,
+				SlopePercent: direction.slope,
+				HeadwindKt:   headwind,
+				RequiredFt:   required,
+				AvailableFt:  float64(runway.LengthFt),
+				CanDepart:    required <= float64(runway.LengthFt),
+			}
+			performance.Runways = append(performance.Runways, result)
+			performance.CanDepart = performance.CanDepart || result.CanDepart
+		}
+	}
+	return performance
+}
+
+// headwindKt returns the headwind component, the heading is taken from the runway ident if it is missing
+func headwindKt(wind *Wind, headingDeg float64, ident string) float64 {
+	if wind == nil || wind.Variable || wind.SpeedKt == 0 {
+		return 0
+	}
+	if headingDeg == 0 {
+		number, err := strconv.Atoi(strings.TrimRight(ident, "LCRW"))
+		if err != nil || number < 1 || number > 36 {
+			return 0
+		}
+		headingDeg = float64(number * 10)
+	}
+	return float64(wind.SpeedKt) * math.Cos((float64(wind.DirectionDeg)-headingDeg)*DegToRad)
+}
+
+// FindNearestAirportsWithPerformance returns the nearest airports with their takeoff checks, weather-adjusted if METARs are loaded
+func (af *AirportFinder) FindNearestAirportsWithPerformance(latitudeDeg, longitudeDeg, radiusMeters float64, maxResults int, airportTypeFilter uint64, options *PerformanceOptions) []*AirportPerformance {
+	airports := af.FindNearestAirports(latitudeDeg, longitudeDeg, radiusMeters, maxResults, airportTypeFilter)
+	performances := make([]*AirportPerformance, 0, len(airports))
+	for _, airport := range airports {
+		performances = append(performances, CheckTakeoffPerformance(airport, options))
+	}
+	return performances
+}
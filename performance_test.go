@@ -0,0 +1,183 @@
+package alphafoxtrot
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRunwaySlopePercent(t *testing.T) {
+	tests := []struct {
+		runway Runway
+		slope  float64
+		known  bool
+	}{
+		{Runway{LengthFt: 3500, LowEndElevationFt: 171, HighEndElevationFt: 174}, 3.0 / 35, true},
+		{Runway{LengthFt: 1000, LowEndElevationFt: 120, HighEndElevationFt: 100}, -2, true},
+		{Runway{LengthFt: 1000, LowEndElevationFt: 120}, 0, false},
+		// 0 ft is indistinguishable from a missing elevation
+		{Runway{LengthFt: 1000, LowEndElevationFt: 0, HighEndElevationFt: 10}, 0, false},
+		{Runway{LowEndElevationFt: 120, HighEndElevationFt: 100}, 0, false},
+	}
+	for _, test := range tests {
+		slope, known := RunwaySlopePercent(&test.runway)
+		if known != test.known || math.Abs(slope-test.slope) > 1e-9 {
+			t.Errorf("%+v: got %f %v", test.runway, slope, known)
+		}
+	}
+}
+
+func TestCheckTakeoffPerformanceDefaults(t *testing.T) {
+	airport := &Airport{ICAOCode: "KSMO", ElevationFt: 177, Runways: []Runway{
+		{LengthFt: 3500, Surface: "ASP", LowEndIdent: "03", HighEndIdent: "21", LowEndElevationFt: 171, HighEndElevationFt: 174},
+	}}
+	want := CheckTakeoffPerformance(airport, &PerformanceOptions{Model: NewSimpleTakeoffModel(DefaultTakeoffDistanceFt)})
+	for _, options := range []*PerformanceOptions{nil, {}} {
+		performance := CheckTakeoffPerformance(airport, options)
+		if len(performance.Runways) != 2 || !performance.CanDepart {
+			t.Fatalf("%+v: got %+v", options, performance)
+		}
+		for i, runway := range performance.Runways {
+			if runway.RequiredFt != want.Runways[i].RequiredFt {
+				t.Errorf("%+v: %s requires %f ft, want %f", options, runway.Ident, runway.RequiredFt, want.Runways[i].RequiredFt)
+			}
+		}
+	}
+}
+
+func TestPressureAndDensityAltitude(t *testing.T) {
+	tests := []struct {
+		elevationFt, altimeterInHg, temperatureC float64
+		pressureAltitudeFt, densityAltitudeFt    float64
+	}{
+		{0, 29.92, 15, 0, 0},
+		{0, 30.92, 15, -1000, -1000 + 118.8*(15-16.98)},
+		{5000, 29.92, 5.1, 5000, 5000},
+		{5000, 29.42, 35, 5500, 5500 + 118.8*(35-(15-1.98*5.5))},
+		{177, 29.92, -10, 177, 177 + 118.8*(-10-(15-1.98*0.177))},
+	}
+	for _, test := range tests {
+		pressureAltitude := PressureAltitudeFt(test.elevationFt, test.altimeterInHg)
+		if math.Abs(pressureAltitude-test.pressureAltitudeFt) > 1e-6 {
+			t.Errorf("%+v: got pressure altitude %f", test, pressureAltitude)
+		}
+		if densityAltitude := DensityAltitudeFt(pressureAltitude, test.temperatureC); math.Abs(densityAltitude-test.densityAltitudeFt) > 1e-6 {
+			t.Errorf("%+v: got density altitude %f", test, densityAltitude)
+		}
+	}
+	if isa := ISATemperatureC(0); isa != 15 {
+		t.Errorf("got ISA %f at sea level", isa)
+	}
+}
+
+func TestHeadwindKt(t *testing.T) {
+	tests := []struct {
+		name       string
+		wind       *Wind
+		headingDeg float64
+		ident      string
+		want       float64
+	}{
+		{"no wind", nil, 30, "03", 0},
+		{"calm", &Wind{DirectionDeg: 30}, 30, "03", 0},
+		{"variable", &Wind{Variable: true, SpeedKt: 5}, 30, "03", 0},
+		{"headwind", &Wind{DirectionDeg: 30, SpeedKt: 10}, 30, "03", 10},
+		{"tailwind", &Wind{DirectionDeg: 210, SpeedKt: 10}, 30, "03", -10},
+		{"crosswind", &Wind{DirectionDeg: 120, SpeedKt: 10}, 30, "03", 0},
+		{"60 degrees", &Wind{DirectionDeg: 90, SpeedKt: 10}, 30, "03", 5},
+		{"heading from ident", &Wind{DirectionDeg: 250, SpeedKt: 12}, 0, "25L", 12},
+		{"tailwind from ident", &Wind{DirectionDeg: 250, SpeedKt: 12}, 0, "07R", -12},
+		{"helipad ident", &Wind{DirectionDeg: 250, SpeedKt: 12}, 0, "H1", 0},
+	}
+	for _, test := range tests {
+		if got := headwindKt(test.wind, test.headingDeg, test.ident); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%s: got %f, want %f", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSimpleTakeoffModel(t *testing.T) {
+	model := NewSimpleTakeoffModel(1000)
+	model.SafetyFactor = 0
+	tests := []struct {
+		name       string
+		conditions TakeoffConditions
+		want       float64
+	}{
+		{"sea level ISA", TakeoffConditions{Paved: true}, 1000},
+		{"below sea level", TakeoffConditions{DensityAltitudeFt: -1000, Paved: true}, 1000},
+		{"density altitude", TakeoffConditions{DensityAltitudeFt: 2000, Paved: true}, 1000 * 1.12 * 1.12},
+		{"uphill", TakeoffConditions{SlopePercent: 2, Paved: true}, 1200},
+		{"downhill", TakeoffConditions{SlopePercent: -2, Paved: true}, 1000},
+		{"headwind", TakeoffConditions{HeadwindKt: 20, Paved: true}, 900},
+		{"strong headwind", TakeoffConditions{HeadwindKt: 200, Paved: true}, 500},
+		{"tailwind", TakeoffConditions{HeadwindKt: -4, Paved: true}, 1200},
+		{"unpaved", TakeoffConditions{}, 1200},
+	}
+	for _, test := range tests {
+		if got := model.TakeoffDistanceFt(&test.conditions); math.Abs(got-test.want) > 1e-6 {
+			t.Errorf("%s: got %f, want %f", test.name, got, test.want)
+		}
+	}
+	model.SafetyFactor = 1.15
+	if got := model.TakeoffDistanceFt(&TakeoffConditions{Paved: true}); math.Abs(got-1150) > 1e-6 {
+		t.Errorf("safety factor: got %f, want 1150", got)
+	}
+}
+
+func TestCheckTakeoffPerformanceTemperature(t *testing.T) {
+	airport := &Airport{ICAOCode: "KSMO", ElevationFt: 177, Runways: []Runway{
+		{LengthFt: 3500, Surface: "ASP", LowEndIdent: "03", HighEndIdent: "21"},
+	}}
+	// without a temperature the density altitude equals the pressure altitude (ISA)
+	performance := CheckTakeoffPerformance(airport, nil)
+	if math.Abs(performance.DensityAltitudeFt-177) > 1e-6 || math.Abs(performance.TemperatureC-ISATemperatureC(177)) > 1e-9 {
+		t.Errorf("ISA: got %f °C and density altitude %f", performance.TemperatureC, performance.DensityAltitudeFt)
+	}
+
+	hot := 40.0
+	options := &PerformanceOptions{Model: NewSimpleTakeoffModel(2500), TemperatureC: &hot}
+	performance = CheckTakeoffPerformance(airport, options)
+	if performance.TemperatureC != 40 || performance.DensityAltitudeFt < 3000 || performance.CanDepart {
+		t.Errorf("hot day: got %+v", performance)
+	}
+
+	metar, err := ParseMETAR("KSMO 011751Z 21010KT 10SM FEW015 10/05 A3012", testWeatherTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	airport.METAR = metar
+	performance = CheckTakeoffPerformance(airport, options)
+	if performance.TemperatureC != 10 || math.Abs(performance.AltimeterInHg-30.12) > 0.005 || performance.PressureAltitudeFt > 0 {
+		t.Errorf("METAR: got %+v", performance)
+	}
+	if performance.Runways[0].HeadwindKt >= 0 || performance.Runways[1].HeadwindKt <= 0 || performance.Runways[0].RequiredFt <= performance.Runways[1].RequiredFt {
+		t.Errorf("METAR wind: got %+v", performance.Runways)
+	}
+	options.IgnoreMETAR = true
+	if performance = CheckTakeoffPerformance(airport, options); performance.TemperatureC != 40 {
+		t.Errorf("ignored METAR: got %f °C", performance.TemperatureC)
+	}
+}
+
+func TestFindNearestAirportsWithPerformance(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	temperature := 30.0
+	options := &PerformanceOptions{Model: NewSimpleTakeoffModel(3000), TemperatureC: &temperature}
+	performances := finder.FindNearestAirportsWithPerformance(33.94, -118.41, KilometersToMeters(30), -1, AirportTypeRunways, options)
+	canDepart := make(map[string]bool)
+	for _, performance := range performances {
+		canDepart[performance.Airport.ICAOCode] = performance.CanDepart
+	}
+	want := map[string]bool{"KLAX": true, "KSMO": false, "KLGB": true}
+	if len(canDepart) != len(want) {
+		t.Fatalf("got %v, want %v", canDepart, want)
+	}
+	for icaoCode, ok := range want {
+		if canDepart[icaoCode] != ok {
+			t.Errorf("%s: got %v, want %v", icaoCode, canDepart[icaoCode], ok)
+		}
+	}
+	if performances[0].Airport.ICAOCode != "KLAX" || len(performances[0].Runways) != 4 {
+		t.Errorf("unexpected nearest airport %+v", performances[0])
+	}
+}
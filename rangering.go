@@ -0,0 +1,352 @@
+package alphafoxtrot
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultRangeRingSegments is the number of ring segments if 0 is passed, one every 5 degrees
+const DefaultRangeRingSegments = 72
+
+const (
+	RecordKindRangeRing = "range_ring"
+	RecordKindCoverage  = "coverage"
+)
+
+// AirportInRange is an airport within range of one or more bases, the distance and bearing are from the nearest base
+type AirportInRange struct {
+	Airport        *Airport
+	Base           string
+	Bases          []string
+	DistanceMeters float64
+	BearingDeg     float64
+}
+
+// RangeRing is the geodesic circle around a base and the airports inside it, except the base itself
+type RangeRing struct {
+	Base         *Airport
+	RadiusMeters float64
+	Ring         [][]float64
+	Airports     []*AirportInRange
+}
+
+// Coverage is the union of the range rings of several bases and the airports within range of at least one of them.
+// Like in RangeRing the bases are not listed in Airports, even if they are within range of other bases.
+type Coverage struct {
+	Bases        []*Airport
+	RadiusMeters float64
+	Rings        []*RangeRing
+	Polygons     [][][][]float64 // MultiPolygon coordinates
+	Airports     []*AirportInRange
+}
+
+// RangeRingPolygon returns a closed counterclockwise ring of GeoJSON positions around the position.
+// Longitudes are continuous around the center, i.e. rings crossing the antimeridian have longitudes beyond ±180,
+// and rings around a pole are not supported.
+func RangeRingPolygon(latitudeDeg, longitudeDeg, radiusMeters float64, segments int) [][]float64 {
+	ring := rangeRingPoints(latitudeDeg, longitudeDeg, radiusMeters, segments)
+	return append(ring, ring[0])
+}
+
+// rangeRingPoints returns the open ring, starting north and going counterclockwise
+func rangeRingPoints(latitudeDeg, longitudeDeg, radiusMeters float64, segments int) [][]float64 {
+	if segments <= 0 {
+		segments = DefaultRangeRingSegments
+	}
+	ring := make([][]float64, 0, segments+1)
+	for i := 0; i < segments; i++ {
+		lat, lon := Destination(latitudeDeg, longitudeDeg, -360*float64(i)/float64(segments), radiusMeters)
+		if lon-longitudeDeg > 180 {
+			lon -= 360
+		} else if lon-longitudeDeg < -180 {
+			lon += 360
+		}
+		ring = append(ring, geoJSONPosition(lat, lon))
+	}
+	return ring
+}
+
+// FindRangeRing returns the ring around the airport and the airports inside it, nil if the airport is unknown
+func (af *AirportFinder) FindRangeRing(icaoCode string, radiusMeters float64, segments int, airportTypeFilter uint64) *RangeRing {
+	base := af.FindAirportByICAOCode(icaoCode)
+	if base == nil {
+		return nil
+	}
+	return af.rangeRing(base, radiusMeters, segments, airportTypeFilter)
+}
+
+func (af *AirportFinder) rangeRing(base *Airport, radiusMeters float64, segments int, airportTypeFilter uint64) *RangeRing {
+	rangeRing := &RangeRing{
+		Base:         base,
+		RadiusMeters: radiusMeters,
+		Ring:         RangeRingPolygon(base.LatitudeDeg, base.LongitudeDeg, radiusMeters, segments),
+		Airports:     make([]*AirportInRange, 0),
+	}
+	airports := af.FindNearestAirports(base.LatitudeDeg, base.LongitudeDeg, radiusMeters, -1, airportTypeFilter)
+	for _, airport := range airports {
+		if airport.ID == base.ID {
+			continue
+		}
+		rangeRing.Airports = append(rangeRing.Airports, &AirportInRange{
+			Airport:        airport,
+			Base:           base.ICAOCode,
+			Bases:          []string{base.ICAOCode},
+			DistanceMeters: Distance(base.LatitudeDeg, base.LongitudeDeg, airport.LatitudeDeg, airport.LongitudeDeg),
+			BearingDeg:     Bearing(base.LatitudeDeg, base.LongitudeDeg, airport.LatitudeDeg, airport.LongitudeDeg),
+		})
+	}
+	return rangeRing
+}
+
+// FindCoverage returns the coverage of the bases, an error is returned if a base is unknown
+func (af *AirportFinder) FindCoverage(baseICAOCodes []string, radiusMeters float64, segments int, airportTypeFilter uint64) (*Coverage, error) {
+	coverage := &Coverage{
+		Bases:        make([]*Airport, 0, len(baseICAOCodes)),
+		RadiusMeters: radiusMeters,
+		Rings:        make([]*RangeRing, 0, len(baseICAOCodes)),
+		Airports:     make([]*AirportInRange, 0),
+	}
+	bases := make(map[uint64]bool)
+	for _, icaoCode := range baseICAOCodes {
+		base := af.FindAirportByICAOCode(icaoCode)
+		if base == nil {
+			return nil, fmt.Errorf("coverage: unknown base %s", icaoCode)
+		}
+		if bases[base.ID] {
+			continue
+		}
+		bases[base.ID] = true
+		coverage.Bases = append(coverage.Bases, base)
+		coverage.Rings = append(coverage.Rings, af.rangeRing(base, radiusMeters, segments, airportTypeFilter))
+	}
+
+	covered := make(map[uint64]*AirportInRange)
+	for _, rangeRing := range coverage.Rings {
+		for _, inRange := range rangeRing.Airports {
+			if bases[inRange.Airport.ID] {
+				continue
+			}
+			existing, ok := covered[inRange.Airport.ID]
+			if !ok {
+				covered[inRange.Airport.ID] = inRange
+				coverage.Airports = append(coverage.Airports, inRange)
+				continue
+			}
+			existing.Bases = append(existing.Bases, inRange.Base)
+			if inRange.DistanceMeters < existing.DistanceMeters {
+				existing.Base, existing.DistanceMeters, existing.BearingDeg = inRange.Base, inRange.DistanceMeters, inRange.BearingDeg
+			}
+		}
+	}
+	sort.SliceStable(coverage.Airports, func(i, j int) bool {
+		return coverage.Airports[i].DistanceMeters < coverage.Airports[j].DistanceMeters
+	})
+
+	centers := make([][]float64, 0, len(coverage.Bases))
+	for _, base := range coverage.Bases {
+		centers = append(centers, []float64{base.LatitudeDeg, base.LongitudeDeg})
+	}
+	coverage.Polygons = unionRangeRings(centers, radiusMeters, segments)
+	return coverage, nil
+}
+
+// FeatureCollection returns the ring as a Polygon feature followed by the airports inside it
+func (r *RangeRing) FeatureCollection() *GeoJSONFeatureCollection {
+	fc := NewGeoJSONFeatureCollection()
+	fc.Features = append(fc.Features, r.Feature())
+	fc.Features = append(fc.Features, AirportFeature(r.Base))
+	for _, inRange := range r.Airports {
+		fc.Features = append(fc.Features, inRange.Feature())
+	}
+	return fc
+}
+
+func (r *RangeRing) Feature() *GeoJSONFeature {
+	return &GeoJSONFeature{
+		Type:     GeoJSONFeatureType,
+		Geometry: &GeoJSONGeometry{GeoJSONPolygonType, [][][]float64{r.Ring}},
+		Properties: map[string]interface{}{
+			"kind":          RecordKindRangeRing,
+			"base":          r.Base.ICAOCode,
+			"radius_meters": r.RadiusMeters,
+		},
+	}
+}
+
+// FeatureCollection returns the union of the rings as a MultiPolygon feature followed by the bases and the covered airports.
+// Set includeRings to add the ring of every base as well.
+func (c *Coverage) FeatureCollection(includeRings bool) *GeoJSONFeatureCollection {
+	fc := NewGeoJSONFeatureCollection()
+	fc.Features = append(fc.Features, &GeoJSONFeature{
+		Type:     GeoJSONFeatureType,
+		Geometry: &GeoJSONGeometry{GeoJSONMultiPolygonType, c.Polygons},
+		Properties: map[string]interface{}{
+			"kind":          RecordKindCoverage,
+			"radius_meters": c.RadiusMeters,
+		},
+	})
+	if includeRings {
+		for _, rangeRing := range c.Rings {
+			fc.Features = append(fc.Features, rangeRing.Feature())
+		}
+	}
+	for _, base := range c.Bases {
+		feature := AirportFeature(base)
+		feature.Properties["base"] = true
+		fc.Features = append(fc.Features, feature)
+	}
+	for _, inRange := range c.Airports {
+		fc.Features = append(fc.Features, inRange.Feature())
+	}
+	return fc
+}
+
+// Feature returns the airport as a Point feature with the nearest base, the bases in range and the distance as extra properties
+func (a *AirportInRange) Feature() *GeoJSONFeature {
+	feature := AirportFeature(a.Airport)
+	feature.Properties["nearest_base"] = a.Base
+	feature.Properties["bases"] = a.Bases
+	feature.Properties["distance_meters"] = a.DistanceMeters
+	feature.Properties["bearing_deg"] = a.BearingDeg
+	return feature
+}
+
+// unionRangeRings returns the union of the rings around the centers (latitude, longitude) as MultiPolygon coordinates.
+// The outline is made of the ring points which are outside every other ring, so it is as exact as the number of segments.
+// Rings on both sides of the antimeridian are not merged, rings with the same center are merged into one.
+func unionRangeRings(centers [][]float64, radiusMeters float64, segments int) [][][][]float64 {
+	type arc struct {
+		points [][]float64
+		used   bool
+	}
+	unique := make([][]float64, 0, len(centers))
+	for _, center := range centers {
+		duplicate := false
+		for _, other := range unique {
+			if Distance(center[0], center[1], other[0], other[1]) < 1 {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			unique = append(unique, center)
+		}
+	}
+	centers = unique
+
+	arcs := make([]*arc, 0)
+	for i, center := range centers {
+		ring := rangeRingPoints(center[0], center[1], radiusMeters, segments)
+		outside := make([]bool, len(ring))
+		start := -1
+		for k, point := range ring {
+			outside[k] = true
+			for j, other := range centers {
+				if j != i && Distance(point[1], point[0], other[0], other[1]) < radiusMeters {
+					outside[k] = false
+					break
+				}
+			}
+			// an arc starts at an outside point following an inside point
+			if k > 0 && outside[k] && !outside[k-1] && start < 0 {
+				start = k
+			}
+		}
+		if start < 0 {
+			if outside[0] && !outside[len(ring)-1] {
+				start = 0
+			} else if outside[0] {
+				// the ring is not overlapped by any other ring
+				arcs = append(arcs, &arc{points: ring})
+				continue
+			} else {
+				// the ring is inside the other rings
+				continue
+			}
+		}
+		var current *arc
+		for n := 0; n < len(ring); n++ {
+			k := (start + n) % len(ring)
+			if !outside[k] {
+				current = nil
+				continue
+			}
+			if current == nil {
+				current = &arc{}
+				arcs = append(arcs, current)
+			}
+			current.points = append(current.points, ring[k])
+		}
+	}
+
+	// join the arcs into loops, each arc continues with the arc starting nearest to its end
+	loops := make([][][]float64, 0)
+	for _, first := range arcs {
+		if first.used {
+			continue
+		}
+		first.used = true
+		loop := append([][]float64{}, first.points...)
+		for last := first; ; {
+			end := last.points[len(last.points)-1]
+			next, nearest := first, squaredDegrees(end, first.points[0])
+			for _, candidate := range arcs {
+				if d := squaredDegrees(end, candidate.points[0]); !candidate.used && d < nearest {
+					next, nearest = candidate, d
+				}
+			}
+			if next == first {
+				break
+			}
+			next.used = true
+			loop = append(loop, next.points...)
+			last = next
+		}
+		loops = append(loops, append(loop, loop[0]))
+	}
+
+	// counterclockwise loops are outlines, clockwise loops are holes inside them
+	polygons := make([][][][]float64, 0)
+	holes := make([][][]float64, 0)
+	for _, loop := range loops {
+		if ringArea(loop) >= 0 {
+			polygons = append(polygons, [][][]float64{loop})
+		} else {
+			holes = append(holes, loop)
+		}
+	}
+	for _, hole := range holes {
+		for i := range polygons {
+			if ringContains(polygons[i][0], hole[0][0], hole[0][1]) {
+				polygons[i] = append(polygons[i], hole)
+				break
+			}
+		}
+	}
+	return polygons
+}
+
+func squaredDegrees(a, b []float64) float64 {
+	return (a[0]-b[0])*(a[0]-b[0]) + (a[1]-b[1])*(a[1]-b[1])
+}
+
+// ringArea returns the signed area of the closed ring in square degrees, positive if counterclockwise
+func ringArea(ring [][]float64) float64 {
+	area := 0.0
+	for i := 0; i+1 < len(ring); i++ {
+		area += ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+	}
+	return area / 2
+}
+
+// ringContains tests if the position is inside the closed ring by ray casting
+func ringContains(ring [][]float64, x, y float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi, xj, yj := ring[i][0], ring[i][1], ring[j][0], ring[j][1]
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,195 @@
+package alphafoxtrot
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFindRangeRing(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	if finder.FindRangeRing("XXXX", 1000, 0, AirportTypeAll) != nil {
+		t.Error("got a ring around an unknown airport")
+	}
+	rangeRing := finder.FindRangeRing("KLAX", KilometersToMeters(50), 0, AirportTypeAll)
+	if len(rangeRing.Ring) != DefaultRangeRingSegments+1 {
+		t.Errorf("got %d ring positions", len(rangeRing.Ring))
+	}
+	if area := ringArea(rangeRing.Ring); area <= 0 {
+		t.Errorf("ring is not counterclockwise, signed area %g", area)
+	}
+	for _, position := range rangeRing.Ring {
+		if distance := Distance(position[1], position[0], rangeRing.Base.LatitudeDeg, rangeRing.Base.LongitudeDeg); math.Abs(distance-rangeRing.RadiusMeters) > 1 {
+			t.Errorf("ring position %v is %.1f m away from the base", position, distance)
+		}
+	}
+	icaoCodes := make(map[string]bool)
+	for _, inRange := range rangeRing.Airports {
+		icaoCodes[inRange.Airport.ICAOCode] = true
+		if inRange.Base != "KLAX" || inRange.DistanceMeters > rangeRing.RadiusMeters {
+			t.Errorf("unexpected airport in range %+v", inRange)
+		}
+	}
+	if len(icaoCodes) != 3 || !icaoCodes["KSMO"] || !icaoCodes["KLGB"] || !icaoCodes["2CA8"] {
+		t.Errorf("got airports %v", icaoCodes)
+	}
+}
+
+func TestFindCoverage(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	if _, err := finder.FindCoverage([]string{"KLAX", "XXXX"}, 1000, 0, AirportTypeAll); err == nil {
+		t.Error("expected an error for an unknown base")
+	}
+	coverage, err := finder.FindCoverage([]string{"KLAX", "KLGB", "KLAX"}, KilometersToMeters(30), 0, AirportTypeAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(coverage.Bases) != 2 || len(coverage.Rings) != 2 || len(coverage.Polygons) != 1 {
+		t.Fatalf("got %d bases, %d rings and %d polygons", len(coverage.Bases), len(coverage.Rings), len(coverage.Polygons))
+	}
+	covered := make(map[string]*AirportInRange)
+	for _, inRange := range coverage.Airports {
+		covered[inRange.Airport.ICAOCode] = inRange
+	}
+	// the bases are within range of each other but not covered airports
+	if _, ok := covered["KLAX"]; ok {
+		t.Error("base KLAX is listed as covered")
+	}
+	if _, ok := covered["KLGB"]; ok {
+		t.Error("base KLGB is listed as covered")
+	}
+	if inRange := covered["KSMO"]; inRange == nil || inRange.Base != "KLAX" || len(inRange.Bases) != 1 {
+		t.Errorf("unexpected KSMO coverage %+v", inRange)
+	}
+	if inRange := covered["2CA8"]; inRange == nil || inRange.Base != "KLGB" || len(inRange.Bases) != 2 {
+		t.Errorf("unexpected 2CA8 coverage %+v", inRange)
+	}
+}
+
+func TestFindCoverageAirportsWithoutICAOCode(t *testing.T) {
+	databases := NewDatabases()
+	databases.Airports.Airports = []*AirportData{
+		{ID: 1, ICAOCode: "XBAS", Type: AirportTypeSmallName, LatitudeDeg: 10, LongitudeDeg: 10},
+		{ID: 2, Type: AirportTypeHeliportName, LatitudeDeg: 10.1, LongitudeDeg: 10},
+		{ID: 3, Type: AirportTypeHeliportName, LatitudeDeg: 10, LongitudeDeg: 10.1},
+		{ID: 4, ICAOCode: "XDUP", Type: AirportTypeSmallName, LatitudeDeg: 9.9, LongitudeDeg: 10},
+		{ID: 5, ICAOCode: "XDUP", Type: AirportTypeSmallName, LatitudeDeg: 10, LongitudeDeg: 9.9},
+	}
+	finder := NewAirportFinder()
+	if errs := finder.LoadSources(AirportTypeAll, NewDatabasesSource("test", databases)); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	coverage, err := finder.FindCoverage([]string{"XBAS"}, KilometersToMeters(50), 0, AirportTypeAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(coverage.Airports) != 4 {
+		t.Errorf("got %d covered airports, want 4", len(coverage.Airports))
+	}
+}
+
+func TestUnionRangeRings(t *testing.T) {
+	radius := KilometersToMeters(70)
+	hexagon := make([][]float64, 0, 6)
+	for i := 0; i < 6; i++ {
+		lat, lon := Destination(0, 0, float64(i)*60, KilometersToMeters(100))
+		hexagon = append(hexagon, []float64{lat, lon})
+	}
+	tests := []struct {
+		name     string
+		centers  [][]float64
+		polygons int
+		holes    int
+		inside   [][]float64
+		outside  [][]float64
+	}{
+		{
+			name:     "disjoint",
+			centers:  [][]float64{{0, 0}, {0, 5}},
+			polygons: 2,
+			inside:   [][]float64{{0, 0}, {0, 5}},
+			outside:  [][]float64{{0, 2.5}},
+		},
+		{
+			name:     "overlapping",
+			centers:  [][]float64{{0, 0}, {0, 1}},
+			polygons: 1,
+			inside:   [][]float64{{0, 0}, {0, 0.5}, {0, 1}},
+			outside:  [][]float64{{0, 2}, {1, 0.5}},
+		},
+		{
+			name:     "same center",
+			centers:  [][]float64{{0, 0}, {0, 0}},
+			polygons: 1,
+			inside:   [][]float64{{0, 0}},
+			outside:  [][]float64{{0, 1}},
+		},
+		{
+			name:     "ring inside the others",
+			centers:  append([][]float64{{0, 0}}, hexagon...),
+			polygons: 1,
+			inside:   [][]float64{{0, 0}, hexagon[0]},
+			outside:  [][]float64{{0, 2}},
+		},
+		{
+			name:     "hole",
+			centers:  hexagon,
+			polygons: 1,
+			holes:    1,
+			inside:   [][]float64{hexagon[0], hexagon[3]},
+			outside:  [][]float64{{0, 0}, {0, 2}},
+		},
+	}
+	for _, test := range tests {
+		polygons := unionRangeRings(test.centers, radius, 0)
+		if len(polygons) != test.polygons {
+			t.Errorf("%s: got %d polygons, want %d", test.name, len(polygons), test.polygons)
+			continue
+		}
+		holes := 0
+		for _, polygon := range polygons {
+			if area := ringArea(polygon[0]); area <= 0 {
+				t.Errorf("%s: outline is not counterclockwise", test.name)
+			}
+			for _, hole := range polygon[1:] {
+				holes++
+				if area := ringArea(hole); area >= 0 {
+					t.Errorf("%s: hole is not clockwise", test.name)
+				}
+			}
+			for _, ring := range polygon {
+				if first, last := ring[0], ring[len(ring)-1]; first[0] != last[0] || first[1] != last[1] {
+					t.Errorf("%s: ring is not closed", test.name)
+				}
+			}
+		}
+		if holes != test.holes {
+			t.Errorf("%s: got %d holes, want %d", test.name, holes, test.holes)
+		}
+		for _, position := range test.inside {
+			if !multiPolygonContains(polygons, position[1], position[0]) {
+				t.Errorf("%s: %v is not inside", test.name, position)
+			}
+		}
+		for _, position := range test.outside {
+			if multiPolygonContains(polygons, position[1], position[0]) {
+				t.Errorf("%s: %v is inside", test.name, position)
+			}
+		}
+	}
+}
+
+func multiPolygonContains(polygons [][][][]float64, x, y float64) bool {
+	for _, polygon := range polygons {
+		if !ringContains(polygon[0], x, y) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon[1:] {
+			inHole = inHole || ringContains(hole, x, y)
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,248 @@
+package alphafoxtrot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultRefreshInterval = time.Hour * 24
+	StagingDirName         = ".staging"
+)
+
+type RefreshOptions struct {
+	DataDir       string                            // required, directory holding the csv files that are served
+	Interval      time.Duration                     // optional, defaults to DefaultRefreshInterval
+	AirportFilter uint64                            // optional, defaults to AirportTypeAll
+	Progress      DownloadProgress                  // optional, defaults to NoProgress
+	Timeout       time.Duration                     // optional, defaults to DefaultDownloadTimeout
+	Validate      func(finder *AirportFinder) error // optional, additional check of the freshly loaded data before it is swapped in
+	Overrides     string                            // optional, patch file which is applied to every refreshed dataset (see LoadOptions.OverridesFilename)
+}
+
+// Refresher periodically downloads changed files from OurAirports.com,
+// loads them into a fresh AirportFinder and swaps the data into the live finder.
+// If anything goes wrong along the way, the live finder keeps serving its current data.
+type Refresher struct {
+	finder       *AirportFinder
+	options      RefreshOptions
+	refreshMutex sync.Mutex
+	mutex        sync.Mutex
+	loaded       bool
+	lastSuccess  time.Time
+	datasetTime  time.Time
+	lastError    error
+	cancel       context.CancelFunc
+	done         chan struct{}
+}
+
+func NewRefresher(finder *AirportFinder, options *RefreshOptions) *Refresher {
+	opts := *options
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultRefreshInterval
+	}
+	if opts.AirportFilter == 0 {
+		opts.AirportFilter = AirportTypeAll
+	}
+	if opts.Progress == nil {
+		opts.Progress = NoProgress{}
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultDownloadTimeout
+	}
+	return &Refresher{
+		finder:  finder,
+		options: opts,
+	}
+}
+
+// Start refreshes the data right away and then once per interval until the context is done or Stop is called
+func (r *Refresher) Start(ctx context.Context) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.cancel != nil {
+		return
+	}
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+	go r.run(ctx, r.done)
+}
+
+// Stop stops the background refresh and waits until a running refresh has finished
+func (r *Refresher) Stop() {
+	r.mutex.Lock()
+	cancel, done := r.cancel, r.done
+	r.cancel, r.done = nil, nil
+	r.mutex.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+func (r *Refresher) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(r.options.Interval)
+	defer ticker.Stop()
+
+	r.RefreshContext(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.RefreshContext(ctx)
+		}
+	}
+}
+
+// Refresh runs a single refresh cycle and returns its error, which is also available via LastError
+func (r *Refresher) Refresh() error {
+	return r.RefreshContext(context.Background())
+}
+
+// RefreshContext runs a single refresh cycle, the downloads are canceled when the context is done
+func (r *Refresher) RefreshContext(ctx context.Context) error {
+	r.refreshMutex.Lock()
+	defer r.refreshMutex.Unlock()
+
+	err := r.refresh(ctx)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.lastError = err
+	if err == nil {
+		r.lastSuccess = time.Now()
+		r.datasetTime = newestModTime(r.options.DataDir)
+	}
+	return err
+}
+
+func (r *Refresher) refresh(ctx context.Context) error {
+	dataDir := r.options.DataDir
+	stagingDir := filepath.Join(dataDir, StagingDirName)
+	if err := os.RemoveAll(stagingDir); err != nil {
+		return err
+	}
+	defer os.RemoveAll(stagingDir)
+
+	downloadOptions := &DownloadOptions{
+		Progress:      r.options.Progress,
+		Timeout:       r.options.Timeout,
+		SkipUnchanged: true,
+		ReferenceDir:  dataDir,
+		Context:       ctx,
+	}
+	downloaded, errs := DownloadDatabaseWithOptions(stagingDir, downloadOptions)
+	if len(errs) > 0 {
+		return joinErrors("download failed", errs)
+	}
+
+	r.mutex.Lock()
+	loaded := r.loaded
+	r.mutex.Unlock()
+	if len(downloaded) == 0 && loaded {
+		return nil
+	}
+
+	staged := make(map[string]bool, len(downloaded))
+	for _, key := range downloaded {
+		staged[key] = true
+	}
+	stagedFile := func(key string) string {
+		if staged[key] {
+			return filepath.Join(stagingDir, OurAirportsFiles[key])
+		}
+		return filepath.Join(dataDir, OurAirportsFiles[key])
+	}
+	loadOptions := &LoadOptions{
+		AirportsFilename:    stagedFile(AirportsFileKey),
+		FrequenciesFilename: stagedFile(FrequenciesFileKey),
+		RunwaysFilename:     stagedFile(RunwaysFileKey),
+		RegionsFilename:     stagedFile(RegionsFileKey),
+		CountriesFilename:   stagedFile(CountriesFileKey),
+		NavaidsFilename:     stagedFile(NavaidsFileKey),
+		OverridesFilename:   r.options.Overrides,
+	}
+
+	fresh := NewAirportFinder()
+	if errs := fresh.Load(loadOptions, r.options.AirportFilter); len(errs) > 0 {
+		return joinErrors("validation failed", errs)
+	}
+	if len(fresh.airportDB.Airports) == 0 {
+		return fmt.Errorf("validation failed: no airports loaded")
+	}
+	if r.options.Validate != nil {
+		if err := r.options.Validate(fresh); err != nil {
+			return fmt.Errorf("validation failed: %v", err)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.finder.Swap(fresh)
+	r.mutex.Lock()
+	r.loaded = true
+	r.mutex.Unlock()
+
+	// the finder serves the new data now, files which fail to move differ from the server and are downloaded again next time
+	for _, key := range downloaded {
+		filename := OurAirportsFiles[key]
+		if err := os.Rename(filepath.Join(stagingDir, filename), filepath.Join(dataDir, filename)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LastSuccess returns the time of the last successful refresh, zero if there was none yet
+func (r *Refresher) LastSuccess() time.Time {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.lastSuccess
+}
+
+// DatasetAge returns the time since the newest data file was modified on the server, zero if unknown
+func (r *Refresher) DatasetAge() time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.datasetTime.IsZero() {
+		return 0
+	}
+	return time.Since(r.datasetTime)
+}
+
+// LastError returns the error of the last refresh, nil if it succeeded
+func (r *Refresher) LastError() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.lastError
+}
+
+func newestModTime(dataDir string) time.Time {
+	var newest time.Time
+	for _, filename := range OurAirportsFiles {
+		info, err := os.Stat(filepath.Join(dataDir, filename))
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	return newest
+}
+
+func joinErrors(prefix string, errs []error) error {
+	if len(errs) == 1 {
+		return fmt.Errorf("%s: %v", prefix, errs[0])
+	}
+	return fmt.Errorf("%s: %v (and %d more errors)", prefix, errs[0], len(errs)-1)
+}
@@ -53,8 +53,15 @@ func (db *RegionDB) Parse(file string, skipFirstLine bool) error {
 		return err
 	}
 	defer f.Close()
+	return ReadRegions(f, skipFirstLine, func(region *RegionData) error {
+		db.Regions[region.ISOCode] = region
+		return nil
+	})
+}
 
-	reader := csv.NewReader(f)
+// ReadRegions calls fn for every record of the csv and stops at the first error returned by fn
+func ReadRegions(r io.Reader, skipFirstLine bool, fn func(*RegionData) error) error {
+	reader := csv.NewReader(r)
 	line := -1
 
 	for {
@@ -95,7 +102,9 @@ func (db *RegionDB) Parse(file string, skipFirstLine bool) error {
 			WikipediaLink: wikipedia,
 			Keywords:      keywords,
 		}
-		db.Regions[isoCode] = region
+		if err := fn(region); err != nil {
+			return err
+		}
 	}
 }
 
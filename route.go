@@ -0,0 +1,179 @@
+package alphafoxtrot
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+)
+
+const (
+	RouteShortest   = "shortest"
+	RouteFewestLegs = "fewest_legs"
+)
+
+type RouteRequest struct {
+	Origin            string // ICAO code
+	Destination       string // ICAO code
+	MaxLegMeters      float64
+	Runway            RunwayProfile // the stops need a suitable runway, the origin and destination are not checked
+	AirportTypeFilter uint64        // for the stops, AirportTypeRunways if 0
+	Optimize          string        // RouteShortest if empty; RouteFewestLegs takes the shortest of the routes with the fewest legs
+}
+
+type RouteLeg struct {
+	From           *Airport
+	To             *Airport
+	DistanceMeters float64
+	BearingDeg     float64
+}
+
+type Route struct {
+	Legs           []RouteLeg
+	DistanceMeters float64
+}
+
+type routeCost struct {
+	legs   int
+	meters float64
+}
+
+type routeQueueItem struct {
+	node     int
+	cost     routeCost
+	estimate routeCost // cost plus the estimate to the destination
+}
+
+type routeQueue struct {
+	items      []routeQueueItem
+	fewestLegs bool
+}
+
+func (q *routeQueue) less(a, b routeCost) bool {
+	if q.fewestLegs && a.legs != b.legs {
+		return a.legs < b.legs
+	}
+	return a.meters < b.meters
+}
+
+func (q *routeQueue) Len() int           { return len(q.items) }
+func (q *routeQueue) Less(i, j int) bool { return q.less(q.items[i].estimate, q.items[j].estimate) }
+func (q *routeQueue) Swap(i, j int)      { q.items[i], q.items[j] = q.items[j], q.items[i] }
+func (q *routeQueue) Push(x interface{}) { q.items = append(q.items, x.(routeQueueItem)) }
+func (q *routeQueue) Pop() interface{} {
+	item := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return item
+}
+
+// FindRoute searches the route from the origin to the destination with stops at airports which suit the runway profile.
+// It is an A* search, the neighbors of an airport are the airports within the maximum leg distance.
+func (af *AirportFinder) FindRoute(request *RouteRequest) (*Route, error) {
+	if request.MaxLegMeters <= 0 {
+		return nil, fmt.Errorf("route: invalid maximum leg distance %f", request.MaxLegMeters)
+	}
+	if request.Optimize != "" && request.Optimize != RouteShortest && request.Optimize != RouteFewestLegs {
+		return nil, fmt.Errorf("route: unknown optimization %q", request.Optimize)
+	}
+	airportTypeFilter := request.AirportTypeFilter
+	if airportTypeFilter == 0 {
+		airportTypeFilter = AirportTypeRunways
+	}
+
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
+	airports := af.airportDB.Airports
+	// the same airports as FindAirportByICAOCode if several share the ICAO code
+	origin := af.airportDB.indexOfICAOCode(request.Origin)
+	destination := af.airportDB.indexOfICAOCode(request.Destination)
+	if origin < 0 {
+		return nil, fmt.Errorf("route: unknown origin %s", request.Origin)
+	}
+	if destination < 0 {
+		return nil, fmt.Errorf("route: unknown destination %s", request.Destination)
+	}
+
+	suitable := make(map[int]bool)
+	accept := func(i int) bool {
+		if i == destination {
+			return true
+		}
+		if airports[i].TypeFlag&airportTypeFilter == 0 {
+			return false
+		}
+		ok, known := suitable[i]
+		if !known {
+			for _, runway := range af.runwayDB.FindByAirportID(airports[i].ID) {
+				if ok = request.Runway.Suits(NewRunway(runway)); ok {
+					break
+				}
+			}
+			suitable[i] = ok
+		}
+		return ok
+	}
+	// the great circle distance and the legs it takes at least, neither overestimates
+	target := airports[destination]
+	estimate := func(i int) routeCost {
+		meters := Distance(airports[i].LatitudeDeg, airports[i].LongitudeDeg, target.LatitudeDeg, target.LongitudeDeg)
+		return routeCost{int(math.Ceil(meters/request.MaxLegMeters - 1e-9)), meters}
+	}
+
+	index := af.airportDB.SpatialIndex()
+	queue := &routeQueue{fewestLegs: request.Optimize == RouteFewestLegs}
+	costs := map[int]routeCost{origin: {}}
+	previous := make(map[int]int)
+	done := make(map[int]bool)
+	heap.Push(queue, routeQueueItem{node: origin, estimate: estimate(origin)})
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(routeQueueItem)
+		if done[item.node] {
+			continue
+		}
+		done[item.node] = true
+		if item.node == destination {
+			return af.makeRoute(airports, previous, origin, destination), nil
+		}
+		from := airports[item.node]
+		for _, neighbor := range index.Search(from.LatitudeDeg, from.LongitudeDeg, request.MaxLegMeters, -1, accept) {
+			if done[neighbor.Item] {
+				continue
+			}
+			cost := routeCost{item.cost.legs + 1, item.cost.meters + neighbor.DistanceMeters}
+			if best, ok := costs[neighbor.Item]; ok && !queue.less(cost, best) {
+				continue
+			}
+			costs[neighbor.Item] = cost
+			previous[neighbor.Item] = item.node
+			remaining := estimate(neighbor.Item)
+			heap.Push(queue, routeQueueItem{
+				node:     neighbor.Item,
+				cost:     cost,
+				estimate: routeCost{cost.legs + remaining.legs, cost.meters + remaining.meters},
+			})
+		}
+	}
+	return nil, fmt.Errorf("route: no route from %s to %s with legs of %.0f m", request.Origin, request.Destination, request.MaxLegMeters)
+}
+
+func (af *AirportFinder) makeRoute(airports []*AirportData, previous map[int]int, origin, destination int) *Route {
+	nodes := []int{destination}
+	for node := destination; node != origin; {
+		node = previous[node]
+		nodes = append([]int{node}, nodes...)
+	}
+	route := &Route{Legs: make([]RouteLeg, 0, len(nodes)-1)}
+	from := af.makeAirport(airports[nodes[0]])
+	for _, node := range nodes[1:] {
+		to := af.makeAirport(airports[node])
+		leg := RouteLeg{
+			From:           from,
+			To:             to,
+			DistanceMeters: Distance(from.LatitudeDeg, from.LongitudeDeg, to.LatitudeDeg, to.LongitudeDeg),
+			BearingDeg:     Bearing(from.LatitudeDeg, from.LongitudeDeg, to.LatitudeDeg, to.LongitudeDeg),
+		}
+		route.Legs = append(route.Legs, leg)
+		route.DistanceMeters += leg.DistanceMeters
+		from = to
+	}
+	return route
+}
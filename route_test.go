@@ -0,0 +1,117 @@
+package alphafoxtrot
+
+import (
+	"strings"
+	"testing"
+)
+
+// loadRouteTestFinder places the origin XORG and the destination XDST 4 degrees apart on the equator.
+// The stops XEQ1 to XEQ3 on the equator have short runways, the stops XOFA and XOFB north of it long ones.
+func loadRouteTestFinder(t *testing.T) *AirportFinder {
+	t.Helper()
+	databases := NewDatabases()
+	databases.Airports.Airports = []*AirportData{
+		{ID: 1, ICAOCode: "XORG", Type: AirportTypeSmallName, LatitudeDeg: 0, LongitudeDeg: 0},
+		{ID: 2, ICAOCode: "XEQ1", Type: AirportTypeSmallName, LatitudeDeg: 0, LongitudeDeg: 1},
+		{ID: 3, ICAOCode: "XEQ2", Type: AirportTypeSmallName, LatitudeDeg: 0, LongitudeDeg: 2},
+		{ID: 4, ICAOCode: "XEQ3", Type: AirportTypeSmallName, LatitudeDeg: 0, LongitudeDeg: 3},
+		{ID: 5, ICAOCode: "XOFA", Type: AirportTypeSmallName, LatitudeDeg: 0.2, LongitudeDeg: 1.33},
+		{ID: 6, ICAOCode: "XOFB", Type: AirportTypeSmallName, LatitudeDeg: 0.2, LongitudeDeg: 2.67},
+		{ID: 7, ICAOCode: "XDST", Type: AirportTypeSmallName, LatitudeDeg: 0, LongitudeDeg: 4},
+		// duplicate ICAO codes far away, FindAirportByICAOCode returns the first airport
+		{ID: 8, ICAOCode: "XORG", Type: AirportTypeSmallName, LatitudeDeg: 50, LongitudeDeg: 50},
+		{ID: 9, ICAOCode: "XDST", Type: AirportTypeSmallName, LatitudeDeg: -50, LongitudeDeg: 50},
+	}
+	for id, lengthFt := range map[uint64]int64{2: 2000, 3: 2000, 4: 2000, 5: 5000, 6: 5000} {
+		databases.Runways.Runways[id] = []*RunwayData{{ID: 100 + id, AirportID: id, LengthFt: lengthFt, WidthFt: 75}}
+	}
+	finder := NewAirportFinder()
+	if errs := finder.LoadSources(AirportTypeAll, NewDatabasesSource("test", databases)); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	return finder
+}
+
+func routeStops(route *Route) string {
+	stops := []string{route.Legs[0].From.ICAOCode}
+	for _, leg := range route.Legs {
+		stops = append(stops, leg.To.ICAOCode)
+	}
+	return strings.Join(stops, " ")
+}
+
+func TestFindRoute(t *testing.T) {
+	finder := loadRouteTestFinder(t)
+	maxLeg := KilometersToMeters(150)
+	tests := []struct {
+		name     string
+		request  RouteRequest
+		want     string
+		legsWant int
+	}{
+		{"shortest", RouteRequest{Optimize: RouteShortest}, "XORG XEQ1 XEQ2 XEQ3 XDST", 4},
+		{"fewest legs", RouteRequest{Optimize: RouteFewestLegs}, "XORG XOFA XOFB XDST", 3},
+		{"runway profile", RouteRequest{Runway: RunwayProfile{MinLengthFt: 4000}}, "XORG XOFA XOFB XDST", 3},
+	}
+	for _, test := range tests {
+		test.request.Origin, test.request.Destination, test.request.MaxLegMeters = "XORG", "XDST", maxLeg
+		route, err := finder.FindRoute(&test.request)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if stops := routeStops(route); stops != test.want || len(route.Legs) != test.legsWant {
+			t.Errorf("%s: got %s, want %s", test.name, stops, test.want)
+		}
+		total := 0.0
+		for _, leg := range route.Legs {
+			if leg.DistanceMeters > maxLeg {
+				t.Errorf("%s: leg %s-%s is %.0f m long", test.name, leg.From.ICAOCode, leg.To.ICAOCode, leg.DistanceMeters)
+			}
+			total += leg.DistanceMeters
+		}
+		if total != route.DistanceMeters {
+			t.Errorf("%s: got distance %.0f m, the legs add up to %.0f m", test.name, route.DistanceMeters, total)
+		}
+	}
+
+	shortest, _ := finder.FindRoute(&RouteRequest{Origin: "XORG", Destination: "XDST", MaxLegMeters: maxLeg})
+	fewest, _ := finder.FindRoute(&RouteRequest{Origin: "XORG", Destination: "XDST", MaxLegMeters: maxLeg, Optimize: RouteFewestLegs})
+	if shortest == nil || fewest == nil || shortest.DistanceMeters >= fewest.DistanceMeters {
+		t.Errorf("the shortest route is not shorter than the one with the fewest legs")
+	}
+	if shortest != nil && shortest.Legs[0].From.ID != 1 {
+		t.Errorf("route starts at the airport with ID %d, want the first XORG", shortest.Legs[0].From.ID)
+	}
+}
+
+func TestFindRouteOriginIsDestination(t *testing.T) {
+	finder := loadRouteTestFinder(t)
+	route, err := finder.FindRoute(&RouteRequest{Origin: "XEQ1", Destination: "XEQ1", MaxLegMeters: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(route.Legs) != 0 || route.DistanceMeters != 0 {
+		t.Errorf("got %+v", route)
+	}
+}
+
+func TestFindRouteErrors(t *testing.T) {
+	finder := loadRouteTestFinder(t)
+	tests := []struct {
+		name    string
+		request RouteRequest
+	}{
+		{"unreachable", RouteRequest{Origin: "XORG", Destination: "XDST", MaxLegMeters: KilometersToMeters(100)}},
+		{"unsuitable stops", RouteRequest{Origin: "XORG", Destination: "XDST", MaxLegMeters: KilometersToMeters(150), Runway: RunwayProfile{MinLengthFt: 6000}}},
+		{"unknown origin", RouteRequest{Origin: "XXXX", Destination: "XDST", MaxLegMeters: 1000}},
+		{"unknown destination", RouteRequest{Origin: "XORG", Destination: "XXXX", MaxLegMeters: 1000}},
+		{"invalid leg", RouteRequest{Origin: "XORG", Destination: "XDST"}},
+		{"unknown optimization", RouteRequest{Origin: "XORG", Destination: "XDST", MaxLegMeters: 1000, Optimize: "fastest"}},
+	}
+	for _, test := range tests {
+		if route, err := finder.FindRoute(&test.request); err == nil {
+			t.Errorf("%s: expected an error, got %+v", test.name, route)
+		}
+	}
+}
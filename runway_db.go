@@ -55,6 +55,7 @@ type RunwayData struct {
 	HighEndElevationFt          int64
 	HighEndHeadingDegT          float64
 	HighEndDisplacedThresholdFt int64
+	Origin                      string
 }
 
 type RunwayDB struct {
@@ -77,8 +78,15 @@ func (db *RunwayDB) Parse(file string, skipFirstLine bool) error {
 		return err
 	}
 	defer f.Close()
+	return ReadRunways(f, skipFirstLine, func(runway *RunwayData) error {
+		db.Runways[runway.AirportID] = append(db.Runways[runway.AirportID], runway)
+		return nil
+	})
+}
 
-	reader := csv.NewReader(f)
+// ReadRunways calls fn for every record of the csv and stops at the first error returned by fn
+func ReadRunways(r io.Reader, skipFirstLine bool, fn func(*RunwayData) error) error {
+	reader := csv.NewReader(r)
 	line := -1
 
 	for {
@@ -116,14 +124,14 @@ func (db *RunwayDB) Parse(file string, skipFirstLine bool) error {
 
 		leIdent := row[colRunwayLowEndIdent]
 		leLatitude, _ := ParseFloat(row[colRunwayLowEndLatitudeDeg])
-		leLongitude, _ := ParseFloat(row[colRunwayLowEndLatitudeDeg])
+		leLongitude, _ := ParseFloat(row[colRunwayLowEndLongitudeDeg])
 		leElevation, _ := ParseInt(row[colRunwayLowEndElevationFt])
 		leHeading, _ := ParseFloat(row[colRunwayLowEndHeadingDegT])
 		leDisplacedThreshold, _ := ParseInt(row[colRunwayLowEndDisplacedThresholdFt])
 
 		heIdent := row[colRunwayHighEndIdent]
 		heLatitude, _ := ParseFloat(row[colRunwayHighEndLatitudeDeg])
-		heLongitude, _ := ParseFloat(row[colRunwayHighEndLatitudeDeg])
+		heLongitude, _ := ParseFloat(row[colRunwayHighEndLongitudeDeg])
 		heElevation, _ := ParseInt(row[colRunwayHighEndElevationFt])
 		heHeading, _ := ParseFloat(row[colRunwayHighEndHeadingDegT])
 		heDisplacedThreshold, _ := ParseInt(row[colRunwayHighEndDisplacedThresholdFt])
@@ -149,8 +157,11 @@ func (db *RunwayDB) Parse(file string, skipFirstLine bool) error {
 			HighEndElevationFt:          heElevation,
 			HighEndHeadingDegT:          heHeading,
 			HighEndDisplacedThresholdFt: heDisplacedThreshold,
+			Origin:                      OriginBase,
+		}
+		if err := fn(runway); err != nil {
+			return err
 		}
-		db.Runways[airportRef] = append(db.Runways[airportRef], runway)
 	}
 }
 
@@ -0,0 +1,223 @@
+package alphafoxtrot
+
+import (
+	"math"
+	"sort"
+)
+
+// SpatialIndex is a k-d tree of positions on the unit sphere, so it has no issues at the antimeridian or the poles.
+// Searches compare chord lengths to prune the tree and Distance for the results.
+type SpatialIndex struct {
+	nodes []spatialNode
+}
+
+type spatialNode struct {
+	item         int
+	latitudeDeg  float64
+	longitudeDeg float64
+	position     [3]float64
+}
+
+// Neighbor is an item found by a SpatialIndex search
+type Neighbor struct {
+	Item           int
+	DistanceMeters float64
+}
+
+// NewSpatialIndex indexes count items, position returns the coordinates of an item
+func NewSpatialIndex(count int, position func(item int) (float64, float64)) *SpatialIndex {
+	nodes := make([]spatialNode, count)
+	for i := range nodes {
+		lat, lon := position(i)
+		nodes[i] = spatialNode{item: i, latitudeDeg: lat, longitudeDeg: lon, position: unitVector(lat, lon)}
+	}
+	buildSpatialTree(nodes, 0)
+	return &SpatialIndex{nodes: nodes}
+}
+
+func (idx *SpatialIndex) Len() int {
+	return len(idx.nodes)
+}
+
+// buildSpatialTree orders the nodes so the median of every range splits it along the axis of its depth
+func buildSpatialTree(nodes []spatialNode, depth int) {
+	if len(nodes) <= 1 {
+		return
+	}
+	axis := depth % 3
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].position[axis] < nodes[j].position[axis]
+	})
+	median := len(nodes) / 2
+	buildSpatialTree(nodes[:median], depth+1)
+	buildSpatialTree(nodes[median+1:], depth+1)
+}
+
+// unitVector returns the position on the unit sphere
+func unitVector(latitudeDeg, longitudeDeg float64) [3]float64 {
+	lat, lon := latitudeDeg*DegToRad, longitudeDeg*DegToRad
+	return [3]float64{math.Cos(lat) * math.Cos(lon), math.Cos(lat) * math.Sin(lon), math.Sin(lat)}
+}
+
+// chordLength returns the straight line distance on the unit sphere of a distance on the earth, with a margin for rounding
+func chordLength(distanceMeters float64) float64 {
+	angle := distanceMeters / EarthRadius
+	if distanceMeters < 0 || angle >= math.Pi {
+		return 2 + 1e-9
+	}
+	return 2*math.Sin(angle/2) + 1e-9
+}
+
+// withinChord compares squared chords, it is the cheap test done before filtering and Distance
+func withinChord(a, b *[3]float64, chord float64) bool {
+	dx, dy, dz := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return dx*dx+dy*dy+dz*dz <= chord*chord
+}
+
+// chordDistance returns the great circle distance between two positions on the unit sphere
+func chordDistance(a, b *[3]float64) float64 {
+	dx, dy, dz := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	chord := math.Sqrt(dx*dx + dy*dy + dz*dz)
+	return 2 * math.Asin(math.Min(chord/2, 1)) * EarthRadius
+}
+
+// Nearest returns the nearest item within the radius which is accepted, -1 if there is none.
+// The radius is unlimited if < 0 and accept may be nil.
+func (idx *SpatialIndex) Nearest(latitudeDeg, longitudeDeg, radiusMeters float64, accept func(item int) bool) (int, float64) {
+	search := &nearestSearch{
+		nodes:        idx.nodes,
+		latitudeDeg:  latitudeDeg,
+		longitudeDeg: longitudeDeg,
+		position:     unitVector(latitudeDeg, longitudeDeg),
+		radiusMeters: radiusMeters,
+		chord:        chordLength(radiusMeters),
+		accept:       accept,
+		item:         -1,
+		distance:     math.MaxFloat64,
+	}
+	if radiusMeters < 0 {
+		search.radiusMeters = math.MaxFloat64
+	}
+	search.search(0, len(idx.nodes), 0)
+	return search.item, search.distance
+}
+
+type nearestSearch struct {
+	nodes        []spatialNode
+	latitudeDeg  float64
+	longitudeDeg float64
+	position     [3]float64
+	radiusMeters float64
+	chord        float64
+	accept       func(item int) bool
+	item         int
+	distance     float64
+}
+
+func (s *nearestSearch) search(lo, hi, depth int) {
+	if lo >= hi {
+		return
+	}
+	median := (lo + hi) / 2
+	node := &s.nodes[median]
+	if withinChord(&s.position, &node.position, s.chord) && (s.accept == nil || s.accept(node.item)) {
+		distance := Distance(s.latitudeDeg, s.longitudeDeg, node.latitudeDeg, node.longitudeDeg)
+		if distance <= s.radiusMeters && (distance < s.distance || (distance == s.distance && node.item < s.item)) {
+			s.item, s.distance = node.item, distance
+			s.chord = math.Min(s.chord, chordLength(distance))
+		}
+	}
+	axis := depth % 3
+	delta := s.position[axis] - node.position[axis]
+	near, far := [2]int{lo, median}, [2]int{median + 1, hi}
+	if delta > 0 {
+		near, far = far, near
+	}
+	s.search(near[0], near[1], depth+1)
+	if math.Abs(delta) <= s.chord {
+		s.search(far[0], far[1], depth+1)
+	}
+}
+
+// Search returns the accepted items within the radius ordered by distance, at most maxResults.
+// The radius and the number of results are unlimited if < 0 and accept may be nil.
+func (idx *SpatialIndex) Search(latitudeDeg, longitudeDeg, radiusMeters float64, maxResults int, accept func(item int) bool) []Neighbor {
+	if maxResults < 0 {
+		maxResults = math.MaxInt32
+	}
+	search := &rangeSearch{
+		nodes:        idx.nodes,
+		latitudeDeg:  latitudeDeg,
+		longitudeDeg: longitudeDeg,
+		position:     unitVector(latitudeDeg, longitudeDeg),
+		radiusMeters: radiusMeters,
+		chord:        chordLength(radiusMeters),
+		maxResults:   maxResults,
+		accept:       accept,
+		results:      make([]Neighbor, 0),
+	}
+	if radiusMeters < 0 {
+		search.radiusMeters = math.MaxFloat64
+	}
+	if maxResults > 0 {
+		search.search(0, len(idx.nodes), 0)
+		search.trim()
+	}
+	return search.results
+}
+
+type rangeSearch struct {
+	nodes        []spatialNode
+	latitudeDeg  float64
+	longitudeDeg float64
+	position     [3]float64
+	radiusMeters float64
+	chord        float64
+	maxResults   int
+	accept       func(item int) bool
+	results      []Neighbor
+}
+
+func (s *rangeSearch) search(lo, hi, depth int) {
+	if lo >= hi {
+		return
+	}
+	median := (lo + hi) / 2
+	node := &s.nodes[median]
+	if withinChord(&s.position, &node.position, s.chord) && (s.accept == nil || s.accept(node.item)) {
+		distance := Distance(s.latitudeDeg, s.longitudeDeg, node.latitudeDeg, node.longitudeDeg)
+		if distance <= s.radiusMeters {
+			s.add(Neighbor{Item: node.item, DistanceMeters: distance})
+		}
+	}
+	axis := depth % 3
+	delta := s.position[axis] - node.position[axis]
+	near, far := [2]int{lo, median}, [2]int{median + 1, hi}
+	if delta > 0 {
+		near, far = far, near
+	}
+	s.search(near[0], near[1], depth+1)
+	if math.Abs(delta) <= s.chord {
+		s.search(far[0], far[1], depth+1)
+	}
+}
+
+// add collects the neighbor, the results are sorted and cut to maxResults once there are twice as many
+// so the search range can shrink to the farthest result
+func (s *rangeSearch) add(neighbor Neighbor) {
+	s.results = append(s.results, neighbor)
+	if len(s.results) >= 2*s.maxResults {
+		s.trim()
+		s.chord = math.Min(s.chord, chordLength(s.results[len(s.results)-1].DistanceMeters))
+	}
+}
+
+func (s *rangeSearch) trim() {
+	sort.Slice(s.results, func(i, j int) bool {
+		a, b := s.results[i], s.results[j]
+		return a.DistanceMeters < b.DistanceMeters || (a.DistanceMeters == b.DistanceMeters && a.Item < b.Item)
+	})
+	if len(s.results) > s.maxResults {
+		s.results = s.results[:s.maxResults]
+	}
+}
@@ -0,0 +1,264 @@
+// Package sqlitedb exports the databases of an AirportFinder to a SQLite file and loads them back.
+// It uses a pure-Go SQLite driver, so it builds without cgo.
+//
+// Runways and frequencies reference their airport via airport_ref, airports_rtree and navaids_rtree
+// are R*Tree tables with the coordinates, e.g.:
+//
+//	SELECT a.ident, a.name FROM airports a JOIN airports_rtree r ON a.id = r.id
+//	WHERE r.min_lat >= 33.5 AND r.max_lat <= 34.5 AND r.min_lon >= -119 AND r.max_lon <= -118
+package sqlitedb
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	alphafoxtrot "github.com/grumpypixel/go-airport-finder"
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE countries (
+	id INTEGER PRIMARY KEY,
+	code TEXT NOT NULL UNIQUE,
+	name TEXT,
+	continent TEXT,
+	wikipedia_link TEXT,
+	keywords TEXT
+);
+CREATE TABLE regions (
+	id INTEGER PRIMARY KEY,
+	code TEXT NOT NULL UNIQUE,
+	local_code TEXT,
+	name TEXT,
+	continent TEXT,
+	iso_country TEXT,
+	wikipedia_link TEXT,
+	keywords TEXT
+);
+CREATE TABLE airports (
+	id INTEGER PRIMARY KEY,
+	ident TEXT NOT NULL,
+	type TEXT,
+	name TEXT,
+	latitude_deg REAL,
+	longitude_deg REAL,
+	elevation_ft INTEGER,
+	continent TEXT,
+	iso_country TEXT,
+	iso_region TEXT,
+	municipality TEXT,
+	scheduled_service INTEGER,
+	gps_code TEXT,
+	iata_code TEXT,
+	local_code TEXT,
+	home_link TEXT,
+	wikipedia_link TEXT,
+	keywords TEXT,
+	timezone TEXT,
+	utc_offset_hours REAL,
+	dst TEXT,
+	origin TEXT
+);
+CREATE INDEX airports_ident ON airports(ident);
+CREATE INDEX airports_iata_code ON airports(iata_code);
+CREATE INDEX airports_iso_country ON airports(iso_country);
+CREATE INDEX airports_iso_region ON airports(iso_region);
+CREATE TABLE runways (
+	id INTEGER PRIMARY KEY,
+	airport_ref INTEGER NOT NULL REFERENCES airports(id),
+	airport_ident TEXT,
+	length_ft INTEGER,
+	width_ft INTEGER,
+	surface TEXT,
+	lighted INTEGER,
+	closed INTEGER,
+	le_ident TEXT,
+	le_latitude_deg REAL,
+	le_longitude_deg REAL,
+	le_elevation_ft INTEGER,
+	le_heading_degT REAL,
+	le_displaced_threshold_ft INTEGER,
+	he_ident TEXT,
+	he_latitude_deg REAL,
+	he_longitude_deg REAL,
+	he_elevation_ft INTEGER,
+	he_heading_degT REAL,
+	he_displaced_threshold_ft INTEGER,
+	origin TEXT
+);
+CREATE INDEX runways_airport_ref ON runways(airport_ref);
+CREATE TABLE frequencies (
+	id INTEGER PRIMARY KEY,
+	airport_ref INTEGER NOT NULL REFERENCES airports(id),
+	airport_ident TEXT,
+	type TEXT,
+	description TEXT,
+	frequency_mhz REAL,
+	origin TEXT
+);
+CREATE INDEX frequencies_airport_ref ON frequencies(airport_ref);
+CREATE TABLE navaids (
+	id INTEGER PRIMARY KEY,
+	filename TEXT,
+	ident TEXT,
+	name TEXT,
+	type TEXT,
+	frequency_khz INTEGER,
+	latitude_deg REAL,
+	longitude_deg REAL,
+	elevation_ft INTEGER,
+	iso_country TEXT,
+	dme_frequency_khz INTEGER,
+	dme_channel TEXT,
+	dme_latitude_deg REAL,
+	dme_longitude_deg REAL,
+	dme_elevation_ft INTEGER,
+	slaved_variation_deg REAL,
+	magnetic_variation_deg REAL,
+	usage_type TEXT,
+	power TEXT,
+	associated_airport TEXT,
+	origin TEXT
+);
+CREATE INDEX navaids_ident ON navaids(ident);
+CREATE INDEX navaids_associated_airport ON navaids(associated_airport);
+CREATE VIRTUAL TABLE airports_rtree USING rtree(id, min_lat, max_lat, min_lon, max_lon);
+CREATE VIRTUAL TABLE navaids_rtree USING rtree(id, min_lat, max_lat, min_lon, max_lon);
+`
+
+// Export writes all databases of the finder to a new SQLite file, an existing file is replaced.
+// Runways and frequencies of airports which aren't loaded (see the airport type filter of Load) are skipped.
+func Export(finder *alphafoxtrot.AirportFinder, file string) error {
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	db, err := openForeignKeys(file)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(schema); err != nil {
+		return fmt.Errorf("cannot create schema: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := exportDatabases(tx, finder.Databases()); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// openForeignKeys opens the file with the REFERENCES constraints enforced on every pooled connection
+func openForeignKeys(file string) (*sql.DB, error) {
+	return sql.Open("sqlite", file+"?_pragma=foreign_keys(1)")
+}
+
+func exportDatabases(tx *sql.Tx, databases *alphafoxtrot.Databases) error {
+	stmt, err := tx.Prepare("INSERT INTO countries VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	for _, country := range databases.Countries.Countries {
+		if _, err := stmt.Exec(country.ID, country.ISOCode, country.Name, country.Continent, country.WikipediaLink, country.Keywords); err != nil {
+			return fmt.Errorf("country %s: %v", country.ISOCode, err)
+		}
+	}
+	stmt.Close()
+
+	stmt, err = tx.Prepare("INSERT INTO regions VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	for _, region := range databases.Regions.Regions {
+		if _, err := stmt.Exec(region.ID, region.ISOCode, region.LocalCode, region.Name, region.Continent, region.ISOCountry, region.WikipediaLink, region.Keywords); err != nil {
+			return fmt.Errorf("region %s: %v", region.ISOCode, err)
+		}
+	}
+	stmt.Close()
+
+	stmt, err = tx.Prepare("INSERT INTO airports VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	rtree, err := tx.Prepare("INSERT INTO airports_rtree VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	airportIDs := make(map[uint64]bool, len(databases.Airports.Airports))
+	for _, airport := range databases.Airports.Airports {
+		if _, err := stmt.Exec(airport.ID, airport.ICAOCode, airport.Type, airport.Name, airport.LatitudeDeg, airport.LongitudeDeg, airport.ElevationFt,
+			airport.Continent, airport.ISOCountry, airport.ISORegion, airport.Municipality, airport.ScheduledService, airport.GPSCode, airport.IATACode,
+			airport.LocalCode, airport.HomeLink, airport.WikipediaLink, airport.Keywords, airport.TimeZone, airport.UTCOffsetHours, airport.DST, airport.Origin); err != nil {
+			return fmt.Errorf("airport %s: %v", airport.ICAOCode, err)
+		}
+		if _, err := rtree.Exec(airport.ID, airport.LatitudeDeg, airport.LatitudeDeg, airport.LongitudeDeg, airport.LongitudeDeg); err != nil {
+			return fmt.Errorf("airport %s: %v", airport.ICAOCode, err)
+		}
+		airportIDs[airport.ID] = true
+	}
+	stmt.Close()
+	rtree.Close()
+
+	stmt, err = tx.Prepare("INSERT INTO runways VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	for airportID, runways := range databases.Runways.Runways {
+		if !airportIDs[airportID] {
+			continue
+		}
+		for _, runway := range runways {
+			if _, err := stmt.Exec(runway.ID, runway.AirportID, runway.AirportIdent, runway.LengthFt, runway.WidthFt, runway.Surface, runway.Lighted, runway.Closed,
+				runway.LowEndIdent, runway.LowEndLatitudeDeg, runway.LowEndLongitudeDeg, runway.LowEndElevationFt, runway.LowEndHeadingDegT, runway.LowEndDisplacedThresholdFt,
+				runway.HighEndIdent, runway.HighEndLatitudeDeg, runway.HighEndLongitudeDeg, runway.HighEndElevationFt, runway.HighEndHeadingDegT, runway.HighEndDisplacedThresholdFt,
+				runway.Origin); err != nil {
+				return fmt.Errorf("runway %d: %v", runway.ID, err)
+			}
+		}
+	}
+	stmt.Close()
+
+	stmt, err = tx.Prepare("INSERT INTO frequencies VALUES (?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	for airportID, frequencies := range databases.Frequencies.Frequencies {
+		if !airportIDs[airportID] {
+			continue
+		}
+		for _, frequency := range frequencies {
+			if _, err := stmt.Exec(frequency.ID, frequency.AirportID, frequency.AirportIdent, frequency.Type, frequency.Description, frequency.FrequencyMHZ, frequency.Origin); err != nil {
+				return fmt.Errorf("frequency %d: %v", frequency.ID, err)
+			}
+		}
+	}
+	stmt.Close()
+
+	stmt, err = tx.Prepare("INSERT INTO navaids VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	rtree, err = tx.Prepare("INSERT INTO navaids_rtree VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	for _, navaid := range databases.Navaids.Navaids {
+		if _, err := stmt.Exec(navaid.ID, navaid.Filename, navaid.Ident, navaid.Name, navaid.Type, navaid.FrequencyKHZ, navaid.LatitudeDeg, navaid.LongitudeDeg,
+			navaid.ElevationFt, navaid.ISOCountry, navaid.DMEFrequencyKHZ, navaid.DMEChannel, navaid.DMELatitudeDeg, navaid.DMELongitudeDeg, navaid.DMEElevationFt,
+			navaid.SlavedVariationDeg, navaid.MagneticVariationDeg, navaid.UsageType, navaid.Power, navaid.AssociatedAirport, navaid.Origin); err != nil {
+			return fmt.Errorf("navaid %s: %v", navaid.Ident, err)
+		}
+		if _, err := rtree.Exec(navaid.ID, navaid.LatitudeDeg, navaid.LatitudeDeg, navaid.LongitudeDeg, navaid.LongitudeDeg); err != nil {
+			return fmt.Errorf("navaid %s: %v", navaid.Ident, err)
+		}
+	}
+	stmt.Close()
+	rtree.Close()
+	return nil
+}
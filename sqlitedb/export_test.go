@@ -0,0 +1,128 @@
+package sqlitedb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	alphafoxtrot "github.com/grumpypixel/go-airport-finder"
+)
+
+const testDataDir = "../testdata/ourairports"
+
+func exportTestData(t *testing.T) (*alphafoxtrot.AirportFinder, string) {
+	t.Helper()
+	finder := alphafoxtrot.NewAirportFinder()
+	if errs := finder.Load(alphafoxtrot.PresetLoadOptions(testDataDir), alphafoxtrot.AirportTypeAll); len(errs) > 0 {
+		t.Fatalf("load: %v", errs)
+	}
+	file := filepath.Join(t.TempDir(), "airports.sqlite")
+	if err := Export(finder, file); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	return finder, file
+}
+
+func TestExportLoadRoundTrip(t *testing.T) {
+	finder, file := exportTestData(t)
+	loaded, err := Load(file, alphafoxtrot.AirportTypeAll)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	want, got := finder.Databases(), loaded.Databases()
+	if len(got.Airports.Airports) != len(want.Airports.Airports) || len(got.Navaids.Navaids) != len(want.Navaids.Navaids) ||
+		len(got.Regions.Regions) != len(want.Regions.Regions) || len(got.Countries.Countries) != len(want.Countries.Countries) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for _, icaoCode := range []string{"KLAX", "KSMO", "KLGB", "EDDF"} {
+		original, airport := finder.FindAirportByICAOCode(icaoCode), loaded.FindAirportByICAOCode(icaoCode)
+		if airport == nil {
+			t.Errorf("%s: missing", icaoCode)
+			continue
+		}
+		if airport.Name != original.Name || airport.LatitudeDeg != original.LatitudeDeg ||
+			airport.LongitudeDeg != original.LongitudeDeg || airport.Region.ISOCode != original.Region.ISOCode ||
+			len(airport.Runways) != len(original.Runways) || len(airport.Frequencies) != len(original.Frequencies) ||
+			len(airport.Navaids) != len(original.Navaids) {
+			t.Errorf("%s: got %+v, want %+v", icaoCode, airport, original)
+		}
+	}
+	runways := loaded.FindAirportByICAOCode("KSMO").Runways
+	if len(runways) != 1 || runways[0].LowEndIdent != "03" || runways[0].LowEndLongitudeDeg != -118.456 || !runways[0].Lighted {
+		t.Errorf("unexpected runways %+v", runways)
+	}
+}
+
+func TestExportRTree(t *testing.T) {
+	_, file := exportTestData(t)
+	source, err := OpenSource(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	rows, err := source.db.Query(`SELECT a.ident FROM airports a JOIN airports_rtree r ON a.id = r.id
+		WHERE r.min_lat >= 33.5 AND r.max_lat <= 34.5 AND r.min_lon >= -119 AND r.max_lon <= -118 ORDER BY a.ident`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	idents := make([]string, 0)
+	for rows.Next() {
+		var ident string
+		if err := rows.Scan(&ident); err != nil {
+			t.Fatal(err)
+		}
+		idents = append(idents, ident)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"2CA8", "KLAX", "KLGB", "KSMO"}
+	if len(idents) != len(want) {
+		t.Fatalf("got %v, want %v", idents, want)
+	}
+	for i := range want {
+		if idents[i] != want[i] {
+			t.Fatalf("got %v, want %v", idents, want)
+		}
+	}
+
+	var navaids int
+	if err := source.db.QueryRow(`SELECT COUNT(*) FROM navaids n JOIN navaids_rtree r ON n.id = r.id
+		WHERE r.min_lat >= 33.5 AND r.max_lat <= 34.5 AND r.min_lon >= -119 AND r.max_lon <= -118`).Scan(&navaids); err != nil {
+		t.Fatal(err)
+	}
+	if navaids == 0 {
+		t.Error("no navaids found in the R*Tree")
+	}
+}
+
+func TestForeignKeysOnEveryConnection(t *testing.T) {
+	_, file := exportTestData(t)
+	db, err := openForeignKeys(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		// the connections are held, so each iteration gets a new one from the pool
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		var enabled int
+		if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&enabled); err != nil {
+			t.Fatal(err)
+		}
+		if enabled != 1 {
+			t.Errorf("connection %d: foreign keys are not enforced", i)
+		}
+		if _, err := conn.ExecContext(ctx, "INSERT INTO runways (id, airport_ref) VALUES (?, ?)", 9000000+i, 424242); err == nil {
+			t.Errorf("connection %d: runway with an unknown airport was inserted", i)
+		}
+	}
+}
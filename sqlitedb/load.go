@@ -0,0 +1,215 @@
+package sqlitedb
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	alphafoxtrot "github.com/grumpypixel/go-airport-finder"
+)
+
+// Load builds an AirportFinder from a SQLite file written by Export
+func Load(file string, airportTypeFilter uint64) (*alphafoxtrot.AirportFinder, error) {
+	source, err := OpenSource(file)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+
+	finder := alphafoxtrot.NewAirportFinder()
+	if errs := finder.LoadSource(source, airportTypeFilter); len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return finder, nil
+}
+
+// LoadDatabases reads all tables written by Export
+func LoadDatabases(db *sql.DB, airportTypeFilter uint64) (*alphafoxtrot.Databases, error) {
+	databases := alphafoxtrot.NewDatabases()
+	source := &Source{db: db}
+	if err := source.Airports(func(airport *alphafoxtrot.AirportData) error {
+		if airport.TypeFlag&airportTypeFilter != 0 {
+			databases.Airports.Airports = append(databases.Airports.Airports, airport)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("cannot load airports: %v", err)
+	}
+	if err := source.Runways(func(runway *alphafoxtrot.RunwayData) error {
+		databases.Runways.Runways[runway.AirportID] = append(databases.Runways.Runways[runway.AirportID], runway)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("cannot load runways: %v", err)
+	}
+	if err := source.Frequencies(func(frequency *alphafoxtrot.FrequencyData) error {
+		databases.Frequencies.Frequencies[frequency.AirportID] = append(databases.Frequencies.Frequencies[frequency.AirportID], frequency)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("cannot load frequencies: %v", err)
+	}
+	if err := source.Navaids(func(navaid *alphafoxtrot.NavaidData) error {
+		databases.Navaids.Navaids = append(databases.Navaids.Navaids, navaid)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("cannot load navaids: %v", err)
+	}
+	if err := source.Regions(func(region *alphafoxtrot.RegionData) error {
+		databases.Regions.Regions[region.ISOCode] = region
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("cannot load regions: %v", err)
+	}
+	if err := source.Countries(func(country *alphafoxtrot.CountryData) error {
+		databases.Countries.Countries[country.ISOCode] = country
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("cannot load countries: %v", err)
+	}
+	return databases, nil
+}
+
+// Source streams the tables of a SQLite file written by Export, it implements alphafoxtrot.DataSource
+type Source struct {
+	db   *sql.DB
+	file string
+}
+
+func OpenSource(file string) (*Source, error) {
+	if _, err := os.Stat(file); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", file)
+	if err != nil {
+		return nil, err
+	}
+	return &Source{db: db, file: file}, nil
+}
+
+func (src *Source) Close() error {
+	return src.db.Close()
+}
+
+func (src *Source) Name() string {
+	return "sqlite:" + src.file
+}
+
+func (src *Source) Countries(fn func(*alphafoxtrot.CountryData) error) error {
+	rows, err := src.db.Query("SELECT id, code, name, continent, wikipedia_link, keywords FROM countries")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		country := &alphafoxtrot.CountryData{}
+		if err := rows.Scan(&country.ID, &country.ISOCode, &country.Name, &country.Continent, &country.WikipediaLink, &country.Keywords); err != nil {
+			return err
+		}
+		if err := fn(country); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (src *Source) Regions(fn func(*alphafoxtrot.RegionData) error) error {
+	rows, err := src.db.Query("SELECT id, code, local_code, name, continent, iso_country, wikipedia_link, keywords FROM regions")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		region := &alphafoxtrot.RegionData{}
+		if err := rows.Scan(&region.ID, &region.ISOCode, &region.LocalCode, &region.Name, &region.Continent, &region.ISOCountry, &region.WikipediaLink, &region.Keywords); err != nil {
+			return err
+		}
+		if err := fn(region); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (src *Source) Airports(fn func(*alphafoxtrot.AirportData) error) error {
+	rows, err := src.db.Query(`SELECT id, ident, type, name, latitude_deg, longitude_deg, elevation_ft, continent, iso_country, iso_region, municipality,
+		scheduled_service, gps_code, iata_code, local_code, home_link, wikipedia_link, keywords, timezone, utc_offset_hours, dst, origin FROM airports ORDER BY rowid`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		airport := &alphafoxtrot.AirportData{}
+		if err := rows.Scan(&airport.ID, &airport.ICAOCode, &airport.Type, &airport.Name, &airport.LatitudeDeg, &airport.LongitudeDeg, &airport.ElevationFt,
+			&airport.Continent, &airport.ISOCountry, &airport.ISORegion, &airport.Municipality, &airport.ScheduledService, &airport.GPSCode, &airport.IATACode,
+			&airport.LocalCode, &airport.HomeLink, &airport.WikipediaLink, &airport.Keywords, &airport.TimeZone, &airport.UTCOffsetHours, &airport.DST, &airport.Origin); err != nil {
+			return err
+		}
+		airport.TypeFlag = alphafoxtrot.AirportTypeFromString(airport.Type)
+		if err := fn(airport); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (src *Source) Runways(fn func(*alphafoxtrot.RunwayData) error) error {
+	rows, err := src.db.Query(`SELECT id, airport_ref, airport_ident, length_ft, width_ft, surface, lighted, closed,
+		le_ident, le_latitude_deg, le_longitude_deg, le_elevation_ft, le_heading_degT, le_displaced_threshold_ft,
+		he_ident, he_latitude_deg, he_longitude_deg, he_elevation_ft, he_heading_degT, he_displaced_threshold_ft, origin FROM runways ORDER BY rowid`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		runway := &alphafoxtrot.RunwayData{}
+		if err := rows.Scan(&runway.ID, &runway.AirportID, &runway.AirportIdent, &runway.LengthFt, &runway.WidthFt, &runway.Surface, &runway.Lighted, &runway.Closed,
+			&runway.LowEndIdent, &runway.LowEndLatitudeDeg, &runway.LowEndLongitudeDeg, &runway.LowEndElevationFt, &runway.LowEndHeadingDegT, &runway.LowEndDisplacedThresholdFt,
+			&runway.HighEndIdent, &runway.HighEndLatitudeDeg, &runway.HighEndLongitudeDeg, &runway.HighEndElevationFt, &runway.HighEndHeadingDegT, &runway.HighEndDisplacedThresholdFt,
+			&runway.Origin); err != nil {
+			return err
+		}
+		if err := fn(runway); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (src *Source) Frequencies(fn func(*alphafoxtrot.FrequencyData) error) error {
+	rows, err := src.db.Query("SELECT id, airport_ref, airport_ident, type, description, frequency_mhz, origin FROM frequencies ORDER BY rowid")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		frequency := &alphafoxtrot.FrequencyData{}
+		if err := rows.Scan(&frequency.ID, &frequency.AirportID, &frequency.AirportIdent, &frequency.Type, &frequency.Description, &frequency.FrequencyMHZ, &frequency.Origin); err != nil {
+			return err
+		}
+		if err := fn(frequency); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (src *Source) Navaids(fn func(*alphafoxtrot.NavaidData) error) error {
+	rows, err := src.db.Query(`SELECT id, filename, ident, name, type, frequency_khz, latitude_deg, longitude_deg, elevation_ft, iso_country,
+		dme_frequency_khz, dme_channel, dme_latitude_deg, dme_longitude_deg, dme_elevation_ft, slaved_variation_deg, magnetic_variation_deg,
+		usage_type, power, associated_airport, origin FROM navaids ORDER BY rowid`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		navaid := &alphafoxtrot.NavaidData{}
+		if err := rows.Scan(&navaid.ID, &navaid.Filename, &navaid.Ident, &navaid.Name, &navaid.Type, &navaid.FrequencyKHZ, &navaid.LatitudeDeg, &navaid.LongitudeDeg,
+			&navaid.ElevationFt, &navaid.ISOCountry, &navaid.DMEFrequencyKHZ, &navaid.DMEChannel, &navaid.DMELatitudeDeg, &navaid.DMELongitudeDeg, &navaid.DMEElevationFt,
+			&navaid.SlavedVariationDeg, &navaid.MagneticVariationDeg, &navaid.UsageType, &navaid.Power, &navaid.AssociatedAirport, &navaid.Origin); err != nil {
+			return err
+		}
+		if err := fn(navaid); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
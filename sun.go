@@ -0,0 +1,149 @@
+package alphafoxtrot
+
+import (
+	"math"
+	"time"
+)
+
+// see https://gml.noaa.gov/grad/solcalc/calcdetails.html
+
+const (
+	sunriseZenithDeg  = 90.833 // includes refraction and the radius of the sun
+	civilZenithDeg    = 96.0
+	nauticalZenithDeg = 102.0
+)
+
+// SunTimes are the sun events of a day in UTC; events which do not happen on that day are zero.
+// PolarDay and PolarNight are set if the sun does not rise or set at all.
+type SunTimes struct {
+	Sunrise      time.Time
+	Sunset       time.Time
+	SolarNoon    time.Time
+	CivilDawn    time.Time
+	CivilDusk    time.Time
+	NauticalDawn time.Time
+	NauticalDusk time.Time
+	PolarDay     bool
+	PolarNight   bool
+}
+
+// SunTimesAt calculates the sun times at the position for the calendar date of date in its own location,
+// e.g. pass time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC) for June 21. The events are those around the solar noon
+// at the position on that date, so sunset or dusk may fall on the next day in UTC.
+func SunTimesAt(latitudeDeg, longitudeDeg float64, date time.Time) *SunTimes {
+	// times are in minutes from midnight UTC of the date, local noon is around 12:00 minus 4 minutes per degree east
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	times := &SunTimes{}
+	times.SolarNoon = solarNoon(longitudeDeg, day)
+	var rises, sets bool
+	times.Sunrise, times.Sunset, rises, sets = sunEvents(latitudeDeg, longitudeDeg, day, times.SolarNoon, sunriseZenithDeg)
+	times.PolarDay = !rises && !sets && SolarElevationDeg(latitudeDeg, longitudeDeg, times.SolarNoon) > 0
+	times.PolarNight = !rises && !sets && !times.PolarDay
+	times.CivilDawn, times.CivilDusk, _, _ = sunEvents(latitudeDeg, longitudeDeg, day, times.SolarNoon, civilZenithDeg)
+	times.NauticalDawn, times.NauticalDusk, _, _ = sunEvents(latitudeDeg, longitudeDeg, day, times.SolarNoon, nauticalZenithDeg)
+	return times
+}
+
+// AirportSunTimes calculates the sun times at the airport
+func AirportSunTimes(airport *Airport, date time.Time) *SunTimes {
+	return SunTimesAt(airport.LatitudeDeg, airport.LongitudeDeg, date)
+}
+
+// IsNight returns true between the end of evening civil twilight and the beginning of morning civil twilight
+func IsNight(latitudeDeg, longitudeDeg float64, t time.Time) bool {
+	return SolarElevationDeg(latitudeDeg, longitudeDeg, t) < 90-civilZenithDeg
+}
+
+func IsNightAtAirport(airport *Airport, t time.Time) bool {
+	return IsNight(airport.LatitudeDeg, airport.LongitudeDeg, t)
+}
+
+// SolarElevationDeg returns the geometric elevation of the sun above the horizon, without refraction
+func SolarElevationDeg(latitudeDeg, longitudeDeg float64, t time.Time) float64 {
+	declination, equationOfTime := solarPosition(t)
+	t = t.UTC()
+	minutes := float64(t.Hour()*60+t.Minute()) + float64(t.Second())/60
+	hourAngle := (minutes+equationOfTime+4*longitudeDeg)/4 - 180
+	lat := latitudeDeg * DegToRad
+	cosZenith := math.Sin(lat)*math.Sin(declination) + math.Cos(lat)*math.Cos(declination)*math.Cos(hourAngle*DegToRad)
+	return 90 - math.Acos(math.Max(-1, math.Min(1, cosZenith)))/DegToRad
+}
+
+func solarNoon(longitudeDeg float64, day time.Time) time.Time {
+	noon := day.Add(time.Duration((720 - 4*longitudeDeg) * float64(time.Minute)))
+	for i := 0; i < 2; i++ {
+		_, equationOfTime := solarPosition(noon)
+		noon = day.Add(time.Duration((720 - 4*longitudeDeg - equationOfTime) * float64(time.Minute)))
+	}
+	return noon
+}
+
+// sunEvents returns the times before and after noon when the sun crosses the zenith angle
+func sunEvents(latitudeDeg, longitudeDeg float64, day, noon time.Time, zenithDeg float64) (time.Time, time.Time, bool, bool) {
+	var morning, evening time.Time
+	rises, sets := true, true
+	for i, sign := range []float64{-1, 1} {
+		event := noon
+		for iteration := 0; iteration < 3; iteration++ {
+			hourAngle, ok := sunHourAngle(latitudeDeg, event, zenithDeg)
+			if !ok {
+				if i == 0 {
+					rises = false
+				} else {
+					sets = false
+				}
+				event = time.Time{}
+				break
+			}
+			_, equationOfTime := solarPosition(event)
+			minutes := 720 - 4*longitudeDeg - equationOfTime + sign*4*hourAngle
+			event = day.Add(time.Duration(minutes * float64(time.Minute)))
+		}
+		if i == 0 {
+			morning = event
+		} else {
+			evening = event
+		}
+	}
+	return morning, evening, rises, sets
+}
+
+// sunHourAngle returns the hour angle in degrees at which the sun is at the zenith angle, false if it never is on that day
+func sunHourAngle(latitudeDeg float64, t time.Time, zenithDeg float64) (float64, bool) {
+	declination, _ := solarPosition(t)
+	lat := latitudeDeg * DegToRad
+	cosHourAngle := (math.Cos(zenithDeg*DegToRad) - math.Sin(lat)*math.Sin(declination)) / (math.Cos(lat) * math.Cos(declination))
+	if cosHourAngle > 1 || cosHourAngle < -1 {
+		return 0, false
+	}
+	return math.Acos(cosHourAngle) / DegToRad, true
+}
+
+// solarPosition returns the declination of the sun in radians and the equation of time in minutes
+func solarPosition(t time.Time) (float64, float64) {
+	julianDay := float64(t.UTC().UnixNano())/float64(24*time.Hour) + 2440587.5
+	century := (julianDay - 2451545) / 36525
+
+	meanLongitude := math.Mod(280.46646+century*(36000.76983+century*0.0003032), 360)
+	meanAnomaly := 357.52911 + century*(35999.05029-0.0001537*century)
+	eccentricity := 0.016708634 - century*(0.000042037+0.0000001267*century)
+	anomaly := meanAnomaly * DegToRad
+	center := math.Sin(anomaly)*(1.914602-century*(0.004817+0.000014*century)) +
+		math.Sin(2*anomaly)*(0.019993-0.000101*century) +
+		math.Sin(3*anomaly)*0.000289
+	omega := (125.04 - 1934.136*century) * DegToRad
+	apparentLongitude := (meanLongitude + center - 0.00569 - 0.00478*math.Sin(omega)) * DegToRad
+	meanObliquity := 23 + (26+(21.448-century*(46.815+century*(0.00059-century*0.001813)))/60)/60
+	obliquity := (meanObliquity + 0.00256*math.Cos(omega)) * DegToRad
+
+	declination := math.Asin(math.Sin(obliquity) * math.Sin(apparentLongitude))
+
+	y := math.Pow(math.Tan(obliquity/2), 2)
+	longitude := meanLongitude * DegToRad
+	equationOfTime := 4 / DegToRad * (y*math.Sin(2*longitude) -
+		2*eccentricity*math.Sin(anomaly) +
+		4*eccentricity*y*math.Sin(anomaly)*math.Cos(2*longitude) -
+		0.5*y*y*math.Sin(4*longitude) -
+		1.25*eccentricity*eccentricity*math.Sin(2*anomaly))
+	return declination, equationOfTime
+}
@@ -0,0 +1,90 @@
+package alphafoxtrot
+
+import (
+	"testing"
+	"time"
+)
+
+// the expected times are from the NOAA solar calculator, rounded to the minute
+func checkSunTime(t *testing.T, name string, got time.Time, want string) {
+	t.Helper()
+	if want == "" {
+		if !got.IsZero() {
+			t.Errorf("%s: got %v, want none", name, got)
+		}
+		return
+	}
+	wantTime, err := time.Parse("2006-01-02 15:04", want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := got.Sub(wantTime); diff < -time.Minute || diff > time.Minute {
+		t.Errorf("%s: got %v, want %s UTC", name, got, want)
+	}
+}
+
+func TestSunTimesAt(t *testing.T) {
+	tests := []struct {
+		name                  string
+		lat, lon              float64
+		date                  time.Time
+		sunrise, sunset, noon string
+		civilDawn, civilDusk  string
+		nauticalDawn          string
+		polarDay, polarNight  bool
+	}{
+		{"KSMO June 21", 34.0158, -118.451, time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC),
+			"2024-06-21 12:43", "2024-06-22 03:08", "2024-06-21 19:56", "2024-06-21 12:14", "2024-06-22 03:38", "2024-06-21 11:38", false, false},
+		{"Tromsø June 21", 69.6833, 18.9189, time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC),
+			"", "", "2024-06-21 10:46", "", "", "", true, false},
+		{"Tromsø December 21", 69.6833, 18.9189, time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC),
+			"", "", "2024-12-21 10:43", "2024-12-21 08:32", "2024-12-21 12:53", "2024-12-21 06:47", false, true},
+		{"Longyearbyen January 15", 78.2461, 15.4656, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+			"", "", "2024-01-15 11:07", "", "", "2024-01-15 08:28", false, true},
+	}
+	for _, test := range tests {
+		times := SunTimesAt(test.lat, test.lon, test.date)
+		checkSunTime(t, test.name+" sunrise", times.Sunrise, test.sunrise)
+		checkSunTime(t, test.name+" sunset", times.Sunset, test.sunset)
+		checkSunTime(t, test.name+" solar noon", times.SolarNoon, test.noon)
+		checkSunTime(t, test.name+" civil dawn", times.CivilDawn, test.civilDawn)
+		checkSunTime(t, test.name+" civil dusk", times.CivilDusk, test.civilDusk)
+		checkSunTime(t, test.name+" nautical dawn", times.NauticalDawn, test.nauticalDawn)
+		if times.PolarDay != test.polarDay || times.PolarNight != test.polarNight {
+			t.Errorf("%s: got polar day %v and polar night %v", test.name, times.PolarDay, times.PolarNight)
+		}
+	}
+}
+
+func TestSunTimesAtLocalDate(t *testing.T) {
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skip(err)
+	}
+	// 23:00 on June 21 in Los Angeles is already June 22 in UTC, the date in the location counts
+	times := SunTimesAt(34.0158, -118.451, time.Date(2024, 6, 21, 23, 0, 0, 0, losAngeles))
+	checkSunTime(t, "KSMO sunrise", times.Sunrise, "2024-06-21 12:43")
+}
+
+func TestIsNight(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lon float64
+		time     time.Time
+		want     bool
+	}{
+		{"KSMO noon", 34.0158, -118.451, time.Date(2024, 6, 21, 19, 56, 0, 0, time.UTC), false},
+		{"KSMO 3 am", 34.0158, -118.451, time.Date(2024, 6, 21, 10, 0, 0, 0, time.UTC), true},
+		{"KSMO after sunset, civil twilight", 34.0158, -118.451, time.Date(2024, 6, 22, 3, 20, 0, 0, time.UTC), false},
+		{"KSMO after civil dusk", 34.0158, -118.451, time.Date(2024, 6, 22, 3, 45, 0, 0, time.UTC), true},
+		{"Tromsø midnight sun", 69.6833, 18.9189, time.Date(2024, 6, 21, 22, 0, 0, 0, time.UTC), false},
+		{"Tromsø polar night noon, civil twilight", 69.6833, 18.9189, time.Date(2024, 12, 21, 10, 43, 0, 0, time.UTC), false},
+		{"Tromsø polar night evening", 69.6833, 18.9189, time.Date(2024, 12, 21, 16, 0, 0, 0, time.UTC), true},
+		{"Longyearbyen polar night noon", 78.2461, 15.4656, time.Date(2024, 1, 15, 11, 7, 0, 0, time.UTC), true},
+	}
+	for _, test := range tests {
+		if got := IsNight(test.lat, test.lon, test.time); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package alphafoxtrot
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// e.g.
+// TAF KSFO 011720Z 0118/0224 28015KT P6SM FEW020
+//   FM020200 29012KT P6SM SKC
+//   TEMPO 0210/0214 3SM BR BKN008
+//   PROB30 0214/0216 1SM FG OVC002
+
+const (
+	TAFGroupBase        = "BASE"
+	TAFGroupFrom        = "FM"
+	TAFGroupBecoming    = "BECMG"
+	TAFGroupTemporary   = "TEMPO"
+	TAFGroupProbability = "PROB"
+)
+
+var (
+	tafPeriodPattern      = regexp.MustCompile(`^(\d{2})(\d{2})/(\d{2})(\d{2})$`)
+	tafFromPattern        = regexp.MustCompile(`^FM(\d{2})(\d{2})(\d{2})$`)
+	tafProbabilityPattern = regexp.MustCompile(`^PROB(\d{2})$`)
+)
+
+// TAFGroup is a change group of a forecast; PROB groups followed by TEMPO have the type TEMPO and a probability
+type TAFGroup struct {
+	Type        string
+	Probability int
+	From        time.Time
+	To          time.Time
+	Conditions
+}
+
+type TAF struct {
+	Raw       string
+	Station   string
+	IssueTime time.Time
+	ValidFrom time.Time
+	ValidTo   time.Time
+	Amended   bool
+	Corrected bool
+	Groups    []TAFGroup
+	Remarks   string
+}
+
+// Forecast are the conditions forecast for a point in time.
+// Conditions are the prevailing conditions, Temporary lists the TEMPO and PROB groups in effect
+// and the BECMG groups whose change is in progress.
+type Forecast struct {
+	Station        string
+	Time           time.Time
+	FlightCategory string
+	Conditions
+	Temporary           []TAFGroup
+	WorstFlightCategory string
+}
+
+// ParseTAF decodes a TAF bulletin, the days of month in the report are resolved relative to now
+func ParseTAF(report string, now time.Time) (*TAF, error) {
+	tokens := reportTokens(report)
+	taf := &TAF{Raw: strings.Join(tokens, " ")}
+	if len(tokens) > 0 && tokens[0] == "TAF" {
+		tokens = tokens[1:]
+	}
+	for len(tokens) > 0 && (tokens[0] == "AMD" || tokens[0] == "COR") {
+		taf.Amended = taf.Amended || tokens[0] == "AMD"
+		taf.Corrected = taf.Corrected || tokens[0] == "COR"
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 || !stationPattern.MatchString(tokens[0]) {
+		return nil, fmt.Errorf("taf: missing station in %q", report)
+	}
+	taf.Station = tokens[0]
+	tokens = tokens[1:]
+
+	if len(tokens) > 0 {
+		if match := reportTimePattern.FindStringSubmatch(tokens[0]); match != nil {
+			day, _ := strconv.Atoi(match[1])
+			hour, _ := strconv.Atoi(match[2])
+			minute, _ := strconv.Atoi(match[3])
+			taf.IssueTime = parseReportTime(day, hour, minute, now)
+			tokens = tokens[1:]
+		}
+	}
+	if len(tokens) > 0 && tokens[0] == "NIL" {
+		return nil, fmt.Errorf("taf: missing report for %s", taf.Station)
+	}
+	if len(tokens) == 0 || !tafPeriodPattern.MatchString(tokens[0]) {
+		return nil, fmt.Errorf("taf: missing validity period in %q", report)
+	}
+	reference := taf.IssueTime
+	if reference.IsZero() {
+		reference = now
+	}
+	taf.ValidFrom, taf.ValidTo = parseTAFPeriod(tokens[0], reference)
+	tokens = tokens[1:]
+
+	group := &TAFGroup{Type: TAFGroupBase, From: taf.ValidFrom, To: taf.ValidTo}
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if token == "RMK" {
+			taf.Remarks = strings.Join(tokens[i+1:], " ")
+			break
+		}
+		if match := tafFromPattern.FindStringSubmatch(token); match != nil {
+			taf.Groups = append(taf.Groups, *group)
+			day, _ := strconv.Atoi(match[1])
+			hour, _ := strconv.Atoi(match[2])
+			minute, _ := strconv.Atoi(match[3])
+			group = &TAFGroup{Type: TAFGroupFrom, From: tafTime(taf.ValidFrom, day, hour, minute), To: taf.ValidTo}
+			continue
+		}
+		probability := 0
+		if match := tafProbabilityPattern.FindStringSubmatch(token); match != nil {
+			probability, _ = strconv.Atoi(match[1])
+			if i+1 < len(tokens) && tokens[i+1] == TAFGroupTemporary {
+				i++
+				token = tokens[i]
+			} else {
+				token = TAFGroupProbability
+			}
+		}
+		if token == TAFGroupBecoming || token == TAFGroupTemporary || token == TAFGroupProbability {
+			if i+1 >= len(tokens) || !tafPeriodPattern.MatchString(tokens[i+1]) {
+				return nil, fmt.Errorf("taf: missing period after %s", token)
+			}
+			taf.Groups = append(taf.Groups, *group)
+			i++
+			from, to := parseTAFPeriod(tokens[i], taf.ValidFrom)
+			group = &TAFGroup{Type: token, Probability: probability, From: from, To: to}
+			continue
+		}
+		if n := group.Conditions.parseToken(tokens, i); n > 0 {
+			i += n - 1
+		}
+		// temperature (TX/TN), wind shear and other groups are skipped
+	}
+	taf.Groups = append(taf.Groups, *group)
+
+	// FM groups last until the next FM group
+	var previous *TAFGroup
+	for i := range taf.Groups {
+		if taf.Groups[i].Type != TAFGroupBase && taf.Groups[i].Type != TAFGroupFrom {
+			continue
+		}
+		if previous != nil {
+			previous.To = taf.Groups[i].From
+		}
+		previous = &taf.Groups[i]
+	}
+	return taf, nil
+}
+
+// ForecastAt merges the groups in effect at the time, nil if the time is outside the validity period.
+// BECMG changes are applied at the end of their period; during the period they count like a temporary group,
+// so the worst flight category covers both the old and the new conditions.
+func (t *TAF) ForecastAt(at time.Time) *Forecast {
+	if at.Before(t.ValidFrom) || !at.Before(t.ValidTo) {
+		return nil
+	}
+	forecast := &Forecast{Station: t.Station, Time: at, Temporary: make([]TAFGroup, 0)}
+	for _, group := range t.Groups {
+		switch group.Type {
+		case TAFGroupBase, TAFGroupFrom:
+			if !at.Before(group.From) {
+				forecast.Conditions = group.Conditions
+			}
+		case TAFGroupBecoming:
+			if !at.Before(group.To) {
+				forecast.Conditions.merge(&group.Conditions)
+			} else if !at.Before(group.From) {
+				forecast.Temporary = append(forecast.Temporary, group)
+			}
+		default:
+			if !at.Before(group.From) && at.Before(group.To) {
+				forecast.Temporary = append(forecast.Temporary, group)
+			}
+		}
+	}
+	forecast.FlightCategory = forecast.Conditions.FlightCategory()
+	forecast.WorstFlightCategory = forecast.FlightCategory
+	for _, group := range forecast.Temporary {
+		conditions := forecast.Conditions
+		conditions.merge(&group.Conditions)
+		forecast.WorstFlightCategory = worseFlightCategory(forecast.WorstFlightCategory, conditions.FlightCategory())
+	}
+	return forecast
+}
+
+// merge overwrites the conditions with the elements present in the other conditions
+func (c *Conditions) merge(other *Conditions) {
+	if other.Wind != nil {
+		c.Wind = other.Wind
+	}
+	if other.HasVisibility {
+		c.VisibilityMeters = other.VisibilityMeters
+		c.HasVisibility = true
+		c.CAVOK = other.CAVOK
+	}
+	if other.Weather != nil {
+		c.Weather = other.Weather
+	}
+	if other.CAVOK || other.SkyClear || len(other.Clouds) > 0 {
+		c.Clouds = other.Clouds
+		c.SkyClear = other.SkyClear
+	}
+}
+
+var flightCategoryRanks = map[string]int{
+	FlightCategoryUnknown: 0,
+	FlightCategoryVFR:     1,
+	FlightCategoryMVFR:    2,
+	FlightCategoryIFR:     3,
+	FlightCategoryLIFR:    4,
+}
+
+func worseFlightCategory(a, b string) string {
+	if flightCategoryRanks[b] > flightCategoryRanks[a] {
+		return b
+	}
+	return a
+}
+
+// parseTAFPeriod parses DDHH/DDHH, hour 24 is the end of the day
+func parseTAFPeriod(period string, reference time.Time) (time.Time, time.Time) {
+	match := tafPeriodPattern.FindStringSubmatch(period)
+	fromDay, _ := strconv.Atoi(match[1])
+	fromHour, _ := strconv.Atoi(match[2])
+	toDay, _ := strconv.Atoi(match[3])
+	toHour, _ := strconv.Atoi(match[4])
+	from := tafTime(reference, fromDay, fromHour, 0)
+	return from, tafTime(from, toDay, toHour, 0)
+}
+
+// tafTime returns the first time with the day, hour and minute not before the reference day
+func tafTime(reference time.Time, day, hour, minute int) time.Time {
+	reference = reference.UTC()
+	t := time.Date(reference.Year(), reference.Month(), day, hour, minute, 0, 0, time.UTC)
+	if day < reference.Day() {
+		t = time.Date(reference.Year(), reference.Month()+1, day, hour, minute, 0, 0, time.UTC)
+	}
+	return t
+}
@@ -0,0 +1,171 @@
+package alphafoxtrot
+
+import (
+	"testing"
+	"time"
+)
+
+func readTestTAFs(t *testing.T) *WeatherDB {
+	t.Helper()
+	db := NewWeatherDB()
+	if err := db.ParseTAFs("testdata/metar/tafs.txt", testWeatherTime); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestParseTAF(t *testing.T) {
+	db := readTestTAFs(t)
+	ksfo := db.FindTAFByICAOCode("KSFO")
+	if ksfo == nil {
+		t.Fatal("KSFO: not found")
+	}
+	if !ksfo.IssueTime.Equal(time.Date(2024, 5, 1, 17, 20, 0, 0, time.UTC)) ||
+		!ksfo.ValidFrom.Equal(time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)) ||
+		!ksfo.ValidTo.Equal(time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("got issue time %v, validity %v - %v", ksfo.IssueTime, ksfo.ValidFrom, ksfo.ValidTo)
+	}
+	types := []string{TAFGroupBase, TAFGroupFrom, TAFGroupFrom, TAFGroupTemporary, TAFGroupFrom}
+	if len(ksfo.Groups) != len(types) {
+		t.Fatalf("got %d groups, want %d", len(ksfo.Groups), len(types))
+	}
+	for i, typ := range types {
+		if ksfo.Groups[i].Type != typ {
+			t.Errorf("group %d: got %s, want %s", i, ksfo.Groups[i].Type, typ)
+		}
+	}
+	// FM groups end at the next FM group, across the TEMPO group
+	if !ksfo.Groups[0].To.Equal(ksfo.Groups[1].From) || !ksfo.Groups[2].To.Equal(ksfo.Groups[4].From) || !ksfo.Groups[4].To.Equal(ksfo.ValidTo) {
+		t.Errorf("unexpected FM periods %+v", ksfo.Groups)
+	}
+
+	klax := db.FindTAFByICAOCode("KLAX")
+	if klax == nil || !klax.Amended {
+		t.Fatalf("KLAX: got %+v", klax)
+	}
+	if probability := klax.Groups[2]; probability.Type != TAFGroupProbability || probability.Probability != 30 {
+		t.Errorf("got %+v", probability)
+	}
+	eddf := db.FindTAFByICAOCode("EDDF")
+	if tempo := eddf.Groups[2]; tempo.Type != TAFGroupTemporary || tempo.Probability != 40 {
+		t.Errorf("got %+v", tempo)
+	}
+}
+
+func TestParseTAFErrors(t *testing.T) {
+	tests := []string{
+		"TAF",
+		"TAF KXYZ 011720Z NIL",
+		"TAF KXYZ 011720Z 28015KT P6SM",
+		"TAF KXYZ 011720Z 0118/0224 28015KT P6SM TEMPO 3SM BR",
+	}
+	for _, report := range tests {
+		if taf, err := ParseTAF(report, testWeatherTime); err == nil {
+			t.Errorf("%q: got %+v", report, taf)
+		}
+	}
+}
+
+func TestParseTAFMonthChange(t *testing.T) {
+	now := time.Date(2024, 4, 30, 23, 30, 0, 0, time.UTC)
+	taf, err := ParseTAF("TAF KXYZ 302320Z 0100/0206 18010KT P6SM SKC FM011200 20015KT P6SM SKC", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !taf.ValidFrom.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) || !taf.ValidTo.Equal(time.Date(2024, 5, 2, 6, 0, 0, 0, time.UTC)) {
+		t.Errorf("got validity %v - %v", taf.ValidFrom, taf.ValidTo)
+	}
+	if from := taf.Groups[1].From; !from.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("got FM at %v", from)
+	}
+}
+
+func TestForecastAt(t *testing.T) {
+	db := readTestTAFs(t)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 5, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name      string
+		icao      string
+		time      time.Time
+		category  string
+		worst     string
+		temporary int
+		windKt    int
+	}{
+		{"KSFO base", "KSFO", at(1, 18, 0), FlightCategoryVFR, FlightCategoryVFR, 0, 15},
+		{"KSFO before FM012200", "KSFO", at(1, 21, 59), FlightCategoryVFR, FlightCategoryVFR, 0, 15},
+		{"KSFO at FM012200", "KSFO", at(1, 22, 0), FlightCategoryVFR, FlightCategoryVFR, 0, 18},
+		{"KSFO before FM020300", "KSFO", at(2, 2, 59), FlightCategoryVFR, FlightCategoryVFR, 0, 18},
+		{"KSFO at FM020300", "KSFO", at(2, 3, 0), FlightCategoryMVFR, FlightCategoryMVFR, 0, 10},
+		{"KSFO at the start of TEMPO", "KSFO", at(2, 6, 0), FlightCategoryMVFR, FlightCategoryIFR, 1, 10},
+		{"KSFO at the end of TEMPO", "KSFO", at(2, 10, 0), FlightCategoryMVFR, FlightCategoryMVFR, 0, 10},
+		{"KSFO at FM021600", "KSFO", at(2, 16, 0), FlightCategoryVFR, FlightCategoryVFR, 0, 12},
+		{"KSFO at the end of the validity", "KSFO", at(2, 23, 59), FlightCategoryVFR, FlightCategoryVFR, 0, 12},
+		{"KLAX at FM020400", "KLAX", at(2, 4, 0), FlightCategoryMVFR, FlightCategoryMVFR, 0, 5},
+		{"KLAX before PROB30", "KLAX", at(2, 9, 59), FlightCategoryMVFR, FlightCategoryMVFR, 0, 5},
+		{"KLAX at the start of PROB30", "KLAX", at(2, 10, 0), FlightCategoryMVFR, FlightCategoryLIFR, 1, 5},
+		{"KLAX at the end of PROB30", "KLAX", at(2, 14, 0), FlightCategoryMVFR, FlightCategoryMVFR, 0, 5},
+		{"KLAX before BECMG", "KLAX", at(2, 16, 59), FlightCategoryMVFR, FlightCategoryMVFR, 0, 5},
+		{"KLAX at the start of BECMG", "KLAX", at(2, 17, 0), FlightCategoryMVFR, FlightCategoryMVFR, 1, 5},
+		{"KLAX before the end of BECMG", "KLAX", at(2, 18, 59), FlightCategoryMVFR, FlightCategoryMVFR, 1, 5},
+		{"KLAX at the end of a BECMG", "KLAX", at(2, 19, 0), FlightCategoryMVFR, FlightCategoryMVFR, 0, 12},
+		{"EDDF before BECMG", "EDDF", at(2, 5, 59), FlightCategoryVFR, FlightCategoryVFR, 0, 12},
+		{"EDDF at the start of a deteriorating BECMG", "EDDF", at(2, 6, 0), FlightCategoryVFR, FlightCategoryIFR, 1, 12},
+		{"EDDF at the end of a deteriorating BECMG", "EDDF", at(2, 8, 0), FlightCategoryIFR, FlightCategoryIFR, 0, 12},
+		{"EDDF in PROB40 TEMPO", "EDDF", at(2, 12, 0), FlightCategoryIFR, FlightCategoryLIFR, 1, 12},
+	}
+	for _, test := range tests {
+		forecast := db.FindTAFByICAOCode(test.icao).ForecastAt(test.time)
+		if forecast == nil {
+			t.Errorf("%s: no forecast", test.name)
+			continue
+		}
+		if forecast.FlightCategory != test.category || forecast.WorstFlightCategory != test.worst || len(forecast.Temporary) != test.temporary {
+			t.Errorf("%s: got %s, worst %s, %d temporary groups", test.name, forecast.FlightCategory, forecast.WorstFlightCategory, len(forecast.Temporary))
+		}
+		if forecast.Wind == nil || forecast.Wind.SpeedKt != test.windKt {
+			t.Errorf("%s: got wind %+v", test.name, forecast.Wind)
+		}
+	}
+}
+
+func TestForecastAtMerge(t *testing.T) {
+	db := readTestTAFs(t)
+	// NSW clears the mist of FM020400, 9999 and BKN030 replace the visibility and clouds
+	klax := db.FindTAFByICAOCode("KLAX").ForecastAt(time.Date(2024, 5, 2, 19, 0, 0, 0, time.UTC))
+	if len(klax.Weather) != 0 || klax.VisibilityMeters != 10000 || len(klax.Clouds) != 1 || klax.Clouds[0].BaseFt != 3000 {
+		t.Errorf("unexpected conditions %+v", klax.Conditions)
+	}
+	// BECMG keeps the wind of the base group and ends CAVOK
+	eddf := db.FindTAFByICAOCode("EDDF").ForecastAt(time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC))
+	if eddf.CAVOK || eddf.VisibilityMeters != 4000 || eddf.Wind.DirectionDeg != 270 || len(eddf.Weather) != 1 || eddf.Weather[0] != "-RA" {
+		t.Errorf("unexpected conditions %+v", eddf.Conditions)
+	}
+}
+
+func TestForecastAtOutsideValidity(t *testing.T) {
+	ksfo := readTestTAFs(t).FindTAFByICAOCode("KSFO")
+	for _, at := range []time.Time{ksfo.ValidFrom.Add(-time.Minute), ksfo.ValidTo} {
+		if forecast := ksfo.ForecastAt(at); forecast != nil {
+			t.Errorf("%v: got %+v", at, forecast)
+		}
+	}
+}
+
+func TestForecastAtImprovingBECMG(t *testing.T) {
+	taf, err := ParseTAF("TAF KXYZ 011720Z 0118/0224 18005KT 1SM BR OVC004 BECMG 0200/0202 P6SM NSW SKC", testWeatherTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the better conditions are not forecast before the change is complete
+	during := taf.ForecastAt(time.Date(2024, 5, 2, 1, 0, 0, 0, time.UTC))
+	if during.FlightCategory != FlightCategoryLIFR || during.WorstFlightCategory != FlightCategoryLIFR || len(during.Temporary) != 1 {
+		t.Errorf("during BECMG: got %s, worst %s, %d temporary groups", during.FlightCategory, during.WorstFlightCategory, len(during.Temporary))
+	}
+	after := taf.ForecastAt(time.Date(2024, 5, 2, 2, 0, 0, 0, time.UTC))
+	if after.FlightCategory != FlightCategoryVFR || after.WorstFlightCategory != FlightCategoryVFR || len(after.Temporary) != 0 {
+		t.Errorf("after BECMG: got %s, worst %s, %d temporary groups", after.FlightCategory, after.WorstFlightCategory, len(after.Temporary))
+	}
+}
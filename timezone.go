@@ -0,0 +1,267 @@
+package alphafoxtrot
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // zones are resolved offline, so their rules are embedded as well
+)
+
+// zone.tab is the public domain tzdb table of the zones of each country with a reference point,
+// regions.tab maps ISO 3166-2 regions of countries with several zones
+//
+//go:embed timezones/zone.tab timezones/regions.tab
+var timeZoneFiles embed.FS
+
+// e.g. zone.tab: US	+340308-1181434	America/Los_Angeles	Pacific
+// e.g. regions.tab: US-TX	America/Chicago 31.5 -99.5	America/Denver 31.8 -106.5
+// e.g. regions.tab: US-IN	America/Indiana/Indianapolis	America/Chicago 41.77 -87.53 41.77 -86.52 40.73 -86.93 40.73 -87.53
+
+type TimeZoneData struct {
+	Name         string
+	ISOCountry   string
+	LatitudeDeg  float64
+	LongitudeDeg float64
+	Boundary     [][]float64 // longitude, latitude pairs of the area of the zone in a region
+}
+
+// TimeZoneDB resolves positions to IANA zone names by country, region, boundary and the nearest reference point.
+// Positions in regions which are crossed by a zone line without a boundary are resolved approximately.
+type TimeZoneDB struct {
+	Countries map[string][]*TimeZoneData
+	Regions   map[string][]*TimeZoneData
+	Zones     []*TimeZoneData
+}
+
+var (
+	defaultTimeZoneDB     *TimeZoneDB
+	defaultTimeZoneDBOnce sync.Once
+)
+
+func NewTimeZoneDB() *TimeZoneDB {
+	return &TimeZoneDB{
+		Countries: make(map[string][]*TimeZoneData),
+		Regions:   make(map[string][]*TimeZoneData),
+		Zones:     make([]*TimeZoneData, 0),
+	}
+}
+
+// EmbeddedTimeZoneDB returns the time zones shipped with the package
+func EmbeddedTimeZoneDB() *TimeZoneDB {
+	defaultTimeZoneDBOnce.Do(func() {
+		db := NewTimeZoneDB()
+		zones, _ := timeZoneFiles.ReadFile("timezones/zone.tab")
+		regions, _ := timeZoneFiles.ReadFile("timezones/regions.tab")
+		if err := db.ReadZoneTab(bytes.NewReader(zones)); err != nil {
+			log.Println(err)
+		}
+		if err := db.ReadRegions(bytes.NewReader(regions)); err != nil {
+			log.Println(err)
+		}
+		defaultTimeZoneDB = db
+	})
+	return defaultTimeZoneDB
+}
+
+// ReadZoneTab reads a tzdb zone.tab, e.g. a newer one than the embedded
+func (db *TimeZoneDB) ReadZoneTab(r io.Reader) error {
+	return readTimeZoneTable(r, func(line int, fields []string) {
+		if len(fields) < 3 {
+			log.Println(line, fmt.Errorf("zone.tab: expected 3 columns"))
+			return
+		}
+		lat, lon, err := parseISO6709(fields[1])
+		if err != nil {
+			log.Println(line, err)
+			return
+		}
+		zone := &TimeZoneData{Name: fields[2], ISOCountry: fields[0], LatitudeDeg: lat, LongitudeDeg: lon}
+		db.Zones = append(db.Zones, zone)
+		db.Countries[zone.ISOCountry] = append(db.Countries[zone.ISOCountry], zone)
+	})
+}
+
+// ReadRegions reads region lines: the region (or a country as default), then zones with an optional reference point
+// (latitude longitude) or boundary (at least three latitude longitude pairs)
+func (db *TimeZoneDB) ReadRegions(r io.Reader) error {
+	return readTimeZoneTable(r, func(line int, fields []string) {
+		if len(fields) < 2 {
+			log.Println(line, fmt.Errorf("regions.tab: missing zone"))
+			return
+		}
+		region := strings.TrimSpace(fields[0])
+		zones := make([]*TimeZoneData, 0, len(fields)-1)
+		for _, field := range fields[1:] {
+			values := strings.Fields(field)
+			if len(values) == 0 {
+				// e.g. a trailing tab
+				continue
+			}
+			zone := &TimeZoneData{Name: values[0], ISOCountry: strings.SplitN(region, "-", 2)[0]}
+			if len(values) != 1 && len(values) != 3 && (len(values) < 7 || len(values)%2 == 0) {
+				log.Println(line, fmt.Errorf("regions.tab: invalid reference point or boundary %q", field))
+				return
+			}
+			points := make([][]float64, 0, len(values)/2)
+			for i := 1; i+1 < len(values); i += 2 {
+				lat, latErr := ParseFloat(values[i])
+				lon, lonErr := ParseFloat(values[i+1])
+				if latErr != nil || lonErr != nil {
+					log.Println(line, fmt.Errorf("regions.tab: invalid reference point or boundary %q", field))
+					return
+				}
+				points = append(points, []float64{lon, lat})
+			}
+			if len(points) == 1 {
+				zone.LatitudeDeg, zone.LongitudeDeg = points[0][1], points[0][0]
+			} else if len(points) > 1 {
+				zone.Boundary = points
+			}
+			zones = append(zones, zone)
+		}
+		if region == "" || len(zones) == 0 {
+			log.Println(line, fmt.Errorf("regions.tab: missing region or zone"))
+			return
+		}
+		db.Regions[region] = zones
+	})
+}
+
+func readTimeZoneTable(r io.Reader, fn func(line int, fields []string)) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fn(line, strings.Split(text, "\t"))
+	}
+	return scanner.Err()
+}
+
+// parseISO6709 parses the coordinates of zone.tab, e.g. +340308-1181434 or -3352+15113
+func parseISO6709(str string) (float64, float64, error) {
+	split := 0
+	if len(str) > 1 {
+		split = strings.IndexAny(str[1:], "+-") + 1
+	}
+	if split == 0 {
+		return 0, 0, fmt.Errorf("zone.tab: invalid coordinates %q", str)
+	}
+	lat, err := parseISO6709Angle(str[:split], 2)
+	if err != nil {
+		return 0, 0, err
+	}
+	lon, err := parseISO6709Angle(str[split:], 3)
+	if err != nil {
+		return 0, 0, err
+	}
+	return lat, lon, nil
+}
+
+func parseISO6709Angle(str string, degreeDigits int) (float64, error) {
+	digits := str[1:]
+	if len(digits) != degreeDigits+2 && len(digits) != degreeDigits+4 {
+		return 0, fmt.Errorf("zone.tab: invalid angle %q", str)
+	}
+	value := 0.0
+	for i, divisor := 0, 1.0; i < len(digits); divisor *= 60 {
+		width := 2
+		if i == 0 {
+			width = degreeDigits
+		}
+		part, err := strconv.Atoi(digits[i : i+width])
+		if err != nil {
+			return 0, fmt.Errorf("zone.tab: invalid angle %q", str)
+		}
+		value += float64(part) / divisor
+		i += width
+	}
+	if str[0] == '-' {
+		value = -value
+	}
+	return value, nil
+}
+
+// Resolve returns the zone name of the position; the country and region are optional but required near borders
+func (db *TimeZoneDB) Resolve(latitudeDeg, longitudeDeg float64, isoCountry, isoRegion string) string {
+	if zones, ok := db.Regions[isoRegion]; ok && isoRegion != "" {
+		return regionTimeZone(zones, latitudeDeg, longitudeDeg)
+	}
+	if zones, ok := db.Countries[isoCountry]; ok && len(zones) == 1 {
+		return zones[0].Name
+	}
+	if zones, ok := db.Regions[isoCountry]; ok && isoCountry != "" {
+		return regionTimeZone(zones, latitudeDeg, longitudeDeg)
+	}
+	if zones, ok := db.Countries[isoCountry]; ok {
+		return nearestTimeZone(zones, latitudeDeg, longitudeDeg)
+	}
+	return nearestTimeZone(db.Zones, latitudeDeg, longitudeDeg)
+}
+
+// regionTimeZone returns the zone whose boundary contains the position, otherwise the nearest of the zones without a boundary
+func regionTimeZone(zones []*TimeZoneData, latitudeDeg, longitudeDeg float64) string {
+	others := make([]*TimeZoneData, 0, len(zones))
+	for _, zone := range zones {
+		if zone.Boundary == nil {
+			others = append(others, zone)
+		} else if ringContains(zone.Boundary, longitudeDeg, latitudeDeg) {
+			return zone.Name
+		}
+	}
+	if len(others) == 0 {
+		return nearestTimeZone(zones, latitudeDeg, longitudeDeg)
+	}
+	return nearestTimeZone(others, latitudeDeg, longitudeDeg)
+}
+
+func nearestTimeZone(zones []*TimeZoneData, latitudeDeg, longitudeDeg float64) string {
+	if len(zones) == 1 {
+		return zones[0].Name
+	}
+	name, nearest := "", math.MaxFloat64
+	for _, zone := range zones {
+		if distance := Distance(latitudeDeg, longitudeDeg, zone.LatitudeDeg, zone.LongitudeDeg); distance < nearest {
+			name, nearest = zone.Name, distance
+		}
+	}
+	return name
+}
+
+// ResolveTimeZone resolves the zone name with the embedded time zones
+func ResolveTimeZone(latitudeDeg, longitudeDeg float64, isoCountry, isoRegion string) string {
+	return EmbeddedTimeZoneDB().Resolve(latitudeDeg, longitudeDeg, isoCountry, isoRegion)
+}
+
+// AirportTimeZone returns the zone of the airport, the zone of the data (e.g. from OpenFlights) takes priority
+func AirportTimeZone(airport *Airport) string {
+	if airport.TimeZone != "" {
+		return airport.TimeZone
+	}
+	return ResolveTimeZone(airport.LatitudeDeg, airport.LongitudeDeg, airport.Country.ISOCode, airport.Region.ISOCode)
+}
+
+// AirportLocation returns the location of the airport's zone
+func AirportLocation(airport *Airport) (*time.Location, error) {
+	return time.LoadLocation(AirportTimeZone(airport))
+}
+
+// AirportLocalTime converts the time to the local time at the airport
+func AirportLocalTime(airport *Airport, t time.Time) (time.Time, error) {
+	location, err := AirportLocation(airport)
+	if err != nil {
+		return t, err
+	}
+	return t.In(location), nil
+}
@@ -0,0 +1,87 @@
+package alphafoxtrot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveTimeZoneBorderAirports(t *testing.T) {
+	tests := []struct {
+		icao       string
+		lat, lon   float64
+		isoCountry string
+		isoRegion  string
+		want       string
+	}{
+		{"KSBN", 41.7087, -86.3173, "US", "US-IN", "America/Indiana/Indianapolis"},
+		{"KEVV", 38.0370, -87.5324, "US", "US-IN", "America/Chicago"},
+		{"KGYY", 41.6163, -87.4128, "US", "US-IN", "America/Chicago"},
+		{"KIND", 39.7173, -86.2944, "US", "US-IN", "America/Indiana/Indianapolis"},
+		{"KPNS", 30.4734, -87.1866, "US", "US-FL", "America/Chicago"},
+		{"KTLH", 30.3965, -84.3503, "US", "US-FL", "America/New_York"},
+		{"KMIA", 25.7932, -80.2906, "US", "US-FL", "America/New_York"},
+		{"CYXC", 49.6108, -115.7820, "CA", "CA-BC", "America/Edmonton"},
+		{"CYVR", 49.1939, -123.1840, "CA", "CA-BC", "America/Vancouver"},
+		{"UWOO", 51.7958, 55.4567, "RU", "RU-ORE", "Asia/Yekaterinburg"},
+		{"UWPP", 53.1106, 45.0211, "RU", "RU-PNZ", "Europe/Moscow"},
+		{"UWSG", 51.7126, 46.1712, "RU", "RU-SAR", "Europe/Saratov"},
+		{"UWGG", 56.2301, 43.7840, "RU", "RU-NIZ", "Europe/Moscow"},
+		{"UNNT", 55.0126, 82.6507, "RU", "RU-NVS", "Asia/Novosibirsk"},
+		{"UEEE", 62.0933, 129.7710, "RU", "RU-SA", "Asia/Yakutsk"},
+		{"UHMM", 59.9101, 150.7200, "RU", "RU-MAG", "Asia/Magadan"},
+		{"UAAA", 43.3521, 77.0405, "KZ", "KZ-ALA", "Asia/Almaty"},
+		{"WAAA", -5.0616, 119.5540, "ID", "ID-SN", "Asia/Makassar"},
+	}
+	for _, test := range tests {
+		if got := ResolveTimeZone(test.lat, test.lon, test.isoCountry, test.isoRegion); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.icao, got, test.want)
+		}
+	}
+}
+
+func TestReadRegions(t *testing.T) {
+	db := NewTimeZoneDB()
+	regions := "# comment\n" +
+		"XX	Zone/Default\n" +
+		"XX-A	Zone/West	Zone/East 0 10 0 20 10 20 10 10\n" +
+		"XX-B	Zone/North 10 0	Zone/South -10 0\n" +
+		"XX-C	Zone/Broken 10\n" +
+		"XX-D	Zone/Trailing	\n" +
+		"XX-E		\n"
+	if err := db.ReadRegions(strings.NewReader(regions)); err != nil {
+		t.Fatal(err)
+	}
+	if len(db.Regions) != 4 {
+		t.Fatalf("got %d regions, want 4 without the invalid ones", len(db.Regions))
+	}
+	tests := []struct {
+		lat, lon  float64
+		isoRegion string
+		want      string
+	}{
+		{5, 15, "XX-A", "Zone/East"},
+		{5, 25, "XX-A", "Zone/West"},
+		{20, 15, "XX-A", "Zone/West"},
+		{3, 50, "XX-B", "Zone/North"},
+		{-1, 0, "XX-B", "Zone/South"},
+		{5, 15, "XX-D", "Zone/Trailing"},
+		{5, 15, "XX-Z", "Zone/Default"},
+	}
+	for _, test := range tests {
+		if got := db.Resolve(test.lat, test.lon, "XX", test.isoRegion); got != test.want {
+			t.Errorf("%v,%v %s: got %s, want %s", test.lat, test.lon, test.isoRegion, got, test.want)
+		}
+	}
+}
+
+func TestEmbeddedTimeZonesLoad(t *testing.T) {
+	db := EmbeddedTimeZoneDB()
+	for region, zones := range db.Regions {
+		for _, zone := range zones {
+			if _, err := time.LoadLocation(zone.Name); err != nil {
+				t.Errorf("%s: %v", region, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package alphafoxtrot
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+const (
+	IssueDuplicateIdent        = "duplicate_ident"
+	IssueDuplicateIATACode     = "duplicate_iata_code"
+	IssueUnknownAirportRef     = "unknown_airport_ref"
+	IssueAirportIdentMismatch  = "airport_ident_mismatch"
+	IssueUnknownNavaidAirport  = "unknown_navaid_airport"
+	IssueUnknownRegion         = "unknown_region"
+	IssueUnknownCountry        = "unknown_country"
+	IssueInvalidCoordinates    = "invalid_coordinates"
+	IssueRunwayHeadingMismatch = "runway_heading_mismatch"
+	IssueRunwayEndpointFar     = "runway_endpoint_far"
+)
+
+type ValidationOptions struct {
+	MaxRunwayEndpointDistanceMeters float64 // runway ends further away from the airport reference point are reported
+	MaxRunwayHeadingDeviationDeg    float64 // runway idents deviating more from the true heading are reported, this has to cover the magnetic variation
+}
+
+func DefaultValidationOptions() *ValidationOptions {
+	return &ValidationOptions{
+		MaxRunwayEndpointDistanceMeters: KilometersToMeters(10),
+		MaxRunwayHeadingDeviationDeg:    30,
+	}
+}
+
+type ValidationIssue struct {
+	Issue   string
+	Kind    string // see RecordKindAirport etc.
+	ID      uint64
+	Ident   string
+	Message string
+}
+
+func (issue ValidationIssue) String() string {
+	return fmt.Sprintf("%s %s #%d %s: %s", issue.Issue, issue.Kind, issue.ID, issue.Ident, issue.Message)
+}
+
+// Validate checks the loaded data for inconsistencies.
+// The checks for unknown airport references are only meaningful if the airports were loaded with AirportTypeAll,
+// the checks for unknown regions and countries are skipped if no regions or countries were loaded.
+func (af *AirportFinder) Validate(options *ValidationOptions) []ValidationIssue {
+	af.mutex.RLock()
+	defer af.mutex.RUnlock()
+
+	if options == nil {
+		options = DefaultValidationOptions()
+	}
+	issues := make([]ValidationIssue, 0)
+	report := func(issue, kind string, id uint64, ident, format string, args ...interface{}) {
+		issues = append(issues, ValidationIssue{issue, kind, id, ident, fmt.Sprintf(format, args...)})
+	}
+
+	airportsByID := make(map[uint64]*AirportData, len(af.airportDB.Airports))
+	airportsByIdent := make(map[string]*AirportData, len(af.airportDB.Airports))
+	airportsByIATACode := make(map[string]*AirportData)
+	for _, airport := range af.airportDB.Airports {
+		airportsByID[airport.ID] = airport
+
+		if other, ok := airportsByIdent[airport.ICAOCode]; ok {
+			report(IssueDuplicateIdent, RecordKindAirport, airport.ID, airport.ICAOCode, "ident is also used by airport #%d", other.ID)
+		} else {
+			airportsByIdent[airport.ICAOCode] = airport
+		}
+
+		if airport.IATACode != "" {
+			if other, ok := airportsByIATACode[airport.IATACode]; ok {
+				report(IssueDuplicateIATACode, RecordKindAirport, airport.ID, airport.ICAOCode, "IATA code %s is also used by airport #%d %s", airport.IATACode, other.ID, other.ICAOCode)
+			} else {
+				airportsByIATACode[airport.IATACode] = airport
+			}
+		}
+
+		if len(af.regionDB.Regions) > 0 && af.regionDB.FindByISOCode(airport.ISORegion) == nil {
+			report(IssueUnknownRegion, RecordKindAirport, airport.ID, airport.ICAOCode, "region %q not found", airport.ISORegion)
+		}
+		if len(af.countryDB.Countries) > 0 && af.countryDB.FindByISOCode(airport.ISOCountry) == nil {
+			report(IssueUnknownCountry, RecordKindAirport, airport.ID, airport.ICAOCode, "country %q not found", airport.ISOCountry)
+		}
+		if !validCoordinates(airport.LatitudeDeg, airport.LongitudeDeg) {
+			report(IssueInvalidCoordinates, RecordKindAirport, airport.ID, airport.ICAOCode, "coordinates %f,%f", airport.LatitudeDeg, airport.LongitudeDeg)
+		}
+	}
+
+	runwayAirportIDs := make([]uint64, 0, len(af.runwayDB.Runways))
+	for airportID := range af.runwayDB.Runways {
+		runwayAirportIDs = append(runwayAirportIDs, airportID)
+	}
+	sortUint64s(runwayAirportIDs)
+	for _, airportID := range runwayAirportIDs {
+		for _, runway := range af.runwayDB.Runways[airportID] {
+			ident := runway.AirportIdent + " " + runway.LowEndIdent + "/" + runway.HighEndIdent
+			airport, ok := airportsByID[runway.AirportID]
+			if !ok {
+				report(IssueUnknownAirportRef, RecordKindRunway, runway.ID, ident, "airport #%d not found", runway.AirportID)
+				continue
+			}
+			if airport.ICAOCode != runway.AirportIdent {
+				report(IssueAirportIdentMismatch, RecordKindRunway, runway.ID, ident, "airport #%d has ident %s", airport.ID, airport.ICAOCode)
+			}
+			ends := []struct {
+				ident        string
+				latitudeDeg  float64
+				longitudeDeg float64
+				headingDegT  float64
+			}{
+				{runway.LowEndIdent, runway.LowEndLatitudeDeg, runway.LowEndLongitudeDeg, runway.LowEndHeadingDegT},
+				{runway.HighEndIdent, runway.HighEndLatitudeDeg, runway.HighEndLongitudeDeg, runway.HighEndHeadingDegT},
+			}
+			for _, end := range ends {
+				if end.latitudeDeg != 0 || end.longitudeDeg != 0 {
+					if !validCoordinates(end.latitudeDeg, end.longitudeDeg) {
+						report(IssueInvalidCoordinates, RecordKindRunway, runway.ID, ident, "runway end %s coordinates %f,%f", end.ident, end.latitudeDeg, end.longitudeDeg)
+					} else if distance := Distance(airport.LatitudeDeg, airport.LongitudeDeg, end.latitudeDeg, end.longitudeDeg); distance > options.MaxRunwayEndpointDistanceMeters {
+						report(IssueRunwayEndpointFar, RecordKindRunway, runway.ID, ident, "runway end %s is %.1f km away from the airport", end.ident, MetersToKilometers(distance))
+					}
+				}
+				if end.headingDegT != 0 {
+					if identHeading, ok := RunwayIdentHeading(end.ident); ok {
+						if deviation := HeadingDifference(identHeading, end.headingDegT); deviation > options.MaxRunwayHeadingDeviationDeg {
+							report(IssueRunwayHeadingMismatch, RecordKindRunway, runway.ID, ident, "runway end %s has a true heading of %.0f°", end.ident, end.headingDegT)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	frequencyAirportIDs := make([]uint64, 0, len(af.frequencyDB.Frequencies))
+	for airportID := range af.frequencyDB.Frequencies {
+		frequencyAirportIDs = append(frequencyAirportIDs, airportID)
+	}
+	sortUint64s(frequencyAirportIDs)
+	for _, airportID := range frequencyAirportIDs {
+		for _, frequency := range af.frequencyDB.Frequencies[airportID] {
+			ident := frequency.AirportIdent + " " + frequency.Type
+			airport, ok := airportsByID[frequency.AirportID]
+			if !ok {
+				report(IssueUnknownAirportRef, RecordKindFrequency, frequency.ID, ident, "airport #%d not found", frequency.AirportID)
+				continue
+			}
+			if airport.ICAOCode != frequency.AirportIdent {
+				report(IssueAirportIdentMismatch, RecordKindFrequency, frequency.ID, ident, "airport #%d has ident %s", airport.ID, airport.ICAOCode)
+			}
+		}
+	}
+
+	for _, navaid := range af.navaidDB.Navaids {
+		if navaid.AssociatedAirport != "" {
+			if _, ok := airportsByIdent[navaid.AssociatedAirport]; !ok {
+				report(IssueUnknownNavaidAirport, RecordKindNavaid, navaid.ID, navaid.Ident, "associated airport %s not found", navaid.AssociatedAirport)
+			}
+		}
+		if !validCoordinates(navaid.LatitudeDeg, navaid.LongitudeDeg) {
+			report(IssueInvalidCoordinates, RecordKindNavaid, navaid.ID, navaid.Ident, "coordinates %f,%f", navaid.LatitudeDeg, navaid.LongitudeDeg)
+		}
+	}
+	return issues
+}
+
+// ValidationGate returns a check for RefreshOptions.Validate which fails if there are more than maxIssues issues,
+// a negative maxIssues always fails
+func ValidationGate(options *ValidationOptions, maxIssues int) func(*AirportFinder) error {
+	return func(finder *AirportFinder) error {
+		if maxIssues < 0 {
+			return fmt.Errorf("invalid max. validation issues %d", maxIssues)
+		}
+		issues := finder.Validate(options)
+		if len(issues) > maxIssues {
+			return fmt.Errorf("%d validation issues (max. %d), first: %v", len(issues), maxIssues, issues[0])
+		}
+		return nil
+	}
+}
+
+// RunwayIdentHeading returns the magnetic heading encoded in a runway ident like "07L" or "25",
+// false if the ident doesn't start with a runway number (e.g. "H1" or "N")
+func RunwayIdentHeading(ident string) (float64, bool) {
+	digits := 0
+	for digits < len(ident) && digits < 2 && ident[digits] >= '0' && ident[digits] <= '9' {
+		digits++
+	}
+	if digits == 0 {
+		return 0, false
+	}
+	number, err := strconv.Atoi(ident[:digits])
+	if err != nil || number < 1 || number > 36 {
+		return 0, false
+	}
+	return float64(number) * 10.0, true
+}
+
+// HeadingDifference returns the absolute difference between two headings in degrees (0-180)
+func HeadingDifference(a, b float64) float64 {
+	diff := math.Mod(math.Abs(a-b), 360.0)
+	if diff > 180.0 {
+		diff = 360.0 - diff
+	}
+	return diff
+}
+
+func validCoordinates(latitudeDeg, longitudeDeg float64) bool {
+	if latitudeDeg == 0 && longitudeDeg == 0 {
+		return false
+	}
+	return latitudeDeg >= -90 && latitudeDeg <= 90 && longitudeDeg >= -180 && longitudeDeg <= 180
+}
+
+func sortUint64s(values []uint64) {
+	sort.Slice(values, func(i, j int) bool {
+		return values[i] < values[j]
+	})
+}
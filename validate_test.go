@@ -0,0 +1,142 @@
+package alphafoxtrot
+
+import (
+	"math"
+	"testing"
+)
+
+func TestValidateTestData(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	if issues := finder.Validate(nil); len(issues) != 0 {
+		t.Errorf("unexpected issues %v", issues)
+	}
+}
+
+func TestRunwayLongitudes(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	runways := finder.FindAirportByICAOCode("KLAX").Runways
+	if len(runways) != 2 {
+		t.Fatalf("got %d runways, want 2", len(runways))
+	}
+	runway := runways[0]
+	if runway.LowEndIdent != "07L" {
+		runway = runways[1]
+	}
+	if runway.LowEndLatitudeDeg != 33.9358 || runway.LowEndLongitudeDeg != -118.419 ||
+		runway.HighEndLatitudeDeg != 33.9399 || runway.HighEndLongitudeDeg != -118.38 {
+		t.Errorf("unexpected runway ends %+v", runway)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	var klax *AirportData
+	for _, airport := range finder.airportDB.Airports {
+		if airport.ICAOCode == "KLAX" {
+			klax = airport
+		}
+	}
+	finder.airportDB.Airports = append(finder.airportDB.Airports,
+		&AirportData{ID: 9001, ICAOCode: "KLAX", IATACode: "LAX", ISORegion: "US-CA", ISOCountry: "US", LatitudeDeg: 33.9, LongitudeDeg: -118.4},
+		&AirportData{ID: 9002, ICAOCode: "XBAD", ISORegion: "XX-YY", ISOCountry: "XX", LatitudeDeg: 91, LongitudeDeg: 0},
+	)
+	finder.runwayDB.Runways[klax.ID] = append(finder.runwayDB.Runways[klax.ID],
+		&RunwayData{ID: 9101, AirportID: klax.ID, AirportIdent: "KLAX", LowEndIdent: "18", LowEndHeadingDegT: 90,
+			HighEndIdent: "36", HighEndLatitudeDeg: 34.5, HighEndLongitudeDeg: -118.4},
+		&RunwayData{ID: 9102, AirportID: klax.ID, AirportIdent: "KSMO", LowEndIdent: "H1", LowEndHeadingDegT: 90},
+	)
+	finder.runwayDB.Runways[9999] = []*RunwayData{{ID: 9103, AirportID: 9999, AirportIdent: "XNONE"}}
+	finder.frequencyDB.Frequencies[9999] = []*FrequencyData{{ID: 9201, AirportID: 9999, AirportIdent: "XNONE", Type: "TWR"}}
+	finder.navaidDB.Navaids = append(finder.navaidDB.Navaids, &NavaidData{ID: 9301, Ident: "XX", AssociatedAirport: "XNONE", LatitudeDeg: 0, LongitudeDeg: 181})
+
+	count := make(map[string]int)
+	for _, issue := range finder.Validate(nil) {
+		count[issue.Issue+" "+issue.Kind]++
+	}
+	want := map[string]int{
+		IssueDuplicateIdent + " " + RecordKindAirport:       1,
+		IssueDuplicateIATACode + " " + RecordKindAirport:    1,
+		IssueUnknownRegion + " " + RecordKindAirport:        1,
+		IssueUnknownCountry + " " + RecordKindAirport:       1,
+		IssueInvalidCoordinates + " " + RecordKindAirport:   1,
+		IssueRunwayHeadingMismatch + " " + RecordKindRunway: 1,
+		IssueRunwayEndpointFar + " " + RecordKindRunway:     1,
+		IssueAirportIdentMismatch + " " + RecordKindRunway:  1,
+		IssueUnknownAirportRef + " " + RecordKindRunway:     1,
+		IssueUnknownAirportRef + " " + RecordKindFrequency:  1,
+		IssueUnknownNavaidAirport + " " + RecordKindNavaid:  1,
+		IssueInvalidCoordinates + " " + RecordKindNavaid:    1,
+	}
+	for key, n := range want {
+		if count[key] != n {
+			t.Errorf("%s: got %d issues, want %d", key, count[key], n)
+		}
+	}
+	for key, n := range count {
+		if _, ok := want[key]; !ok {
+			t.Errorf("%s: got %d unexpected issues", key, n)
+		}
+	}
+}
+
+func TestRunwayIdentHeading(t *testing.T) {
+	tests := []struct {
+		ident   string
+		heading float64
+		ok      bool
+	}{
+		{"07L", 70, true},
+		{"25", 250, true},
+		{"36", 360, true},
+		{"9", 90, true},
+		{"00", 0, false},
+		{"37", 0, false},
+		{"H1", 0, false},
+		{"N", 0, false},
+		{"", 0, false},
+	}
+	for _, test := range tests {
+		heading, ok := RunwayIdentHeading(test.ident)
+		if heading != test.heading || ok != test.ok {
+			t.Errorf("%q: got %v %v, want %v %v", test.ident, heading, ok, test.heading, test.ok)
+		}
+	}
+}
+
+func TestHeadingDifference(t *testing.T) {
+	tests := []struct {
+		a, b, want float64
+	}{
+		{70, 83, 13},
+		{83, 70, 13},
+		{350, 10, 20},
+		{10, 350, 20},
+		{0, 180, 180},
+		{90, 270, 180},
+		{360, 0, 0},
+		{-10, 10, 20},
+		{720, 90, 90},
+	}
+	for _, test := range tests {
+		if got := HeadingDifference(test.a, test.b); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%v %v: got %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestValidationGate(t *testing.T) {
+	finder := loadTestFinder(t, AirportTypeAll)
+	if err := ValidationGate(nil, 0)(finder); err != nil {
+		t.Errorf("valid data: %v", err)
+	}
+	if err := ValidationGate(nil, -1)(finder); err == nil {
+		t.Error("negative max. issues: expected an error")
+	}
+	finder.airportDB.Airports = append(finder.airportDB.Airports, &AirportData{ID: 9002, ICAOCode: "XBAD", ISORegion: "US-CA", ISOCountry: "US", LatitudeDeg: 91})
+	if err := ValidationGate(nil, 0)(finder); err == nil {
+		t.Error("1 issue with max. 0: expected an error")
+	}
+	if err := ValidationGate(nil, 1)(finder); err != nil {
+		t.Errorf("1 issue with max. 1: %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package alphafoxtrot
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FlightCategoryVFR     = "VFR"
+	FlightCategoryMVFR    = "MVFR"
+	FlightCategoryIFR     = "IFR"
+	FlightCategoryLIFR    = "LIFR"
+	FlightCategoryUnknown = ""
+)
+
+const (
+	CloudCoverFew                = "FEW"
+	CloudCoverScattered          = "SCT"
+	CloudCoverBroken             = "BKN"
+	CloudCoverOvercast           = "OVC"
+	CloudCoverVerticalVisibility = "VV"
+)
+
+const noSignificantWeather = "NSW"
+
+// visibility reported as 9999 or CAVOK
+const unrestrictedVisibilityMeters = 10000.0
+
+var (
+	windPattern         = regexp.MustCompile(`^(\d{3}|VRB)(\d{2,3})(?:G(\d{2,3}))?(KT|MPS|KMH)$`)
+	windVariablePattern = regexp.MustCompile(`^(\d{3})V(\d{3})$`)
+	visibilityPattern   = regexp.MustCompile(`^(\d{4})(NDV)?$`)
+	visibilitySMPattern = regexp.MustCompile(`^([PM])?(\d+)?(?:(\d)/(\d{1,2}))?SM$`)
+	cloudPattern        = regexp.MustCompile(`^(FEW|SCT|BKN|OVC|VV)(\d{3}|///)(CB|TCU|///)?$`)
+	weatherPattern      = regexp.MustCompile(`^(\+|-|VC)?(MI|PR|BC|DR|BL|SH|TS|FZ)?((?:DZ|RA|SN|SG|IC|PL|GR|GS|UP|BR|FG|FU|VA|DU|SA|HZ|PY|PO|SQ|FC|SS|DS)*)$`)
+	reportTimePattern   = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})Z$`)
+	wholeNumberPattern  = regexp.MustCompile(`^\d{1,2}$`)
+	directionVisPattern = regexp.MustCompile(`^\d{4}(N|NE|E|SE|S|SW|W|NW)$`)
+	noSignificantClouds = map[string]bool{"SKC": true, "CLR": true, "NSC": true, "NCD": true}
+)
+
+type Wind struct {
+	DirectionDeg    int
+	Variable        bool
+	SpeedKt         int
+	GustKt          int
+	VariableFromDeg int
+	VariableToDeg   int
+}
+
+type Cloud struct {
+	Cover  string
+	BaseFt int64
+	Type   string
+}
+
+// Conditions are the wind, visibility, weather and clouds shared by METARs and TAF groups
+type Conditions struct {
+	Wind             *Wind
+	VisibilityMeters float64
+	HasVisibility    bool
+	CAVOK            bool
+	Weather          []string
+	Clouds           []Cloud
+	SkyClear         bool
+}
+
+// VisibilityStatuteMiles returns the visibility in statute miles.
+// It divides by MilesToMeters(1) since MetersToMiles isn't its exact inverse and 5 SM would come back as more than 5.
+func (c *Conditions) VisibilityStatuteMiles() float64 {
+	return c.VisibilityMeters / MilesToMeters(1)
+}
+
+// CeilingFt returns the base of the lowest broken or overcast layer or the vertical visibility
+func (c *Conditions) CeilingFt() (int64, bool) {
+	for _, cloud := range c.Clouds {
+		switch cloud.Cover {
+		case CloudCoverBroken, CloudCoverOvercast, CloudCoverVerticalVisibility:
+			return cloud.BaseFt, true
+		}
+	}
+	return 0, false
+}
+
+// FlightCategory returns VFR, MVFR, IFR or LIFR using the FAA ceiling and visibility limits
+func (c *Conditions) FlightCategory() string {
+	ceiling, hasCeiling := c.CeilingFt()
+	hasClouds := hasCeiling || len(c.Clouds) > 0 || c.SkyClear || c.CAVOK
+	if !c.HasVisibility && !hasClouds {
+		return FlightCategoryUnknown
+	}
+	visibility := c.VisibilityStatuteMiles()
+	switch {
+	case (hasCeiling && ceiling < 500) || (c.HasVisibility && visibility < 1):
+		return FlightCategoryLIFR
+	case (hasCeiling && ceiling < 1000) || (c.HasVisibility && visibility < 3):
+		return FlightCategoryIFR
+	case (hasCeiling && ceiling <= 3000) || (c.HasVisibility && visibility <= 5):
+		return FlightCategoryMVFR
+	}
+	return FlightCategoryVFR
+}
+
+// parseToken parses the condition at tokens[i] and returns the number of tokens consumed, 0 if it is not a condition
+func (c *Conditions) parseToken(tokens []string, i int) int {
+	token := tokens[i]
+	if match := windPattern.FindStringSubmatch(token); match != nil {
+		wind := &Wind{}
+		if match[1] == "VRB" {
+			wind.Variable = true
+		} else {
+			wind.DirectionDeg, _ = strconv.Atoi(match[1])
+		}
+		wind.SpeedKt = windSpeedKt(match[2], match[4])
+		if match[3] != "" {
+			wind.GustKt = windSpeedKt(match[3], match[4])
+		}
+		c.Wind = wind
+		return 1
+	}
+	if match := windVariablePattern.FindStringSubmatch(token); match != nil && c.Wind != nil {
+		c.Wind.VariableFromDeg, _ = strconv.Atoi(match[1])
+		c.Wind.VariableToDeg, _ = strconv.Atoi(match[2])
+		return 1
+	}
+	if token == "CAVOK" {
+		c.CAVOK = true
+		c.HasVisibility = true
+		c.VisibilityMeters = unrestrictedVisibilityMeters
+		return 1
+	}
+	if match := visibilityPattern.FindStringSubmatch(token); match != nil {
+		meters, _ := strconv.ParseFloat(match[1], 64)
+		if meters == 9999 {
+			meters = unrestrictedVisibilityMeters
+		}
+		c.VisibilityMeters = meters
+		c.HasVisibility = true
+		return 1
+	}
+	if directionVisPattern.MatchString(token) {
+		return 1
+	}
+	// e.g. 1 1/2SM
+	if wholeNumberPattern.MatchString(token) && i+1 < len(tokens) {
+		if match := visibilitySMPattern.FindStringSubmatch(tokens[i+1]); match != nil && match[2] == "" && match[3] != "" {
+			whole, _ := strconv.ParseFloat(token, 64)
+			c.setVisibilityStatuteMiles(whole + fraction(match[3], match[4]))
+			return 2
+		}
+	}
+	if match := visibilitySMPattern.FindStringSubmatch(token); match != nil && (match[2] != "" || match[3] != "") {
+		miles, _ := strconv.ParseFloat(match[2], 64)
+		if match[3] != "" {
+			miles += fraction(match[3], match[4])
+		}
+		c.setVisibilityStatuteMiles(miles)
+		return 1
+	}
+	if noSignificantClouds[token] {
+		c.SkyClear = true
+		return 1
+	}
+	if match := cloudPattern.FindStringSubmatch(token); match != nil {
+		cloud := Cloud{Cover: match[1]}
+		if hundreds, err := strconv.ParseInt(match[2], 10, 64); err == nil {
+			cloud.BaseFt = hundreds * 100
+		}
+		if match[3] != "///" {
+			cloud.Type = match[3]
+		}
+		c.Clouds = append(c.Clouds, cloud)
+		return 1
+	}
+	if token == noSignificantWeather {
+		c.Weather = []string{}
+		return 1
+	}
+	if len(token) >= 2 {
+		if match := weatherPattern.FindStringSubmatch(token); match != nil && (match[2] != "" || match[3] != "") {
+			c.Weather = append(c.Weather, token)
+			return 1
+		}
+	}
+	return 0
+}
+
+func (c *Conditions) setVisibilityStatuteMiles(miles float64) {
+	c.VisibilityMeters = MilesToMeters(miles)
+	c.HasVisibility = true
+}
+
+func fraction(numerator, denominator string) float64 {
+	n, _ := strconv.ParseFloat(numerator, 64)
+	d, _ := strconv.ParseFloat(denominator, 64)
+	if d == 0 {
+		return 0
+	}
+	return n / d
+}
+
+func windSpeedKt(speed, unit string) int {
+	value, _ := strconv.Atoi(speed)
+	switch unit {
+	case "MPS":
+		return int(float64(value)*1.94384 + 0.5)
+	case "KMH":
+		return int(float64(value)*0.539957 + 0.5)
+	}
+	return value
+}
+
+// parseReportTime resolves a DDHHMM time to the latest matching time not more than a day after now
+func parseReportTime(day, hour, minute int, now time.Time) time.Time {
+	now = now.UTC()
+	t := time.Date(now.Year(), now.Month(), day, hour, minute, 0, 0, time.UTC)
+	if t.After(now.Add(24 * time.Hour)) {
+		t = time.Date(now.Year(), now.Month()-1, day, hour, minute, 0, 0, time.UTC)
+	} else if t.Before(now.Add(-27 * 24 * time.Hour)) {
+		t = time.Date(now.Year(), now.Month()+1, day, hour, minute, 0, 0, time.UTC)
+	}
+	return t
+}
+
+// reportTokens splits a report into tokens, dropping the terminating "="
+func reportTokens(report string) []string {
+	return strings.Fields(strings.TrimRight(strings.TrimSpace(report), "="))
+}
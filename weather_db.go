@@ -0,0 +1,147 @@
+package alphafoxtrot
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// e.g. the NOAA cycle files:
+// 2024/05/01 17:51
+// KSMO 011751Z 25012KT 10SM FEW015 21/12 A2992
+
+var reportDatePattern = regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}$`)
+
+// reports are continued on indented lines and on lines starting with a change group or the remarks
+var continuationPattern = regexp.MustCompile(`^(\s|FM\d{6}\b|BECMG\b|TEMPO\b|PROB\d{2}\b|RMK\b)`)
+
+// WeatherDB keeps the latest METAR and TAF per station, it is safe for concurrent use
+type WeatherDB struct {
+	mutex  sync.RWMutex
+	METARs map[string]*METAR
+	TAFs   map[string]*TAF
+}
+
+func NewWeatherDB() *WeatherDB {
+	return &WeatherDB{
+		METARs: make(map[string]*METAR),
+		TAFs:   make(map[string]*TAF),
+	}
+}
+
+func (db *WeatherDB) Clear() {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	db.METARs = make(map[string]*METAR)
+	db.TAFs = make(map[string]*TAF)
+}
+
+// AddMETAR stores the report unless a newer one for the station is already stored
+func (db *WeatherDB) AddMETAR(metar *METAR) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if current, ok := db.METARs[metar.Station]; ok && current.Time.After(metar.Time) {
+		return
+	}
+	db.METARs[metar.Station] = metar
+}
+
+func (db *WeatherDB) FindMETARByICAOCode(icaoCode string) *METAR {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+	return db.METARs[icaoCode]
+}
+
+// AddTAF stores the forecast unless a newer one for the station is already stored
+func (db *WeatherDB) AddTAF(taf *TAF) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if current, ok := db.TAFs[taf.Station]; ok && current.IssueTime.After(taf.IssueTime) {
+		return
+	}
+	db.TAFs[taf.Station] = taf
+}
+
+func (db *WeatherDB) FindTAFByICAOCode(icaoCode string) *TAF {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+	return db.TAFs[icaoCode]
+}
+
+// ParseMETARs reads a file with one report per line, see ReadMETARs
+func (db *WeatherDB) ParseMETARs(file string, now time.Time) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return db.ReadMETARs(f, now)
+}
+
+// ReadMETARs reads METARs, one per line; indented lines continue the previous report, date lines and invalid reports are skipped
+func (db *WeatherDB) ReadMETARs(r io.Reader, now time.Time) error {
+	return readReports(r, func(line int, report string) {
+		metar, err := ParseMETAR(report, now)
+		if err != nil {
+			log.Println(line, err)
+			return
+		}
+		db.AddMETAR(metar)
+	})
+}
+
+// ParseTAFs reads a file with TAF bulletins, see ReadTAFs
+func (db *WeatherDB) ParseTAFs(file string, now time.Time) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return db.ReadTAFs(f, now)
+}
+
+// ReadTAFs reads TAF bulletins; indented lines and lines starting with a change group continue the previous bulletin
+func (db *WeatherDB) ReadTAFs(r io.Reader, now time.Time) error {
+	return readReports(r, func(line int, report string) {
+		taf, err := ParseTAF(report, now)
+		if err != nil {
+			log.Println(line, err)
+			return
+		}
+		db.AddTAF(taf)
+	})
+}
+
+// readReports joins continuation lines and calls fn with the line number where each report starts
+func readReports(r io.Reader, fn func(line int, report string)) error {
+	scanner := bufio.NewScanner(r)
+	report, start, line := "", 0, 0
+	flush := func() {
+		if report != "" {
+			fn(start, report)
+		}
+		report = ""
+	}
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || reportDatePattern.MatchString(trimmed) {
+			flush()
+			continue
+		}
+		if report != "" && continuationPattern.MatchString(text) && !strings.HasSuffix(report, "=") {
+			report += " " + trimmed
+			continue
+		}
+		flush()
+		report, start = trimmed, line
+	}
+	flush()
+	return scanner.Err()
+}
@@ -0,0 +1,357 @@
+package alphafoxtrot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strings"
+)
+
+// https://developer.x-plane.com/article/airport-data-apt-dat-12-00-file-format-specification/
+// e.g.
+// 1 125 0 0 KLAX Los Angeles Intl
+// 1302 datum_lat 33.942501
+// 100 45.72 2 0 0.25 1 3 1 07L 33.93589800 -118.41907200 0.00 0.00 3 8 0 1 25R 33.93989200 -118.38310000 291.69 0.00 3 10 0 1
+// 1050 118275 ATIS
+
+const (
+	OriginXPlane = "xplane"
+
+	xplaneRowLandAirport  = "1"
+	xplaneRowSeaplaneBase = "16"
+	xplaneRowHeliport     = "17"
+	xplaneRowLandRunway   = "100"
+	xplaneRowWaterRunway  = "101"
+	xplaneRowHelipad      = "102"
+	xplaneRowMetadata     = "1302"
+	xplaneRowEndOfFile    = "99"
+	xplaneClosedPrefix    = "[X]"
+	xplaneLargeRunwayFt   = 10000
+	xplaneMaxLineLength   = 1024 * 1024
+)
+
+// frequency row codes: 50-56 in 10 kHz units, 1050-1056 in kHz
+var xplaneFrequencyTypes = map[string]string{
+	"50": "ATIS", "51": "UNIC", "52": "CLD", "53": "GND", "54": "TWR", "55": "APP", "56": "DEP",
+	"1050": "ATIS", "1051": "UNIC", "1052": "CLD", "1053": "GND", "1054": "TWR", "1055": "APP", "1056": "DEP",
+}
+
+type xplaneAirport struct {
+	airport     *AirportData
+	hasDatum    bool
+	runways     []*RunwayData
+	frequencies []*FrequencyData
+}
+
+// ParseXPlane reads an X-Plane apt.dat file into airport, runway and frequency databases,
+// use NewAirportFinderWithDatabases to query them.
+// X-Plane has no airport sizes: land airports with a tower frequency are medium airports
+// (large if a runway is at least 10000 ft long), all others are small airports.
+// Airports whose name starts with [X] are closed. IDs are assigned in file order.
+func ParseXPlane(file string, airportTypeFilter uint64) (*Databases, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadXPlane(f, airportTypeFilter)
+}
+
+func ReadXPlane(r io.Reader, airportTypeFilter uint64) (*Databases, error) {
+	databases := NewDatabases()
+	var current *xplaneAirport
+	var airportID, runwayID, frequencyID uint64
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.finish()
+		airport := current.airport
+		if airport.TypeFlag&airportTypeFilter != 0 {
+			databases.Airports.Airports = append(databases.Airports.Airports, airport)
+			if len(current.runways) > 0 {
+				databases.Runways.Runways[airport.ID] = current.runways
+			}
+			if len(current.frequencies) > 0 {
+				databases.Frequencies.Frequencies[airport.ID] = current.frequencies
+			}
+		}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), xplaneMaxLineLength)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		// the first two lines are the origin (I or A) and the version
+		if line <= 2 || len(fields) == 0 {
+			continue
+		}
+
+		switch code := fields[0]; code {
+		case xplaneRowLandAirport, xplaneRowSeaplaneBase, xplaneRowHeliport:
+			flush()
+			if len(fields) < 5 {
+				log.Println(line, "xplane: invalid airport row")
+				continue
+			}
+			airportID++
+			current = newXPlaneAirport(airportID, code, fields)
+
+		case xplaneRowLandRunway, xplaneRowWaterRunway, xplaneRowHelipad:
+			if current == nil {
+				continue
+			}
+			runwayID++
+			runway, err := current.parseRunway(runwayID, code, fields)
+			if err != nil {
+				log.Println(line, current.airport.ICAOCode, err)
+				continue
+			}
+			current.runways = append(current.runways, runway)
+
+		case xplaneRowMetadata:
+			if current == nil || len(fields) < 3 {
+				continue
+			}
+			current.setMetadata(fields[1], strings.Join(fields[2:], " "))
+
+		case xplaneRowEndOfFile:
+			flush()
+
+		default:
+			frequencyType, ok := xplaneFrequencyTypes[code]
+			if !ok || current == nil || len(fields) < 2 {
+				continue
+			}
+			value, err := ParseFloat(fields[1])
+			if err != nil {
+				log.Println(line, current.airport.ICAOCode, err)
+				continue
+			}
+			mhz := value / 100
+			if len(code) == 4 {
+				mhz = value / 1000
+			}
+			frequencyID++
+			current.frequencies = append(current.frequencies, &FrequencyData{
+				ID:           frequencyID,
+				AirportID:    current.airport.ID,
+				AirportIdent: current.airport.ICAOCode,
+				Type:         frequencyType,
+				Description:  strings.Join(fields[2:], " "),
+				FrequencyMHZ: mhz,
+				Origin:       OriginXPlane,
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return databases, nil
+}
+
+func newXPlaneAirport(id uint64, code string, fields []string) *xplaneAirport {
+	elevation, _ := ParseInt(fields[1])
+	name := strings.Join(fields[5:], " ")
+	airport := &AirportData{
+		ID:          id,
+		ICAOCode:    fields[4],
+		Name:        name,
+		ElevationFt: elevation,
+		Origin:      OriginXPlane,
+	}
+	switch {
+	case strings.HasPrefix(name, xplaneClosedPrefix):
+		airport.Type = AirportTypeClosedName
+		airport.Name = strings.TrimSpace(strings.TrimPrefix(name, xplaneClosedPrefix))
+	case code == xplaneRowSeaplaneBase:
+		airport.Type = AirportTypeSeaplaneBaseName
+	case code == xplaneRowHeliport:
+		airport.Type = AirportTypeHeliportName
+	}
+	return &xplaneAirport{airport: airport}
+}
+
+func (xa *xplaneAirport) setMetadata(key, value string) {
+	airport := xa.airport
+	switch key {
+	case "datum_lat":
+		if lat, err := ParseFloat(value); err == nil {
+			airport.LatitudeDeg = lat
+			xa.hasDatum = true
+		}
+	case "datum_lon":
+		if lon, err := ParseFloat(value); err == nil {
+			airport.LongitudeDeg = lon
+		}
+	case "city":
+		airport.Municipality = value
+	case "icao_code":
+		airport.GPSCode = value
+	case "iata_code":
+		airport.IATACode = value
+	case "faa_code", "local_code":
+		airport.LocalCode = value
+	}
+}
+
+func (xa *xplaneAirport) parseRunway(id uint64, code string, fields []string) (*RunwayData, error) {
+	runway := &RunwayData{
+		ID:                 id,
+		AirportID:          xa.airport.ID,
+		AirportIdent:       xa.airport.ICAOCode,
+		LowEndElevationFt:  xa.airport.ElevationFt,
+		HighEndElevationFt: xa.airport.ElevationFt,
+		Origin:             OriginXPlane,
+	}
+	var err error
+	switch code {
+	case xplaneRowLandRunway:
+		// width surface shoulder smoothness centerline edge signs, then per end: ident lat lon displaced overrun markings approach tdz reil
+		if len(fields) < 26 {
+			return nil, fmt.Errorf("xplane: invalid runway row")
+		}
+		err = xa.parseRunwayEnds(runway, fields[1], fields[8:11], fields[17:20])
+		runway.Surface = xplaneSurface(fields[2])
+		runway.Lighted = fields[6] != "0"
+		displaced, _ := ParseFloat(fields[11])
+		runway.LowEndDisplacedThresholdFt = int64(math.Round(MetersToFeet(displaced)))
+		displaced, _ = ParseFloat(fields[20])
+		runway.HighEndDisplacedThresholdFt = int64(math.Round(MetersToFeet(displaced)))
+
+	case xplaneRowWaterRunway:
+		// width buoys, then per end: ident lat lon
+		if len(fields) < 9 {
+			return nil, fmt.Errorf("xplane: invalid water runway row")
+		}
+		err = xa.parseRunwayEnds(runway, fields[1], fields[3:6], fields[6:9])
+		runway.Surface = "WATER"
+
+	case xplaneRowHelipad:
+		// designator lat lon orientation length width surface markings shoulder smoothness edge
+		if len(fields) < 12 {
+			return nil, fmt.Errorf("xplane: invalid helipad row")
+		}
+		runway.LowEndIdent = fields[1]
+		if runway.LowEndLatitudeDeg, err = ParseFloat(fields[2]); err != nil {
+			return nil, err
+		}
+		if runway.LowEndLongitudeDeg, err = ParseFloat(fields[3]); err != nil {
+			return nil, err
+		}
+		runway.LowEndHeadingDegT, _ = ParseFloat(fields[4])
+		length, _ := ParseFloat(fields[5])
+		width, _ := ParseFloat(fields[6])
+		runway.LengthFt = int64(math.Round(MetersToFeet(length)))
+		runway.WidthFt = int64(math.Round(MetersToFeet(width)))
+		runway.Surface = xplaneSurface(fields[7])
+		runway.Lighted = fields[11] != "0"
+	}
+	if err != nil {
+		return nil, err
+	}
+	return runway, nil
+}
+
+func (xa *xplaneAirport) parseRunwayEnds(runway *RunwayData, width string, lowEnd, highEnd []string) error {
+	var err error
+	runway.LowEndIdent = lowEnd[0]
+	if runway.LowEndLatitudeDeg, err = ParseFloat(lowEnd[1]); err != nil {
+		return err
+	}
+	if runway.LowEndLongitudeDeg, err = ParseFloat(lowEnd[2]); err != nil {
+		return err
+	}
+	runway.HighEndIdent = highEnd[0]
+	if runway.HighEndLatitudeDeg, err = ParseFloat(highEnd[1]); err != nil {
+		return err
+	}
+	if runway.HighEndLongitudeDeg, err = ParseFloat(highEnd[2]); err != nil {
+		return err
+	}
+	widthM, _ := ParseFloat(width)
+	runway.WidthFt = int64(math.Round(MetersToFeet(widthM)))
+	length := Distance(runway.LowEndLatitudeDeg, runway.LowEndLongitudeDeg, runway.HighEndLatitudeDeg, runway.HighEndLongitudeDeg)
+	runway.LengthFt = int64(math.Round(MetersToFeet(length)))
+	runway.LowEndHeadingDegT = math.Round(Bearing(runway.LowEndLatitudeDeg, runway.LowEndLongitudeDeg, runway.HighEndLatitudeDeg, runway.HighEndLongitudeDeg)*10) / 10
+	runway.HighEndHeadingDegT = math.Round(Bearing(runway.HighEndLatitudeDeg, runway.HighEndLongitudeDeg, runway.LowEndLatitudeDeg, runway.LowEndLongitudeDeg)*10) / 10
+	return nil
+}
+
+// finish sets the position of airports without datum to the center of their runways and derives the airport type
+func (xa *xplaneAirport) finish() {
+	airport := xa.airport
+	if !xa.hasDatum && len(xa.runways) > 0 {
+		var lat, lon float64
+		n := 0
+		for _, runway := range xa.runways {
+			lat += runway.LowEndLatitudeDeg
+			lon += runway.LowEndLongitudeDeg
+			n++
+			if runway.HighEndIdent != "" {
+				lat += runway.HighEndLatitudeDeg
+				lon += runway.HighEndLongitudeDeg
+				n++
+			}
+		}
+		airport.LatitudeDeg = lat / float64(n)
+		airport.LongitudeDeg = lon / float64(n)
+	}
+
+	if airport.Type == "" {
+		airport.Type = AirportTypeSmallName
+		for _, frequency := range xa.frequencies {
+			if frequency.Type == "TWR" {
+				airport.Type = AirportTypeMediumName
+				break
+			}
+		}
+		if airport.Type == AirportTypeMediumName {
+			for _, runway := range xa.runways {
+				if runway.LengthFt >= xplaneLargeRunwayFt {
+					airport.Type = AirportTypeLargeName
+					break
+				}
+			}
+		}
+	}
+	airport.TypeFlag = AirportTypeFromString(airport.Type)
+	if airport.GPSCode == "" {
+		airport.GPSCode = airport.ICAOCode
+	}
+}
+
+// xplaneSurface maps X-Plane surface codes to the abbreviations used by OurAirports
+func xplaneSurface(code string) string {
+	value, err := ParseInt(code)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case value == 1, value >= 20 && value <= 38:
+		return "ASP"
+	case value == 2, value >= 50 && value <= 57:
+		return "CON"
+	case value == 3:
+		return "TURF"
+	case value == 4:
+		return "DIRT"
+	case value == 5:
+		return "GRAVEL"
+	case value == 12:
+		return "LAKEBED"
+	case value == 13:
+		return "WATER"
+	case value == 14:
+		return "SNOW"
+	}
+	return "UNKNOWN"
+}
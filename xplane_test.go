@@ -0,0 +1,139 @@
+package alphafoxtrot
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseXPlane(t *testing.T) {
+	databases, err := ParseXPlane("testdata/xplane/apt.dat", AirportTypeAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		icao      string
+		typ       string
+		name      string
+		elevation int64
+		latitude  float64
+		longitude float64
+		runways   int
+	}{
+		{"KLAX", AirportTypeLargeName, "Los Angeles Intl", 125, 33.942501, -118.407997, 2},
+		{"KSMO", AirportTypeMediumName, "Santa Monica Muni", 177, 34.015831, -118.450883, 1}, // no datum, center of the runway
+		{"W55", AirportTypeSeaplaneBaseName, "Kenmore Air Harbor", 0, 47.629, -122.33585, 1},
+		{"CA38", AirportTypeHeliportName, "Cedars-Sinai Medical Center Helipad", 95, 34.0751, -118.3809, 1},
+		{"KXYZ", AirportTypeClosedName, "Old Closed Field", 88, 34.1, -118.095, 1},
+	}
+	if len(databases.Airports.Airports) != len(tests) {
+		t.Fatalf("got %d airports, want %d", len(databases.Airports.Airports), len(tests))
+	}
+	for _, test := range tests {
+		airport := databases.Airports.FindByICAOCode(test.icao)
+		if airport == nil {
+			t.Errorf("%s: not found", test.icao)
+			continue
+		}
+		if airport.Type != test.typ || airport.TypeFlag != AirportTypeFromString(test.typ) || airport.Name != test.name || airport.ElevationFt != test.elevation {
+			t.Errorf("%s: got %s %q %d ft", test.icao, airport.Type, airport.Name, airport.ElevationFt)
+		}
+		if math.Abs(airport.LatitudeDeg-test.latitude) > 1e-5 || math.Abs(airport.LongitudeDeg-test.longitude) > 1e-5 {
+			t.Errorf("%s: got position %f,%f", test.icao, airport.LatitudeDeg, airport.LongitudeDeg)
+		}
+		if runways := databases.Runways.FindByAirportID(airport.ID); len(runways) != test.runways {
+			t.Errorf("%s: got %d runways, want %d", test.icao, len(runways), test.runways)
+		}
+	}
+}
+
+func TestParseXPlaneMetadata(t *testing.T) {
+	databases, err := ParseXPlane("testdata/xplane/apt.dat", AirportTypeAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	klax := databases.Airports.FindByICAOCode("KLAX")
+	if klax.Municipality != "Los Angeles" || klax.IATACode != "LAX" || klax.LocalCode != "LAX" || klax.GPSCode != "KLAX" {
+		t.Errorf("unexpected metadata %+v", klax)
+	}
+	if ksmo := databases.Airports.FindByICAOCode("KSMO"); ksmo.Municipality != "Santa Monica" || ksmo.IATACode != "SMO" || ksmo.GPSCode != "KSMO" {
+		t.Errorf("unexpected metadata %+v", ksmo)
+	}
+}
+
+func TestParseXPlaneRunways(t *testing.T) {
+	databases, err := ParseXPlane("testdata/xplane/apt.dat", AirportTypeAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	klax := databases.Airports.FindByICAOCode("KLAX")
+	runway := databases.Runways.FindByAirportID(klax.ID)[0]
+	if runway.LowEndIdent != "07L" || runway.HighEndIdent != "25R" || runway.Surface != "CON" || !runway.Lighted {
+		t.Errorf("unexpected runway %+v", runway)
+	}
+	if runway.WidthFt != 150 || runway.LengthFt < 10800 || runway.LengthFt > 11200 {
+		t.Errorf("got %d x %d ft", runway.LengthFt, runway.WidthFt)
+	}
+	if runway.LowEndHeadingDegT < 82 || runway.LowEndHeadingDegT > 84 || math.Abs(runway.HighEndHeadingDegT-runway.LowEndHeadingDegT-180) > 0.5 {
+		t.Errorf("got headings %.1f/%.1f", runway.LowEndHeadingDegT, runway.HighEndHeadingDegT)
+	}
+	if runway.LowEndDisplacedThresholdFt != 0 || runway.HighEndDisplacedThresholdFt != 957 {
+		t.Errorf("got displaced thresholds %d/%d ft", runway.LowEndDisplacedThresholdFt, runway.HighEndDisplacedThresholdFt)
+	}
+	if runway.LowEndLatitudeDeg != 33.935898 || runway.HighEndLongitudeDeg != -118.3831 || runway.LowEndElevationFt != 125 {
+		t.Errorf("unexpected runway ends %+v", runway)
+	}
+	ksmo := databases.Airports.FindByICAOCode("KSMO")
+	if runway := databases.Runways.FindByAirportID(ksmo.ID)[0]; runway.Surface != "ASP" || !runway.Lighted {
+		t.Errorf("unexpected runway %+v", runway)
+	}
+	kxyz := databases.Airports.FindByICAOCode("KXYZ")
+	if runway := databases.Runways.FindByAirportID(kxyz.ID)[0]; runway.Surface != "TURF" || runway.Lighted {
+		t.Errorf("unexpected runway %+v", runway)
+	}
+	w55 := databases.Airports.FindByICAOCode("W55")
+	if runway := databases.Runways.FindByAirportID(w55.ID)[0]; runway.Surface != "WATER" || runway.LowEndIdent != "04" || runway.HighEndIdent != "22" || runway.WidthFt != 100 {
+		t.Errorf("unexpected water runway %+v", runway)
+	}
+	ca38 := databases.Airports.FindByICAOCode("CA38")
+	if pad := databases.Runways.FindByAirportID(ca38.ID)[0]; pad.LowEndIdent != "H1" || pad.LengthFt != 40 || pad.WidthFt != 40 || !pad.Lighted {
+		t.Errorf("unexpected helipad %+v", pad)
+	}
+}
+
+func TestParseXPlaneFrequencies(t *testing.T) {
+	databases, err := ParseXPlane("testdata/xplane/apt.dat", AirportTypeAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		icao        string
+		frequencies map[string]float64
+	}{
+		// 1050-1056 in kHz
+		{"KLAX", map[string]float64{"ATIS": 133.8, "CLD": 120.35, "GND": 121.65, "TWR": 133.9, "APP": 124.3, "DEP": 125.2}},
+		// 50-56 in 10 kHz
+		{"KSMO", map[string]float64{"ATIS": 119.15, "UNIC": 123.2, "GND": 121.9, "TWR": 120.7}},
+	}
+	for _, test := range tests {
+		airport := databases.Airports.FindByICAOCode(test.icao)
+		frequencies := databases.Frequencies.FindByAirportID(airport.ID)
+		if len(frequencies) != len(test.frequencies) {
+			t.Errorf("%s: got %d frequencies, want %d", test.icao, len(frequencies), len(test.frequencies))
+		}
+		for _, frequency := range frequencies {
+			if want, ok := test.frequencies[frequency.Type]; !ok || math.Abs(frequency.FrequencyMHZ-want) > 1e-9 {
+				t.Errorf("%s: got %s %f MHz", test.icao, frequency.Type, frequency.FrequencyMHZ)
+			}
+		}
+	}
+}
+
+func TestParseXPlaneFilter(t *testing.T) {
+	databases, err := ParseXPlane("testdata/xplane/apt.dat", AirportTypeRunways)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(databases.Airports.Airports) != 2 || len(databases.Runways.Runways) != 2 || len(databases.Frequencies.Frequencies) != 2 {
+		t.Errorf("got %d airports, %d with runways, %d with frequencies", len(databases.Airports.Airports), len(databases.Runways.Runways), len(databases.Frequencies.Frequencies))
+	}
+}